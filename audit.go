@@ -0,0 +1,24 @@
+package main
+
+// Audited entity types
+// Teams, users and pull requests are audited by the review service
+const (
+	auditEntityAPIToken = "api_token"
+
+	auditEntityWebhookSubscription = "webhook_subscription"
+	auditEntityWebhookDelivery     = "webhook_delivery"
+	auditEntityExternalAccount     = "external_account"
+)
+
+// Audited actions
+const (
+	auditActionTokenCreate   = "token.create"
+	auditActionTokenRevoke   = "token.revoke"
+	auditActionWebhookCreate = "webhook.create"
+	auditActionWebhookUpdate = "webhook.update"
+	auditActionWebhookDelete = "webhook.delete"
+	auditActionDeliveryRetry = "webhook.delivery_retry"
+
+	auditActionExternalAccountSet    = "external_account.set"
+	auditActionExternalAccountDelete = "external_account.delete"
+)
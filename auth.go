@@ -0,0 +1,117 @@
+package main
+
+import (
+	"GODanilich/avito_backend/internal/database"
+	"GODanilich/avito_backend/internal/service"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+const (
+	apiTokenPrefix      = "avt_"            // Prefix that makes leaked tokens easy to recognise
+	bootstrapTokenName  = "bootstrap-admin" // Name of the token created from ADMIN_TOKEN
+	apiTokenRandomBytes = 32                // Entropy of generated tokens
+)
+
+// principal is the authenticated caller of a request
+type principal struct {
+	TokenName string
+	Role      database.TokenRole
+	UserID    string // Set for user tokens, optional for admin tokens
+}
+
+// isAdmin reports whether the caller has the admin role
+func (p principal) isAdmin() bool {
+	return p.Role == database.TokenRoleAdmin
+}
+
+// actor returns the caller as seen by the review service
+func (p principal) actor() service.Actor {
+	return service.Actor{Name: p.TokenName, UserID: p.UserID, Admin: p.isAdmin()}
+}
+
+type principalContextKey struct{}
+
+// principalFromContext returns the caller stored by the authenticate middleware
+func principalFromContext(ctx context.Context) principal {
+	p, _ := ctx.Value(principalContextKey{}).(principal)
+	return p
+}
+
+// hashAPIToken returns the hex encoded SHA-256 of a token
+// Only hashes are stored, so a database leak doesn't expose usable tokens
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateAPIToken creates a new random token
+func generateAPIToken() (string, error) {
+	buf := make([]byte, apiTokenRandomBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return apiTokenPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// bootstrapAdminToken stores the ADMIN_TOKEN from the environment as an admin token
+// Changing ADMIN_TOKEN rotates the bootstrap token on the next start
+func bootstrapAdminToken(ctx context.Context, q database.Querier, token string) error {
+	return q.EnsureAPIToken(ctx, database.EnsureAPITokenParams{
+		Name:      bootstrapTokenName,
+		TokenHash: hashAPIToken(token),
+		Role:      database.TokenRoleAdmin,
+	})
+}
+
+// authenticate resolves the bearer token of a request to a principal
+// Requests without a valid, non-revoked token are rejected with 401
+func (api *apiConfig) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		// Extract the bearer token from the Authorization header
+		scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			respondWithError(w, codeUnauthorized, "bearer token is required")
+			return
+		}
+
+		// Look up the token by its hash
+		apiToken, err := api.DB.GetActiveAPIToken(r.Context(), hashAPIToken(strings.TrimSpace(token)))
+		if err == sql.ErrNoRows {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			respondWithError(w, codeUnauthorized, "invalid or revoked token")
+			return
+		}
+		if err != nil {
+			respondWithAPIError(w, err)
+			return
+		}
+
+		caller := principal{
+			TokenName: apiToken.Name,
+			Role:      apiToken.Role,
+			UserID:    apiToken.UserID.String,
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalContextKey{}, caller)))
+	})
+}
+
+// requireAdmin rejects callers without the admin role with 403
+// It must be mounted after authenticate
+func requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !principalFromContext(r.Context()).isAdmin() {
+			respondWithError(w, codeForbidden, "admin role required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"GODanilich/avito_backend/internal/database"
+	"GODanilich/avito_backend/internal/service"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// PR lifecycle changes requested by code host webhooks
+const (
+	codeHostActionCreate = "create"
+	codeHostActionMerge  = "merge"
+	codeHostActionClose  = "close"
+	codeHostActionReopen = "reopen"
+	codeHostActionRename = "rename"
+	codeHostActionIgnore = "ignore"
+)
+
+// codeHostPREvent is a pull or merge request change reported by a code host
+type codeHostPREvent struct {
+	Provider    string // External account provider, e.g. github
+	Action      string // One of the codeHostAction constants
+	PRID        string // ID the PR is stored under
+	Title       string // Current title of the PR
+	AuthorLogin string // Login of the PR author on the code host
+	ProjectPath string // Repository or project the PR belongs to
+}
+
+// errUnknownAccount is returned when a code host login has no external account mapping
+var errUnknownAccount = errors.New("unknown external account")
+
+// applyCodeHostPREvent runs the lifecycle operation requested by event
+// Changes are made with admin rights and audited as webhook:<provider>
+func (api *apiConfig) applyCodeHostPREvent(ctx context.Context, event codeHostPREvent) (PullRequest, error) {
+	actor := service.Actor{Name: "webhook:" + event.Provider, Admin: true}
+
+	switch event.Action {
+	case codeHostActionCreate:
+		// Resolve the author through the external account mapping
+		authorID, err := api.DB.GetExternalAccountUser(ctx, database.GetExternalAccountUserParams{
+			Provider: event.Provider,
+			Login:    normalizeExternalLogin(event.AuthorLogin),
+		})
+		if err == sql.ErrNoRows {
+			return PullRequest{}, &service.Error{Kind: errUnknownAccount,
+				Message: fmt.Sprintf("%s login %q is not mapped to a user", event.Provider, event.AuthorLogin)}
+		}
+		if err != nil {
+			return PullRequest{}, err
+		}
+
+		pr, err := api.reviews.CreatePR(ctx, actor, service.NewPR{
+			PullRequestID:   event.PRID,
+			PullRequestName: event.Title,
+			AuthorID:        authorID,
+			ProjectPath:     event.ProjectPath,
+		})
+		if errors.Is(err, service.ErrPRExists) {
+			// Redelivery of an event that was already applied
+			return api.reviews.GetPR(ctx, event.PRID)
+		}
+		return pr, err
+	case codeHostActionMerge:
+		// The merge already happened on the code host, so the approval rule isn't enforced
+		return api.reviews.MergePR(ctx, actor, event.PRID, false)
+	case codeHostActionClose:
+		return api.reviews.ClosePR(ctx, actor, event.PRID)
+	case codeHostActionReopen:
+		result, err := api.reviews.ReopenPR(ctx, actor, event.PRID)
+		return result.PR, err
+	case codeHostActionRename:
+		return api.reviews.RenamePR(ctx, actor, event.PRID, event.Title)
+	}
+	return PullRequest{}, fmt.Errorf("unsupported code host action %q", event.Action)
+}
+
+// respondToCodeHostPREvent applies event and writes the webhook response
+// Ignored events are acknowledged with 202 so that the code host doesn't retry them
+func (api *apiConfig) respondToCodeHostPREvent(w http.ResponseWriter, r *http.Request, event codeHostPREvent) {
+	if event.Action == codeHostActionIgnore {
+		respondWithJSON(w, http.StatusAccepted, map[string]string{"action": event.Action})
+		return
+	}
+
+	pr, err := api.applyCodeHostPREvent(r.Context(), event)
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"action": event.Action,
+		"pr":     pr,
+	})
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"GODanilich/avito_backend/internal/storage"
+	"GODanilich/avito_backend/internal/storage/memory"
+	"GODanilich/avito_backend/internal/storage/postgres"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stressStores returns the stores the concurrency tests run against
+// PostgreSQL is used as well when TEST_DB_URL points to a migrated database
+func stressStores(t *testing.T) map[string]storage.Store {
+	t.Helper()
+	stores := map[string]storage.Store{"memory": memory.New()}
+
+	if dbURL := os.Getenv("TEST_DB_URL"); dbURL != "" {
+		conn, err := sql.Open("postgres", dbURL)
+		if err != nil {
+			t.Fatalf("open TEST_DB_URL: %v", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		stores["postgres"] = postgres.New(conn, nil)
+	}
+	return stores
+}
+
+// stressPrefix makes names unique, so runs against a shared database don't collide
+func stressPrefix() string {
+	return fmt.Sprintf("stress-%d-", time.Now().UnixNano())
+}
+
+// runConcurrently sends the requests built by request from n goroutines at once
+// and returns the responses in request order
+func runConcurrently(handler http.Handler, n int, request func(i int) (method, path, body string)) []*httptest.ResponseRecorder {
+	responses := make([]*httptest.ResponseRecorder, n)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			method, path, body := request(i)
+			<-start
+			responses[i] = doRequest(handler, method, path, testAdminToken, body)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	return responses
+}
+
+// checkReviewers asserts the reviewer invariants of a PR: no duplicates,
+// never the author and only members of the author's team
+func checkReviewers(t *testing.T, pr map[string]interface{}, author string, team []string, want int) {
+	t.Helper()
+	raw, _ := pr["assigned_reviewers"].([]interface{})
+	seen := map[string]bool{}
+	for _, value := range raw {
+		reviewer := value.(string)
+		switch {
+		case seen[reviewer]:
+			t.Errorf("reviewer %s is assigned twice: %v", reviewer, raw)
+		case reviewer == author:
+			t.Errorf("author %s is assigned as reviewer: %v", author, raw)
+		case !slices.Contains(team, reviewer):
+			t.Errorf("reviewer %s is not in the author's team: %v", reviewer, raw)
+		}
+		seen[reviewer] = true
+	}
+	if len(raw) != want {
+		t.Errorf("%d reviewers assigned, want %d: %v", len(raw), want, raw)
+	}
+}
+
+func countAuditEvents(t *testing.T, handler http.Handler, action, entityID string) int {
+	t.Helper()
+	query := url.Values{"action": {action}, "entity_id": {entityID}, "limit": {"500"}}
+	body := mustRequest(t, handler, http.MethodGet, "/api/v1/audit/list?"+query.Encode(), "", http.StatusOK)
+	events, _ := body["events"].([]interface{})
+	return len(events)
+}
+
+// Concurrent creates of the same PR id produce exactly one PR, the rest get PR_EXISTS
+func TestConcurrentCreatePR(t *testing.T) {
+	for name, store := range stressStores(t) {
+		t.Run(name, func(t *testing.T) {
+			handler := newTestAPIWithStore(t, store)
+			prefix := stressPrefix()
+			team := []string{prefix + "u1", prefix + "u2", prefix + "u3", prefix + "u4"}
+			mustRequest(t, handler, http.MethodPost, "/api/v1/team/add", teamBody(prefix+"team", team), http.StatusCreated)
+
+			prID := prefix + "pr"
+			responses := runConcurrently(handler, 20, func(i int) (string, string, string) {
+				return http.MethodPost, "/api/v1/pullRequest/create",
+					fmt.Sprintf(`{"pull_request_id":%q,"pull_request_name":"attempt %d","author_id":%q}`, prID, i, team[0])
+			})
+
+			created := 0
+			for _, response := range responses {
+				switch response.Code {
+				case http.StatusCreated:
+					created++
+				case http.StatusConflict:
+					if code := responseErrorCode(decodeBody(t, response)); code != string(codePRExists) {
+						t.Errorf("conflict code = %s, want PR_EXISTS", code)
+					}
+				default:
+					t.Errorf("status = %d, body: %s", response.Code, response.Body)
+				}
+			}
+			if created != 1 {
+				t.Fatalf("%d PRs created, want 1", created)
+			}
+
+			pr := mustRequest(t, handler, http.MethodGet, "/api/v1/pullRequest/get?pull_request_id="+url.QueryEscape(prID), "", http.StatusOK)
+			checkReviewers(t, pr["pr"].(map[string]interface{}), team[0], team, 2)
+			if got := countAuditEvents(t, handler, "pr.create", prID); got != 1 {
+				t.Errorf("pr.create audit events = %d, want 1", got)
+			}
+		})
+	}
+}
+
+// Concurrent reassigns on one PR never assign a reviewer twice or lose a reviewer
+func TestConcurrentReassign(t *testing.T) {
+	for name, store := range stressStores(t) {
+		t.Run(name, func(t *testing.T) {
+			handler := newTestAPIWithStore(t, store)
+			prefix := stressPrefix()
+			var team []string
+			for i := 1; i <= 6; i++ {
+				team = append(team, fmt.Sprintf("%su%d", prefix, i))
+			}
+			mustRequest(t, handler, http.MethodPost, "/api/v1/team/add", teamBody(prefix+"team", team), http.StatusCreated)
+
+			prID := prefix + "pr"
+			created := mustRequest(t, handler, http.MethodPost, "/api/v1/pullRequest/create",
+				fmt.Sprintf(`{"pull_request_id":%q,"pull_request_name":"x","author_id":%q}`, prID, team[0]), http.StatusCreated)
+			initial := created["pr"].(map[string]interface{})["assigned_reviewers"].([]interface{})
+
+			// Every request replaces one of the initial reviewers, most of them race each other
+			responses := runConcurrently(handler, 40, func(i int) (string, string, string) {
+				return http.MethodPost, "/api/v1/pullRequest/reassign",
+					fmt.Sprintf(`{"pull_request_id":%q,"old_reviewer_id":%q}`, prID, initial[i%len(initial)])
+			})
+
+			reassigned := 0
+			for _, response := range responses {
+				body := decodeBody(t, response)
+				switch response.Code {
+				case http.StatusOK:
+					reassigned++
+					checkReviewers(t, body["pr"].(map[string]interface{}), team[0], team, len(initial))
+				case http.StatusConflict:
+					if code := responseErrorCode(body); code != string(codeNotAssigned) && code != string(codeNoCandidate) {
+						t.Errorf("conflict code = %s, want NOT_ASSIGNED or NO_CANDIDATE", code)
+					}
+				default:
+					t.Errorf("status = %d, body: %s", response.Code, response.Body)
+				}
+			}
+			// A replaced reviewer may be picked again later, so any number of requests can win
+			if reassigned == 0 {
+				t.Errorf("no reassign succeeded")
+			}
+
+			pr := mustRequest(t, handler, http.MethodGet, "/api/v1/pullRequest/get?pull_request_id="+url.QueryEscape(prID), "", http.StatusOK)
+			checkReviewers(t, pr["pr"].(map[string]interface{}), team[0], team, len(initial))
+			if got := countAuditEvents(t, handler, "pr.reassign", prID); got != reassigned {
+				t.Errorf("pr.reassign audit events = %d, want %d", got, reassigned)
+			}
+		})
+	}
+}
+
+// Creating PRs, reassigning and deactivating reviewers at the same time keeps every PR consistent
+func TestConcurrentMixedLoad(t *testing.T) {
+	for name, store := range stressStores(t) {
+		t.Run(name, func(t *testing.T) {
+			handler := newTestAPIWithStore(t, store)
+			prefix := stressPrefix()
+			var team []string
+			for i := 1; i <= 10; i++ {
+				team = append(team, fmt.Sprintf("%su%d", prefix, i))
+			}
+			mustRequest(t, handler, http.MethodPost, "/api/v1/team/add", teamBody(prefix+"team", team), http.StatusCreated)
+
+			// Authors and the users that get deactivated never overlap
+			authors, leaving := team[:5], team[8:]
+			const prs = 30
+			responses := runConcurrently(handler, prs*3, func(i int) (string, string, string) {
+				prID := fmt.Sprintf("%spr-%d", prefix, i%prs)
+				switch i / prs {
+				case 0:
+					return http.MethodPost, "/api/v1/pullRequest/create",
+						fmt.Sprintf(`{"pull_request_id":%q,"pull_request_name":"x","author_id":%q}`, prID, authors[i%len(authors)])
+				case 1:
+					return http.MethodPost, "/api/v1/pullRequest/reassign",
+						fmt.Sprintf(`{"pull_request_id":%q,"old_reviewer_id":%q}`, prID, team[5+i%5])
+				default:
+					return http.MethodPost, "/api/v1/team/deactivateUsers",
+						fmt.Sprintf(`{"team_name":%q,"user_ids":[%q]}`, prefix+"team", leaving[i%len(leaving)])
+				}
+			})
+
+			for _, response := range responses {
+				// Reassigns may run before the PR exists or find nobody to replace
+				if response.Code >= http.StatusInternalServerError {
+					t.Errorf("status = %d, body: %s", response.Code, response.Body)
+				}
+			}
+
+			active := team[:8]
+			for i := 0; i < prs; i++ {
+				prID := fmt.Sprintf("%spr-%d", prefix, i)
+				body := mustRequest(t, handler, http.MethodGet, "/api/v1/pullRequest/get?pull_request_id="+url.QueryEscape(prID), "", http.StatusOK)
+				pr := body["pr"].(map[string]interface{})
+				checkReviewers(t, pr, pr["author_id"].(string), active, 2)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// envReader reads optional settings from the environment
+// The first invalid value is kept in err, so callers check it once at the end
+type envReader struct {
+	err error
+}
+
+// int returns the integer value of name, def when it is unset
+func (e *envReader) int(name string, def int) int {
+	value := os.Getenv(name)
+	if value == "" || e.err != nil {
+		return def
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		e.err = fmt.Errorf("%s must be a non-negative integer, got %q", name, value)
+		return def
+	}
+	return parsed
+}
+
+// duration returns the duration value of name (e.g. 15s, 2m), def when it is unset
+func (e *envReader) duration(name string, def time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" || e.err != nil {
+		return def
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed < 0 {
+		e.err = fmt.Errorf("%s must be a non-negative duration like 15s, got %q", name, value)
+		return def
+	}
+	return parsed
+}
+
+// serverConfig holds the HTTP server timeouts
+type serverConfig struct {
+	ReadHeaderTimeout time.Duration // HTTP_READ_HEADER_TIMEOUT, guards against slow clients
+	ReadTimeout       time.Duration // HTTP_READ_TIMEOUT, for the whole request including the body
+	WriteTimeout      time.Duration // HTTP_WRITE_TIMEOUT, from the end of the request headers to the end of the response
+	IdleTimeout       time.Duration // HTTP_IDLE_TIMEOUT, for keep-alive connections
+	ShutdownTimeout   time.Duration // SHUTDOWN_TIMEOUT, how long in-flight requests may take to finish on SIGTERM
+}
+
+func loadServerConfig() (serverConfig, error) {
+	env := &envReader{}
+	cfg := serverConfig{
+		ReadHeaderTimeout: env.duration("HTTP_READ_HEADER_TIMEOUT", 5*time.Second),
+		ReadTimeout:       env.duration("HTTP_READ_TIMEOUT", 15*time.Second),
+		WriteTimeout:      env.duration("HTTP_WRITE_TIMEOUT", 30*time.Second),
+		IdleTimeout:       env.duration("HTTP_IDLE_TIMEOUT", 2*time.Minute),
+		ShutdownTimeout:   env.duration("SHUTDOWN_TIMEOUT", 25*time.Second),
+	}
+	return cfg, env.err
+}
+
+// poolConfig holds the database connection pool settings
+type poolConfig struct {
+	MaxOpenConns    int           // DB_MAX_OPEN_CONNS, 0 means unlimited
+	MaxIdleConns    int           // DB_MAX_IDLE_CONNS
+	ConnMaxLifetime time.Duration // DB_CONN_MAX_LIFETIME, 0 keeps connections forever
+	ConnMaxIdleTime time.Duration // DB_CONN_MAX_IDLE_TIME, 0 keeps idle connections forever
+	ConnectTimeout  time.Duration // DB_CONNECT_TIMEOUT, how long to wait for the database at startup
+}
+
+func loadPoolConfig() (poolConfig, error) {
+	env := &envReader{}
+	cfg := poolConfig{
+		MaxOpenConns:    env.int("DB_MAX_OPEN_CONNS", 25),
+		MaxIdleConns:    env.int("DB_MAX_IDLE_CONNS", 25),
+		ConnMaxLifetime: env.duration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+		ConnMaxIdleTime: env.duration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+		ConnectTimeout:  env.duration("DB_CONNECT_TIMEOUT", 30*time.Second),
+	}
+	return cfg, env.err
+}
+
+// apply sets the pool limits on conn
+func (cfg poolConfig) apply(conn *sql.DB) {
+	conn.SetMaxOpenConns(cfg.MaxOpenConns)
+	conn.SetMaxIdleConns(cfg.MaxIdleConns)
+	conn.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	conn.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+}
+
+// Backoff between database pings at startup
+const (
+	pingInitialBackoff = 250 * time.Millisecond
+	pingMaxBackoff     = 5 * time.Second
+)
+
+// pinger is the part of *sql.DB used to wait for the database
+type pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// waitForDatabase pings db until it answers or ctx is done
+// The database often starts together with the service, so failed pings are
+// retried with exponential backoff instead of failing the startup
+func waitForDatabase(ctx context.Context, db pinger) error {
+	backoff := pingInitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+
+		log.Printf("Database is not ready (attempt %d), retrying in %v: %v", attempt, backoff, err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database is not ready after %d attempts: %w", attempt, err)
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, pingMaxBackoff)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLoadPoolConfig(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		cfg, err := loadPoolConfig()
+		if err != nil {
+			t.Fatalf("loadPoolConfig: %v", err)
+		}
+		if cfg.MaxOpenConns != 25 || cfg.ConnMaxLifetime != 30*time.Minute {
+			t.Errorf("defaults = %+v", cfg)
+		}
+	})
+
+	t.Run("from environment", func(t *testing.T) {
+		t.Setenv("DB_MAX_OPEN_CONNS", "50")
+		t.Setenv("DB_MAX_IDLE_CONNS", "10")
+		t.Setenv("DB_CONN_MAX_LIFETIME", "1h")
+		cfg, err := loadPoolConfig()
+		if err != nil {
+			t.Fatalf("loadPoolConfig: %v", err)
+		}
+		if cfg.MaxOpenConns != 50 || cfg.MaxIdleConns != 10 || cfg.ConnMaxLifetime != time.Hour {
+			t.Errorf("config = %+v", cfg)
+		}
+	})
+
+	for name, value := range map[string]string{"DB_MAX_OPEN_CONNS": "many", "DB_CONN_MAX_IDLE_TIME": "-5m"} {
+		t.Run("invalid "+name, func(t *testing.T) {
+			t.Setenv(name, value)
+			if _, err := loadPoolConfig(); err == nil {
+				t.Errorf("%s=%s accepted", name, value)
+			}
+		})
+	}
+}
+
+func TestLoadServerConfig(t *testing.T) {
+	t.Setenv("HTTP_WRITE_TIMEOUT", "1m")
+	t.Setenv("SHUTDOWN_TIMEOUT", "10s")
+	cfg, err := loadServerConfig()
+	if err != nil {
+		t.Fatalf("loadServerConfig: %v", err)
+	}
+	if cfg.WriteTimeout != time.Minute || cfg.ShutdownTimeout != 10*time.Second || cfg.ReadHeaderTimeout != 5*time.Second {
+		t.Errorf("config = %+v", cfg)
+	}
+
+	t.Setenv("HTTP_IDLE_TIMEOUT", "30")
+	if _, err := loadServerConfig(); err == nil {
+		t.Errorf("duration without a unit accepted")
+	}
+}
+
+// flakyDB fails the first pings, like a database that is still starting
+type flakyDB struct {
+	failures int
+	pings    int
+}
+
+func (db *flakyDB) PingContext(ctx context.Context) error {
+	db.pings++
+	if db.pings <= db.failures {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func TestWaitForDatabase(t *testing.T) {
+	t.Run("retries until the database answers", func(t *testing.T) {
+		db := &flakyDB{failures: 2}
+		if err := waitForDatabase(context.Background(), db); err != nil {
+			t.Fatalf("waitForDatabase: %v", err)
+		}
+		if db.pings != 3 {
+			t.Errorf("pinged %d times, want 3", db.pings)
+		}
+	})
+
+	t.Run("gives up when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		db := &flakyDB{failures: 1000}
+		if err := waitForDatabase(ctx, db); err == nil {
+			t.Fatalf("waitForDatabase succeeded with a database that never answers")
+		}
+	})
+}
@@ -0,0 +1,454 @@
+package main
+
+import (
+	"GODanilich/avito_backend/internal/service"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ctlUsage documents the admin commands, main prints it as part of usage
+const ctlUsage = `Admin commands:
+  team add -f FILE                       create a team from a YAML or JSON file (- reads stdin),
+                                         fails with TEAM_EXISTS if it exists, use POST /import to update
+  team get TEAM                          show a team with its members
+  user activate USER_ID                  mark a user active
+  user deactivate USER_ID                mark a user inactive
+  pr create --id ID --name NAME --author USER_ID
+  pr merge PR_ID
+  pr reassign PR_ID --old USER_ID        replace a reviewer
+  pr list [--status S] [--author U] [--team T] [--reviewer U] [--q TEXT] [--limit N] [--cursor C]
+  stats [--from RFC3339] [--to RFC3339]
+
+Every admin command accepts:
+  -o, --output table|json   output format (default table)
+  --api URL                 API base URL, e.g. http://localhost:8080/api/v1 (default $AVITO_API_URL)
+  --token TOKEN             API token used with --api (default $AVITO_TOKEN)
+Without --api the command runs directly against DB_URL as an admin.
+`
+
+// ctlRequest is the API call a command makes
+type ctlRequest struct {
+	method string
+	path   string
+	query  url.Values
+	body   interface{}
+	render func(w io.Writer, body []byte) error // Table output of the response
+}
+
+// ctlCommand is an admin subcommand such as `pr merge`
+// setup registers the command flags and returns the function that builds
+// the request once flags and positional arguments are parsed
+type ctlCommand struct {
+	args  int // Number of positional arguments
+	setup func(fs *flag.FlagSet) func(args []string) (ctlRequest, error)
+}
+
+var ctlCommands = map[string]ctlCommand{
+	"team add":        {0, ctlTeamAdd},
+	"team get":        {1, ctlTeamGet},
+	"user activate":   {1, ctlSetActive(true)},
+	"user deactivate": {1, ctlSetActive(false)},
+	"pr create":       {0, ctlPRCreate},
+	"pr merge":        {1, ctlPRMerge},
+	"pr reassign":     {1, ctlPRReassign},
+	"pr list":         {0, ctlPRList},
+	"stats":           {0, ctlStats},
+}
+
+// isCtlCommand reports whether name is the first word of an admin command
+func isCtlCommand(name string) bool {
+	for command := range ctlCommands {
+		if strings.Split(command, " ")[0] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// runCtl runs an admin command, group is its first word and args the rest of the command line
+func runCtl(ctx context.Context, group string, args []string, out io.Writer) error {
+	name := group
+	if group != "stats" {
+		if len(args) == 0 {
+			return fmt.Errorf("%s needs a subcommand\n\n%s", group, ctlUsage)
+		}
+		name, args = group+" "+args[0], args[1:]
+	}
+	command, ok := ctlCommands[name]
+	if !ok {
+		return fmt.Errorf("unknown command %q\n\n%s", name, ctlUsage)
+	}
+
+	// flags shared by every command, then the command's own
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var output, apiURL, token string
+	fs.StringVar(&output, "o", "table", "")
+	fs.StringVar(&output, "output", "table", "")
+	fs.StringVar(&apiURL, "api", os.Getenv("AVITO_API_URL"), "")
+	fs.StringVar(&token, "token", os.Getenv("AVITO_TOKEN"), "")
+	build := command.setup(fs)
+
+	positional, err := parseInterleaved(fs, args)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	if len(positional) != command.args {
+		return fmt.Errorf("%s takes %d argument(s), got %d\n\n%s", name, command.args, len(positional), ctlUsage)
+	}
+	if output != "table" && output != "json" {
+		return fmt.Errorf("output must be table or json, got %q", output)
+	}
+	request, err := build(positional)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	client, err := newCtlClient(ctx, apiURL, token)
+	if err != nil {
+		return err
+	}
+	defer client.close()
+
+	body, err := client.send(ctx, request.method, request.path, request.query, request.body)
+	if err != nil {
+		return err
+	}
+
+	if output == "json" {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, body, "", "  "); err != nil {
+			return err
+		}
+		indented.WriteByte('\n')
+		_, err := indented.WriteTo(out)
+		return err
+	}
+	return request.render(out, body)
+}
+
+// parseInterleaved parses flags that may come before or after positional arguments
+// and returns the positional ones
+func parseInterleaved(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// requireFlags fails when one of the named string flags is empty
+func requireFlags(values map[string]*string) error {
+	var missing []string
+	for name, value := range values {
+		if *value == "" {
+			missing = append(missing, "--"+name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("missing %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func ctlTeamAdd(fs *flag.FlagSet) func([]string) (ctlRequest, error) {
+	file := fs.String("f", "", "")
+	return func([]string) (ctlRequest, error) {
+		if *file == "" {
+			return ctlRequest{}, errors.New("missing -f FILE")
+		}
+		team, err := readTeamFile(*file)
+		if err != nil {
+			return ctlRequest{}, err
+		}
+		return ctlRequest{
+			method: http.MethodPost,
+			path:   "/team/add",
+			body:   team,
+			render: renderJSON(func(w *tabwriter.Writer, body struct {
+				Team  service.Team       `json:"team"`
+				Moved []service.UserMove `json:"moved"`
+			}) {
+				writeTeam(w, body.Team)
+				for _, move := range body.Moved {
+					fmt.Fprintf(w, "\nMoved %s from team %s\n", move.UserID, move.FromTeam)
+				}
+			}),
+		}, nil
+	}
+}
+
+// readTeamFile reads a team in the /team/add format, as YAML when the file
+// name ends in .yaml or .yml and as JSON otherwise
+func readTeamFile(name string) (service.Team, error) {
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return service.Team{}, err
+	}
+
+	// YAML is converted to JSON first, so both formats use the json field names
+	if ext := strings.ToLower(filepath.Ext(name)); ext == ".yaml" || ext == ".yml" {
+		var document interface{}
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return service.Team{}, fmt.Errorf("parse %s: %w", name, err)
+		}
+		if data, err = json.Marshal(document); err != nil {
+			return service.Team{}, fmt.Errorf("parse %s: %w", name, err)
+		}
+	}
+
+	var team service.Team
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&team); err != nil {
+		return service.Team{}, fmt.Errorf("parse %s: %w", name, err)
+	}
+	return team, nil
+}
+
+func ctlTeamGet(fs *flag.FlagSet) func([]string) (ctlRequest, error) {
+	return func(args []string) (ctlRequest, error) {
+		return ctlRequest{
+			method: http.MethodGet,
+			path:   "/team/get",
+			query:  url.Values{"team_name": {args[0]}},
+			render: renderJSON(writeTeam),
+		}, nil
+	}
+}
+
+func ctlSetActive(isActive bool) func(fs *flag.FlagSet) func([]string) (ctlRequest, error) {
+	return func(fs *flag.FlagSet) func([]string) (ctlRequest, error) {
+		return func(args []string) (ctlRequest, error) {
+			return ctlRequest{
+				method: http.MethodPost,
+				path:   "/users/setIsActive",
+				body:   map[string]interface{}{"user_id": args[0], "is_active": isActive},
+				render: renderJSON(func(w *tabwriter.Writer, body struct {
+					User service.User `json:"user"`
+				}) {
+					fmt.Fprintln(w, "USER_ID\tUSERNAME\tTEAM\tACTIVE")
+					fmt.Fprintf(w, "%s\t%s\t%s\t%t\n", body.User.UserID, body.User.Username, body.User.TeamName, body.User.IsActive)
+				}),
+			}, nil
+		}
+	}
+}
+
+// ctlPRResponse is the response of the PR commands that change one PR
+type ctlPRResponse struct {
+	PR         service.PullRequest `json:"pr"`
+	ReplacedBy string              `json:"replaced_by"` // Only set by reassign
+}
+
+func renderPR(w *tabwriter.Writer, body ctlPRResponse) {
+	writePRs(w, []service.PullRequest{body.PR})
+	if body.ReplacedBy != "" {
+		fmt.Fprintf(w, "\nReplaced by %s\n", body.ReplacedBy)
+	}
+}
+
+func ctlPRCreate(fs *flag.FlagSet) func([]string) (ctlRequest, error) {
+	id := fs.String("id", "", "")
+	name := fs.String("name", "", "")
+	author := fs.String("author", "", "")
+	return func([]string) (ctlRequest, error) {
+		if err := requireFlags(map[string]*string{"id": id, "name": name, "author": author}); err != nil {
+			return ctlRequest{}, err
+		}
+		return ctlRequest{
+			method: http.MethodPost,
+			path:   "/pullRequest/create",
+			body:   map[string]string{"pull_request_id": *id, "pull_request_name": *name, "author_id": *author},
+			render: renderJSON(renderPR),
+		}, nil
+	}
+}
+
+func ctlPRMerge(fs *flag.FlagSet) func([]string) (ctlRequest, error) {
+	return func(args []string) (ctlRequest, error) {
+		return ctlRequest{
+			method: http.MethodPost,
+			path:   "/pullRequest/merge",
+			body:   map[string]string{"pull_request_id": args[0]},
+			render: renderJSON(renderPR),
+		}, nil
+	}
+}
+
+func ctlPRReassign(fs *flag.FlagSet) func([]string) (ctlRequest, error) {
+	old := fs.String("old", "", "")
+	return func(args []string) (ctlRequest, error) {
+		if err := requireFlags(map[string]*string{"old": old}); err != nil {
+			return ctlRequest{}, err
+		}
+		return ctlRequest{
+			method: http.MethodPost,
+			path:   "/pullRequest/reassign",
+			body:   map[string]string{"pull_request_id": args[0], "old_reviewer_id": *old},
+			render: renderJSON(renderPR),
+		}, nil
+	}
+}
+
+func ctlPRList(fs *flag.FlagSet) func([]string) (ctlRequest, error) {
+	// flag name -> query parameter of /pullRequest/list
+	filters := map[string]string{
+		"status": "status", "author": "author_id", "team": "team_name", "reviewer": "reviewer_id",
+		"q": "q", "limit": "limit", "cursor": "cursor", "created-from": "created_from", "created-to": "created_to",
+	}
+	values := map[string]*string{}
+	for name := range filters {
+		values[name] = fs.String(name, "", "")
+	}
+	return func([]string) (ctlRequest, error) {
+		query := url.Values{}
+		for name, param := range filters {
+			if *values[name] != "" {
+				query.Set(param, *values[name])
+			}
+		}
+		return ctlRequest{
+			method: http.MethodGet,
+			path:   "/pullRequest/list",
+			query:  query,
+			render: renderJSON(func(w *tabwriter.Writer, body struct {
+				PullRequests []service.PullRequest `json:"pull_requests"`
+				NextCursor   *string               `json:"next_cursor"`
+			}) {
+				writePRs(w, body.PullRequests)
+				if body.NextCursor != nil {
+					fmt.Fprintf(w, "\nMore results: --cursor %s\n", *body.NextCursor)
+				}
+			}),
+		}, nil
+	}
+}
+
+func ctlStats(fs *flag.FlagSet) func([]string) (ctlRequest, error) {
+	from := fs.String("from", "", "")
+	to := fs.String("to", "", "")
+	return func([]string) (ctlRequest, error) {
+		query := url.Values{}
+		if *from != "" {
+			query.Set("from", *from)
+		}
+		if *to != "" {
+			query.Set("to", *to)
+		}
+		return ctlRequest{
+			method: http.MethodGet,
+			path:   "/stats/get",
+			query:  query,
+			render: renderJSON(writeStats),
+		}, nil
+	}
+}
+
+// renderJSON decodes the response body into T and writes it as a table
+func renderJSON[T any](write func(w *tabwriter.Writer, body T)) func(io.Writer, []byte) error {
+	return func(out io.Writer, data []byte) error {
+		var body T
+		if err := json.Unmarshal(data, &body); err != nil {
+			return fmt.Errorf("decode response: %w", err)
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		write(w, body)
+		return w.Flush()
+	}
+}
+
+func writeTeam(w *tabwriter.Writer, team service.Team) {
+	fmt.Fprintf(w, "Team %s\n\n", team.TeamName)
+	fmt.Fprintln(w, "USER_ID\tUSERNAME\tACTIVE")
+	for _, member := range team.Members {
+		fmt.Fprintf(w, "%s\t%s\t%t\n", member.UserID, member.Username, member.IsActive)
+	}
+}
+
+func writePRs(w *tabwriter.Writer, prs []service.PullRequest) {
+	fmt.Fprintln(w, "PR_ID\tNAME\tAUTHOR\tSTATUS\tREVIEWERS\tCREATED")
+	for _, pr := range prs {
+		created := "-"
+		if pr.CreatedAt != nil {
+			created = pr.CreatedAt.Local().Format(time.DateTime)
+		}
+		reviewers := strings.Join(pr.AssignedReviewers, ",")
+		if reviewers == "" {
+			reviewers = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status, reviewers, created)
+	}
+}
+
+func writeStats(w *tabwriter.Writer, stats StatsResponse) {
+	fmt.Fprintln(w, "STATUS\tPRS")
+	for _, s := range stats.PRStats {
+		fmt.Fprintf(w, "%s\t%d\n", s.Status, s.Count)
+	}
+
+	fmt.Fprintln(w, "\nREVIEWER\tASSIGNMENTS")
+	for _, s := range stats.AssignmentStats {
+		fmt.Fprintf(w, "%s\t%d\n", s.UserID, s.Count)
+	}
+
+	for _, section := range []struct {
+		title string
+		stats DurationStats
+	}{
+		{"TIME TO MERGE", stats.MergeTime},
+		{"TIME TO FIRST REVIEW", stats.FirstReviewTime},
+	} {
+		fmt.Fprintf(w, "\n%s\tPRS\tP50\tP90\tP99\n", section.title)
+		for _, s := range section.stats.ByTeam {
+			team := "(no team)"
+			if s.TeamName != nil {
+				team = "team " + *s.TeamName
+			}
+			writePercentiles(w, team, s.DurationPercentiles)
+		}
+		for _, s := range section.stats.ByAuthor {
+			writePercentiles(w, "author "+s.AuthorID, s.DurationPercentiles)
+		}
+	}
+}
+
+func writePercentiles(w *tabwriter.Writer, label string, p DurationPercentiles) {
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", label, strconv.FormatInt(p.Count, 10),
+		formatSeconds(p.P50), formatSeconds(p.P90), formatSeconds(p.P99))
+}
+
+// formatSeconds rounds a duration in seconds for display, e.g. 1h23m0s
+func formatSeconds(seconds float64) string {
+	return (time.Duration(seconds * float64(time.Second))).Round(time.Second).String()
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"GODanilich/avito_backend/internal/database"
+	"GODanilich/avito_backend/internal/service"
+	"GODanilich/avito_backend/internal/storage"
+	"GODanilich/avito_backend/internal/storage/postgres"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ctlTokenName is the actor the audit log records for commands run against the database
+const ctlTokenName = "avitoctl"
+
+// ctlClient sends the CLI commands to the API
+// Over HTTP it authenticates with a token, against the database it runs the
+// API router in process, so both modes share validation, rules and auditing
+type ctlClient struct {
+	http    *http.Client
+	baseURL string
+	token   string
+	close   func() error
+}
+
+// newCtlClient talks to apiURL when it is set and to DB_URL otherwise
+func newCtlClient(ctx context.Context, apiURL, token string) (*ctlClient, error) {
+	if apiURL != "" {
+		if token == "" {
+			return nil, fmt.Errorf("an API token is required with --api, set --token or AVITO_TOKEN")
+		}
+		return &ctlClient{
+			http:    &http.Client{Timeout: 30 * time.Second},
+			baseURL: strings.TrimSuffix(apiURL, "/"),
+			token:   token,
+			close:   func() error { return nil },
+		}, nil
+	}
+
+	conn, err := openDatabase(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("connect to database: %w", err)
+	}
+	store := postgres.New(conn, nil)
+	if err := requireSchema(ctx, store); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	strategy, err := defaultReviewerStrategy()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	client := newInProcessCtlClient(store, strategy)
+	client.close = conn.Close
+	return client, nil
+}
+
+// newInProcessCtlClient runs requests on the API router against store, as an admin
+func newInProcessCtlClient(store storage.Store, strategy service.ReviewerStrategy) *ctlClient {
+	api := &apiConfig{
+		DB:      store,
+		reviews: service.New(storage.NewRepository(store), strategy),
+		metrics: newAppMetrics(),
+	}
+	return &ctlClient{
+		http: &http.Client{Transport: handlerTransport{
+			handler: api.routesWithAuth(asPrincipal(principal{TokenName: ctlTokenName, Role: database.TokenRoleAdmin})),
+		}},
+		baseURL: "http://avitoctl/api/v1",
+		close:   func() error { return nil },
+	}
+}
+
+// asPrincipal replaces authenticate on the in-process router, every request runs as caller
+// It is never mounted on the router the server listens with
+func asPrincipal(caller principal) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalContextKey{}, caller)))
+		})
+	}
+}
+
+// handlerTransport is an http.RoundTripper that serves requests with a handler
+type handlerTransport struct {
+	handler http.Handler
+}
+
+func (t handlerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	response := &bufferedResponse{header: http.Header{}}
+	t.handler.ServeHTTP(response, r)
+	if response.status == 0 {
+		response.status = http.StatusOK
+	}
+	return &http.Response{
+		Status:        http.StatusText(response.status),
+		StatusCode:    response.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        response.header,
+		Body:          io.NopCloser(&response.body),
+		ContentLength: int64(response.body.Len()),
+		Request:       r,
+	}, nil
+}
+
+// send calls the API and returns the response body
+// Error responses are returned as errors with their code and message
+func (c *ctlClient) send(ctx context.Context, method, path string, query url.Values, body interface{}) ([]byte, error) {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode > 299 {
+		var errResponse struct {
+			Error struct {
+				Code    string `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(data, &errResponse) == nil && errResponse.Error.Code != "" {
+			return nil, fmt.Errorf("%s: %s", errResponse.Error.Code, errResponse.Error.Message)
+		}
+		return nil, fmt.Errorf("%s %s: unexpected status %d", method, path, resp.StatusCode)
+	}
+	return data, nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"GODanilich/avito_backend/internal/service"
+	"GODanilich/avito_backend/internal/storage/memory"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testTeamYAML = `team_name: backend
+members:
+  - user_id: u1
+    username: Alice
+    is_active: true
+  - user_id: u2
+    username: Bob
+    is_active: true
+  - user_id: u3
+    username: Carol
+    is_active: true
+`
+
+// runCtlCommand runs an admin command against the API at apiURL and returns its output
+func runCtlCommand(t *testing.T, apiURL string, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	args = append(args, "--api", apiURL, "--token", testAdminToken)
+	err := runCtl(context.Background(), args[0], args[1:], &out)
+	return out.String(), err
+}
+
+func TestCtlCommands(t *testing.T) {
+	server := httptest.NewServer(newTestAPIWithStore(t, memory.New()))
+	defer server.Close()
+	apiURL := server.URL + "/api/v1"
+
+	teamFile := filepath.Join(t.TempDir(), "team.yaml")
+	if err := os.WriteFile(teamFile, []byte(testTeamYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string // Substrings of the output
+		wantErr string   // Substring of the error, empty if none is expected
+	}{
+		{"team add from YAML", []string{"team", "add", "-f", teamFile}, []string{"Team backend", "u2  ", "Bob"}, ""},
+		{"team add existing", []string{"team", "add", "-f", teamFile}, nil, "TEAM_EXISTS"},
+		{"team get", []string{"team", "get", "backend"}, []string{"USER_ID", "Carol"}, ""},
+		{"team get missing", []string{"team", "get", "nope"}, nil, "NOT_FOUND"},
+		{"pr create", []string{"pr", "create", "--id", "pr-1", "--name", "Add search", "--author", "u1"}, []string{"pr-1", "Add search", "OPEN"}, ""},
+		{"pr create without flags", []string{"pr", "create", "--id", "pr-2"}, nil, "missing --author, --name"},
+		{"pr reassign without candidate", []string{"pr", "reassign", "pr-1", "--old", "u2"}, nil, "NO_CANDIDATE"},
+		{"user deactivate", []string{"user", "deactivate", "u3"}, []string{"u3", "Carol", "backend", "false"}, ""},
+		{"pr list", []string{"pr", "list", "--status", "OPEN", "--author", "u1"}, []string{"pr-1", "OPEN"}, ""},
+		{"flags after arguments", []string{"team", "get", "backend", "-o", "json"}, []string{`"team_name": "backend"`}, ""},
+		{"pr merge", []string{"pr", "merge", "pr-1"}, []string{"MERGED"}, ""},
+		{"stats", []string{"stats"}, []string{"MERGED", "TIME TO MERGE", "team backend"}, ""},
+		{"unknown subcommand", []string{"pr", "close", "pr-1"}, nil, "unknown command"},
+		{"missing argument", []string{"pr", "merge"}, nil, "takes 1 argument"},
+		{"bad output", []string{"team", "get", "backend", "-o", "xml"}, nil, "table or json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := runCtlCommand(t, apiURL, tt.args...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("output lacks %q:\n%s", want, out)
+				}
+			}
+		})
+	}
+
+	// The merged PR is no longer listed as OPEN
+	out, _ := runCtlCommand(t, apiURL, "pr", "list", "--status", "OPEN")
+	if strings.Contains(out, "pr-1") {
+		t.Errorf("merged PR listed as OPEN:\n%s", out)
+	}
+}
+
+func TestCtlJSONOutput(t *testing.T) {
+	server := httptest.NewServer(newTestAPIWithStore(t, memory.New()))
+	defer server.Close()
+	mustRequest(t, server.Config.Handler, http.MethodPost, "/api/v1/team/add", teamBody("backend", []string{"u1", "u2"}), http.StatusCreated)
+
+	out, err := runCtlCommand(t, server.URL+"/api/v1", "team", "get", "backend", "--output", "json")
+	if err != nil {
+		t.Fatalf("team get: %v", err)
+	}
+	var team service.Team
+	if err := json.Unmarshal([]byte(out), &team); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out)
+	}
+	if team.TeamName != "backend" || len(team.Members) != 2 {
+		t.Errorf("team = %+v", team)
+	}
+}
+
+func TestReadTeamFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	for _, path := range []string{
+		write("team.yml", testTeamYAML),
+		write("team.json", `{"team_name":"backend","members":[{"user_id":"u1","username":"Alice","is_active":true},{"user_id":"u2","username":"Bob","is_active":true},{"user_id":"u3","username":"Carol","is_active":true}]}`),
+	} {
+		team, err := readTeamFile(path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if team.TeamName != "backend" || len(team.Members) != 3 || team.Members[1].Username != "Bob" {
+			t.Errorf("%s: team = %+v", path, team)
+		}
+	}
+
+	// Misspelled fields are rejected instead of silently dropped
+	if _, err := readTeamFile(write("typo.yaml", "team_name: backend\nmember: []\n")); err == nil {
+		t.Errorf("unknown field accepted")
+	}
+}
+
+// Commands against the database run on the router in process, as the avitoctl admin
+func TestInProcessCtlClient(t *testing.T) {
+	store := memory.New()
+	client := newInProcessCtlClient(store, service.StrategyLeastLoaded)
+	ctx := context.Background()
+
+	team := service.Team{TeamName: "backend", Members: []service.TeamMember{{UserID: "u1", Username: "Alice", IsActive: true}}}
+	if _, err := client.send(ctx, http.MethodPost, "/team/add", nil, team); err != nil {
+		t.Fatalf("team add: %v", err)
+	}
+	if _, err := client.send(ctx, http.MethodGet, "/team/get", map[string][]string{"team_name": {"nope"}}, nil); err == nil || !strings.Contains(err.Error(), "NOT_FOUND") {
+		t.Errorf("missing team: error = %v, want NOT_FOUND", err)
+	}
+
+	body, err := client.send(ctx, http.MethodGet, "/audit/list", nil, nil)
+	if err != nil {
+		t.Fatalf("audit list: %v", err)
+	}
+	if !strings.Contains(string(body), `"actor":"`+ctlTokenName+`"`) {
+		t.Errorf("audit events don't name the CLI as actor: %s", body)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"GODanilich/avito_backend/internal/service"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/lib/pq"
+)
+
+// errorCode is a value of the ErrorResponse code enum in openapi.yml
+type errorCode string
+
+const (
+	codeBadRequest       errorCode = "BAD_REQUEST"
+	codeInvalidUserID    errorCode = "INVALID_USER_ID"
+	codeInvalidUsername  errorCode = "INVALID_USERNAME"
+	codeTeamExists       errorCode = "TEAM_EXISTS"
+	codeUnauthorized     errorCode = "UNAUTHORIZED"
+	codeForbidden        errorCode = "FORBIDDEN"
+	codeNotFound         errorCode = "NOT_FOUND"
+	codePRExists         errorCode = "PR_EXISTS"
+	codePRMerged         errorCode = "PR_MERGED"
+	codePRClosed         errorCode = "PR_CLOSED"
+	codeNotApproved      errorCode = "NOT_APPROVED"
+	codeNotAssigned      errorCode = "NOT_ASSIGNED"
+	codeNoCandidate      errorCode = "NO_CANDIDATE"
+	codeTokenExists      errorCode = "TOKEN_EXISTS"
+	codeAlreadyExists    errorCode = "ALREADY_EXISTS"
+	codeUnknownAccount   errorCode = "UNKNOWN_ACCOUNT"
+	codeInvalidReference errorCode = "INVALID_REFERENCE"
+	codeDBError          errorCode = "DB_ERROR"
+	codeInternal         errorCode = "INTERNAL"
+)
+
+// errorStatuses is the catalogue of error codes with the HTTP status each one is sent with
+var errorStatuses = map[errorCode]int{
+	codeBadRequest:       http.StatusBadRequest,
+	codeInvalidUserID:    http.StatusBadRequest,
+	codeInvalidUsername:  http.StatusBadRequest,
+	codeTeamExists:       http.StatusBadRequest,
+	codeUnauthorized:     http.StatusUnauthorized,
+	codeForbidden:        http.StatusForbidden,
+	codeNotFound:         http.StatusNotFound,
+	codePRExists:         http.StatusConflict,
+	codePRMerged:         http.StatusConflict,
+	codePRClosed:         http.StatusConflict,
+	codeNotApproved:      http.StatusConflict,
+	codeNotAssigned:      http.StatusConflict,
+	codeNoCandidate:      http.StatusConflict,
+	codeTokenExists:      http.StatusConflict,
+	codeAlreadyExists:    http.StatusConflict,
+	codeUnknownAccount:   http.StatusUnprocessableEntity,
+	codeInvalidReference: http.StatusUnprocessableEntity,
+	codeDBError:          http.StatusInternalServerError,
+	codeInternal:         http.StatusInternalServerError,
+}
+
+// status returns the HTTP status of the code, codes missing from the catalogue are server errors
+func (c errorCode) status() int {
+	if status, ok := errorStatuses[c]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// APIError is an error response
+// Message is sent to the client, Err is the cause and only ever goes to the log
+type APIError struct {
+	Code    errorCode
+	Message string
+	Err     error
+}
+
+func newAPIError(code errorCode, message string) *APIError {
+	return &APIError{Code: code, Message: message}
+}
+
+func (e *APIError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Status returns the HTTP status the error is sent with
+func (e *APIError) Status() int {
+	return e.Code.status()
+}
+
+// serviceErrorCodes maps domain errors of the review service to error codes
+var serviceErrorCodes = []struct {
+	err  error
+	code errorCode
+}{
+	{service.ErrTeamExists, codeTeamExists},
+	{service.ErrInvalidInput, codeBadRequest},
+	{service.ErrForbidden, codeForbidden},
+	{service.ErrPRNotFound, codeNotFound},
+	{service.ErrTeamNotFound, codeNotFound},
+	{service.ErrUserNotFound, codeNotFound},
+	{service.ErrAuthorNotFound, codeNotFound},
+	{service.ErrAuthorNoTeam, codeNotFound},
+	{service.ErrPRExists, codePRExists},
+	{service.ErrPRMerged, codePRMerged},
+	{service.ErrPRClosed, codePRClosed},
+	{service.ErrNotApproved, codeNotApproved},
+	{service.ErrNotAssigned, codeNotAssigned},
+	{service.ErrNoCandidate, codeNoCandidate},
+	{errUnknownAccount, codeUnknownAccount},
+}
+
+// SQLSTATE codes of constraint violations worth telling the client about
+const (
+	sqlStateForeignKeyViolation = "23503"
+	sqlStateUniqueViolation     = "23505"
+)
+
+// toAPIError classifies err for the client
+// Domain errors keep their message, constraint violations get a generic one
+// and anything else is reported as DB_ERROR without details
+func toAPIError(err error) *APIError {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	for _, known := range serviceErrorCodes {
+		if errors.Is(err, known.err) {
+			return &APIError{Code: known.code, Message: err.Error(), Err: err}
+		}
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case sqlStateUniqueViolation:
+			return &APIError{Code: codeAlreadyExists, Message: "resource already exists", Err: err}
+		case sqlStateForeignKeyViolation:
+			return &APIError{Code: codeInvalidReference, Message: "referenced resource does not exist", Err: err}
+		}
+	}
+
+	return &APIError{Code: codeDBError, Message: "internal error", Err: err}
+}
+
+// respondWithAPIError writes err as an error response
+// Server errors are logged with their cause, which is never sent to the client
+func respondWithAPIError(w http.ResponseWriter, err error) {
+	apiErr := toAPIError(err)
+	if apiErr.Status() > 499 && apiErr.Err != nil {
+		log.Printf("Responding with %v error: %v", apiErr.Status(), apiErr.Err)
+	}
+	writeAPIError(w, apiErr)
+}
+
+// respondWithError writes an error response with a message safe to show to the client
+func respondWithError(w http.ResponseWriter, code errorCode, msg string) {
+	apiErr := newAPIError(code, msg)
+	if apiErr.Status() > 499 {
+		log.Printf("Responding with %v error: %v", apiErr.Status(), msg)
+	}
+	writeAPIError(w, apiErr)
+}
+
+func writeAPIError(w http.ResponseWriter, apiErr *APIError) {
+	type errResponse struct {
+		Code    errorCode `json:"code"`
+		Message string    `json:"message"`
+	}
+	response := struct {
+		Error errResponse `json:"error"`
+	}{
+		Error: errResponse{
+			Code:    apiErr.Code,
+			Message: apiErr.Message,
+		},
+	}
+	respondWithJSON(w, apiErr.Status(), response)
+}
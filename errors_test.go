@@ -0,0 +1,132 @@
+package main
+
+import (
+	"GODanilich/avito_backend/internal/service"
+	"GODanilich/avito_backend/internal/storage"
+	"GODanilich/avito_backend/internal/storage/memory"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/lib/pq"
+)
+
+func TestRespondWithAPIError(t *testing.T) {
+	uniqueErr := &pq.Error{Code: sqlStateUniqueViolation, Message: `duplicate key value violates unique constraint "teams_pkey"`, Constraint: "teams_pkey"}
+	foreignKeyErr := &pq.Error{Code: sqlStateForeignKeyViolation, Message: `insert or update on table "users" violates foreign key constraint "users_team_name_fkey"`}
+
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		code    errorCode
+		message string // Expected message, checked when not empty
+	}{
+		{"api error", newAPIError(codeForbidden, "admin role required"), http.StatusForbidden, codeForbidden, "admin role required"},
+		{"domain error", fmt.Errorf("%w: PR id already exists", service.ErrPRExists), http.StatusConflict, codePRExists, ""},
+		{"unique violation", fmt.Errorf("cannot insert team: %w", uniqueErr), http.StatusConflict, codeAlreadyExists, "resource already exists"},
+		{"foreign key violation", foreignKeyErr, http.StatusUnprocessableEntity, codeInvalidReference, "referenced resource does not exist"},
+		{"other pq error", &pq.Error{Code: "40001", Message: "could not serialize access"}, http.StatusInternalServerError, codeDBError, "internal error"},
+		{"driver error", sql.ErrConnDone, http.StatusInternalServerError, codeDBError, "internal error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			respondWithAPIError(rec, tt.err)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			body := decodeBody(t, rec)
+			if got := responseErrorCode(body); got != string(tt.code) {
+				t.Errorf("code = %q, want %q", got, tt.code)
+			}
+			if message, _ := field(body, "error", "message").(string); tt.message != "" && message != tt.message {
+				t.Errorf("message = %q, want %q", message, tt.message)
+			}
+
+			// Database internals stay in the log
+			for _, internal := range []string{"constraint", "serialize", "sql:"} {
+				if strings.Contains(rec.Body.String(), internal) {
+					t.Errorf("response leaks %q: %s", internal, rec.Body)
+				}
+			}
+		})
+	}
+}
+
+// brokenTxStore is a memory store that can't start transactions
+type brokenTxStore struct {
+	*memory.Store
+	err error
+}
+
+func (s *brokenTxStore) BeginTx(ctx context.Context) (storage.Tx, error) {
+	return nil, s.err
+}
+
+// Handlers report database failures through the catalogue, the cause is logged
+func TestHandlersLogDatabaseErrors(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	cause := &pq.Error{Code: "53300", Message: "too many connections for role"}
+	handler := newTestAPIWithStore(t, &brokenTxStore{Store: memory.New(), err: cause})
+
+	runAPICases(t, handler, []apiCase{
+		{name: "create token", method: http.MethodPost, path: "/api/v1/auth/tokens/create", body: `{"name":"ci","role":"admin"}`,
+			status: http.StatusInternalServerError, code: string(codeDBError), check: expectField([]string{"error", "message"}, "internal error")},
+		{name: "create webhook", method: http.MethodPost, path: "/api/v1/webhooks/subscriptions/create", body: `{"url":"https://example.com/hook"}`,
+			status: http.StatusInternalServerError, code: string(codeDBError), check: expectField([]string{"error", "message"}, "internal error")},
+	})
+	if !strings.Contains(logs.String(), "too many connections") {
+		t.Errorf("cause not logged: %s", logs.String())
+	}
+}
+
+func TestAPIErrorUnwrap(t *testing.T) {
+	cause := errors.New("connection reset")
+	err := fmt.Errorf("handler: %w", &APIError{Code: codeDBError, Message: "internal error", Err: cause})
+
+	if !errors.Is(err, cause) {
+		t.Errorf("errors.Is(%v, cause) = false", err)
+	}
+	if got := toAPIError(err); got.Code != codeDBError || got.Err != cause {
+		t.Errorf("toAPIError = %+v, want the wrapped APIError", got)
+	}
+}
+
+// Every code in the catalogue has to be documented in openapi.yml
+func TestErrorCatalogueMatchesSpec(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData(openAPISpec)
+	if err != nil {
+		t.Fatalf("load openapi.yml: %v", err)
+	}
+
+	documented := map[string]bool{}
+	schema := doc.Components.Schemas["ErrorResponse"].Value.Properties["error"].Value.Properties["code"].Value
+	for _, value := range schema.Enum {
+		documented[value.(string)] = true
+	}
+
+	for code := range errorStatuses {
+		if !documented[string(code)] {
+			t.Errorf("error code %s is missing from the ErrorResponse enum", code)
+		}
+	}
+	for code := range documented {
+		if _, ok := errorStatuses[errorCode(code)]; !ok {
+			t.Errorf("documented error code %s is missing from errorStatuses", code)
+		}
+	}
+}
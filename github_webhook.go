@@ -0,0 +1,129 @@
+package main
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	githubSignatureHeader = "X-Hub-Signature-256"
+	githubEventHeader     = "X-GitHub-Event"
+	githubMaxPayloadBytes = 5 << 20 // Larger deliveries are rejected, PR events are far smaller
+)
+
+// githubPullRequestEvent is the part of a GitHub pull_request webhook payload we use
+type githubPullRequestEvent struct {
+	Action      string `json:"action"` // opened, closed, reopened, edited, ...
+	PullRequest struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+		Merged bool   `json:"merged"`
+		User   struct {
+			Login string `json:"login"`
+		} `json:"user"` // Author of the PR
+	} `json:"pull_request"`
+	Repository struct {
+		FullName string `json:"full_name"` // owner/repo
+	} `json:"repository"`
+	Changes struct {
+		Title *struct {
+			From string `json:"from"`
+		} `json:"title"` // Set when an edit changed the title
+	} `json:"changes"`
+}
+
+// prID returns the ID GitHub PRs are stored under, e.g. github:owner/repo#42
+func (e githubPullRequestEvent) prID() string {
+	return fmt.Sprintf("%s:%s#%d", externalProviderGitHub, e.Repository.FullName, e.PullRequest.Number)
+}
+
+// prAction maps the event to a PR lifecycle change
+func (e githubPullRequestEvent) prAction() string {
+	switch e.Action {
+	case "opened":
+		return codeHostActionCreate
+	case "closed":
+		if e.PullRequest.Merged {
+			return codeHostActionMerge
+		}
+		return codeHostActionClose
+	case "reopened":
+		return codeHostActionReopen
+	case "edited":
+		if e.Changes.Title != nil {
+			return codeHostActionRename
+		}
+	}
+	return codeHostActionIgnore
+}
+
+// codeHostEvent converts the payload to a provider independent event
+func (e githubPullRequestEvent) codeHostEvent() codeHostPREvent {
+	return codeHostPREvent{
+		Provider:    externalProviderGitHub,
+		Action:      e.prAction(),
+		PRID:        e.prID(),
+		Title:       e.PullRequest.Title,
+		AuthorLogin: e.PullRequest.User.Login,
+		ProjectPath: e.Repository.FullName,
+	}
+}
+
+// parseGitHubPullRequestEvent decodes and validates a pull_request payload
+func parseGitHubPullRequestEvent(body []byte) (githubPullRequestEvent, error) {
+	var event githubPullRequestEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return event, errors.New("invalid json")
+	}
+	if event.PullRequest.Number < 1 || event.Repository.FullName == "" {
+		return event, errors.New("pull_request.number and repository.full_name are required")
+	}
+	return event, nil
+}
+
+// verifyGitHubSignature checks the X-Hub-Signature-256 header against the body
+func verifyGitHubSignature(secret string, body []byte, signature string) bool {
+	return signature != "" && hmac.Equal([]byte(signature), []byte(signWebhookPayload(secret, body)))
+}
+
+// handlerGitHubWebhook handles pull_request webhooks sent by GitHub
+// Opened PRs are created with reviewers, merged and closed PRs are merged or
+// closed, reopened PRs are reopened and title edits rename the PR.
+// The PR author is resolved through the github external account mapping
+func (api *apiConfig) handlerGitHubWebhook(w http.ResponseWriter, r *http.Request) {
+	// Read the raw body, the signature covers the exact bytes
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, githubMaxPayloadBytes))
+	if err != nil {
+		respondWithError(w, codeBadRequest, "cannot read body")
+		return
+	}
+
+	// Only deliveries signed with the shared secret are accepted
+	if !verifyGitHubSignature(api.GitHubWebhookSecret, body, r.Header.Get(githubSignatureHeader)) {
+		respondWithError(w, codeUnauthorized, "invalid signature")
+		return
+	}
+
+	switch r.Header.Get(githubEventHeader) {
+	case "ping":
+		// Sent once when the webhook is configured
+		respondWithJSON(w, http.StatusOK, map[string]string{"action": "pong"})
+		return
+	case "pull_request":
+	default:
+		respondWithJSON(w, http.StatusAccepted, map[string]string{"action": codeHostActionIgnore})
+		return
+	}
+
+	event, err := parseGitHubPullRequestEvent(body)
+	if err != nil {
+		respondWithError(w, codeBadRequest, err.Error())
+		return
+	}
+
+	api.respondToCodeHostPREvent(w, r, event.codeHostEvent())
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testGitHubSecret = "test-github-secret"
+
+func readGitHubFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", "github", name))
+	if err != nil {
+		t.Fatalf("read fixture %s: %v", name, err)
+	}
+	return body
+}
+
+func TestGitHubPullRequestEventFixtures(t *testing.T) {
+	tests := []struct {
+		fixture string
+		action  string
+		title   string
+	}{
+		{"pull_request_opened.json", codeHostActionCreate, "Add reviewer load balancing"},
+		{"pull_request_closed_merged.json", codeHostActionMerge, "Add reviewer load balancing"},
+		{"pull_request_closed_unmerged.json", codeHostActionClose, "Add reviewer load balancing"},
+		{"pull_request_reopened.json", codeHostActionReopen, "Add reviewer load balancing"},
+		{"pull_request_edited_title.json", codeHostActionRename, "Balance reviewer load"},
+		{"pull_request_edited_body.json", codeHostActionIgnore, "Add reviewer load balancing"},
+		{"pull_request_synchronize.json", codeHostActionIgnore, "Add reviewer load balancing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			event, err := parseGitHubPullRequestEvent(readGitHubFixture(t, tt.fixture))
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if got := event.prAction(); got != tt.action {
+				t.Errorf("prAction() = %q, want %q", got, tt.action)
+			}
+			if got := event.prID(); got != "github:avito-tech/review-bot#42" {
+				t.Errorf("prID() = %q", got)
+			}
+			if event.PullRequest.Title != tt.title {
+				t.Errorf("title = %q, want %q", event.PullRequest.Title, tt.title)
+			}
+			if got := normalizeExternalLogin(event.PullRequest.User.Login); got != "octo-dev" {
+				t.Errorf("login = %q, want octo-dev", got)
+			}
+			if got := event.codeHostEvent().ProjectPath; got != "avito-tech/review-bot" {
+				t.Errorf("project path = %q, want avito-tech/review-bot", got)
+			}
+		})
+	}
+}
+
+func TestParseGitHubPullRequestEventRejectsIncompletePayload(t *testing.T) {
+	if _, err := parseGitHubPullRequestEvent([]byte(`{"action":"opened"}`)); err == nil {
+		t.Error("expected an error for a payload without pull_request and repository")
+	}
+	if _, err := parseGitHubPullRequestEvent([]byte(`not json`)); err == nil {
+		t.Error("expected an error for invalid json")
+	}
+}
+
+func TestVerifyGitHubSignature(t *testing.T) {
+	body := readGitHubFixture(t, "pull_request_opened.json")
+	signature := signWebhookPayload(testGitHubSecret, body)
+
+	if !verifyGitHubSignature(testGitHubSecret, body, signature) {
+		t.Error("valid signature rejected")
+	}
+	if verifyGitHubSignature("other-secret", body, signature) {
+		t.Error("signature with the wrong secret accepted")
+	}
+	if verifyGitHubSignature(testGitHubSecret, append(body, ' '), signature) {
+		t.Error("signature of a modified body accepted")
+	}
+	if verifyGitHubSignature(testGitHubSecret, body, "") {
+		t.Error("missing signature accepted")
+	}
+}
+
+// The cases below are answered before the database is touched
+func TestGitHubWebhookHandlerWithoutDatabase(t *testing.T) {
+	api := &apiConfig{GitHubWebhookSecret: testGitHubSecret}
+	opened := readGitHubFixture(t, "pull_request_opened.json")
+	synchronize := readGitHubFixture(t, "pull_request_synchronize.json")
+
+	tests := []struct {
+		name      string
+		event     string
+		body      []byte
+		signature string
+		status    int
+	}{
+		{"missing signature", "pull_request", opened, "", http.StatusUnauthorized},
+		{"wrong signature", "pull_request", opened, signWebhookPayload("other-secret", opened), http.StatusUnauthorized},
+		{"ping", "ping", []byte(`{"zen":"Keep it logically awesome."}`), "", http.StatusOK},
+		{"unrelated event", "push", []byte(`{}`), "", http.StatusAccepted},
+		{"ignored action", "pull_request", synchronize, "", http.StatusAccepted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signature := tt.signature
+			if signature == "" && tt.status != http.StatusUnauthorized {
+				signature = signWebhookPayload(testGitHubSecret, tt.body)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/github", bytes.NewReader(tt.body))
+			req.Header.Set(githubEventHeader, tt.event)
+			if signature != "" {
+				req.Header.Set(githubSignatureHeader, signature)
+			}
+			rec := httptest.NewRecorder()
+
+			api.handlerGitHubWebhook(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d, body %s", rec.Code, tt.status, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	gitlabTokenHeader      = "X-Gitlab-Token"
+	gitlabEventHeader      = "X-Gitlab-Event"
+	gitlabMergeRequestHook = "Merge Request Hook"
+	gitlabMaxPayloadBytes  = 5 << 20 // Larger deliveries are rejected, MR events are far smaller
+)
+
+// gitlabMergeRequestEvent is the part of a GitLab Merge Request Hook payload we use
+type gitlabMergeRequestEvent struct {
+	ObjectKind string `json:"object_kind"` // merge_request
+	User       struct {
+		Username string `json:"username"`
+	} `json:"user"` // User who triggered the event, the author for open events
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"` // group/project
+	} `json:"project"`
+	ObjectAttributes struct {
+		IID    int    `json:"iid"`    // MR number within the project
+		Title  string `json:"title"`  // Current title
+		Action string `json:"action"` // open, close, reopen, update, merge, approved, ...
+	} `json:"object_attributes"`
+	Changes struct {
+		Title *struct {
+			Previous string `json:"previous"`
+			Current  string `json:"current"`
+		} `json:"title"` // Set when an update changed the title
+	} `json:"changes"`
+}
+
+// prID returns the ID GitLab MRs are stored under, e.g. gitlab:group/project!42
+func (e gitlabMergeRequestEvent) prID() string {
+	return fmt.Sprintf("%s:%s!%d", externalProviderGitLab, e.Project.PathWithNamespace, e.ObjectAttributes.IID)
+}
+
+// prAction maps the event to a PR lifecycle change
+func (e gitlabMergeRequestEvent) prAction() string {
+	switch e.ObjectAttributes.Action {
+	case "open":
+		return codeHostActionCreate
+	case "merge":
+		return codeHostActionMerge
+	case "close":
+		return codeHostActionClose
+	case "reopen":
+		return codeHostActionReopen
+	case "update":
+		if e.Changes.Title != nil {
+			return codeHostActionRename
+		}
+	}
+	return codeHostActionIgnore
+}
+
+// codeHostEvent converts the payload to a provider independent event
+func (e gitlabMergeRequestEvent) codeHostEvent() codeHostPREvent {
+	return codeHostPREvent{
+		Provider:    externalProviderGitLab,
+		Action:      e.prAction(),
+		PRID:        e.prID(),
+		Title:       e.ObjectAttributes.Title,
+		AuthorLogin: e.User.Username,
+		ProjectPath: e.Project.PathWithNamespace,
+	}
+}
+
+// parseGitLabMergeRequestEvent decodes and validates a Merge Request Hook payload
+func parseGitLabMergeRequestEvent(body []byte) (gitlabMergeRequestEvent, error) {
+	var event gitlabMergeRequestEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return event, errors.New("invalid json")
+	}
+	if event.ObjectKind != "merge_request" {
+		return event, errors.New("object_kind must be merge_request")
+	}
+	if event.ObjectAttributes.IID < 1 || event.Project.PathWithNamespace == "" {
+		return event, errors.New("object_attributes.iid and project.path_with_namespace are required")
+	}
+	return event, nil
+}
+
+// verifyGitLabToken compares the X-Gitlab-Token header with the configured token
+func verifyGitLabToken(expected, token string) bool {
+	return token != "" && subtle.ConstantTimeCompare([]byte(expected), []byte(token)) == 1
+}
+
+// handlerGitLabWebhook handles Merge Request Hook webhooks sent by GitLab
+// Opened MRs are created with reviewers, merged and closed MRs are merged or
+// closed, reopened MRs are reopened and title updates rename the PR.
+// The author is resolved through the gitlab external account mapping
+func (api *apiConfig) handlerGitLabWebhook(w http.ResponseWriter, r *http.Request) {
+	// GitLab sends the shared secret as is, there is no body signature
+	if !verifyGitLabToken(api.GitLabWebhookToken, r.Header.Get(gitlabTokenHeader)) {
+		respondWithError(w, codeUnauthorized, "invalid token")
+		return
+	}
+
+	if r.Header.Get(gitlabEventHeader) != gitlabMergeRequestHook {
+		respondWithJSON(w, http.StatusAccepted, map[string]string{"action": codeHostActionIgnore})
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, gitlabMaxPayloadBytes))
+	if err != nil {
+		respondWithError(w, codeBadRequest, "cannot read body")
+		return
+	}
+
+	event, err := parseGitLabMergeRequestEvent(body)
+	if err != nil {
+		respondWithError(w, codeBadRequest, err.Error())
+		return
+	}
+
+	api.respondToCodeHostPREvent(w, r, event.codeHostEvent())
+}
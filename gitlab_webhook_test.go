@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testGitLabToken = "test-gitlab-token"
+
+func readGitLabFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", "gitlab", name))
+	if err != nil {
+		t.Fatalf("read fixture %s: %v", name, err)
+	}
+	return body
+}
+
+func TestGitLabMergeRequestEventFixtures(t *testing.T) {
+	tests := []struct {
+		fixture string
+		action  string
+		title   string
+	}{
+		{"merge_request_open.json", codeHostActionCreate, "Add reviewer load balancing"},
+		{"merge_request_merge.json", codeHostActionMerge, "Add reviewer load balancing"},
+		{"merge_request_close.json", codeHostActionClose, "Add reviewer load balancing"},
+		{"merge_request_reopen.json", codeHostActionReopen, "Add reviewer load balancing"},
+		{"merge_request_update_title.json", codeHostActionRename, "Balance reviewer load"},
+		{"merge_request_update_labels.json", codeHostActionIgnore, "Add reviewer load balancing"},
+		{"merge_request_approved.json", codeHostActionIgnore, "Add reviewer load balancing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			parsed, err := parseGitLabMergeRequestEvent(readGitLabFixture(t, tt.fixture))
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			event := parsed.codeHostEvent()
+			if event.Action != tt.action {
+				t.Errorf("action = %q, want %q", event.Action, tt.action)
+			}
+			if event.PRID != "gitlab:platform/review-bot!17" {
+				t.Errorf("PR id = %q", event.PRID)
+			}
+			if event.Title != tt.title {
+				t.Errorf("title = %q, want %q", event.Title, tt.title)
+			}
+			if event.ProjectPath != "platform/review-bot" {
+				t.Errorf("project path = %q, want platform/review-bot", event.ProjectPath)
+			}
+			if got := normalizeExternalLogin(event.AuthorLogin); got != "octo-dev" {
+				t.Errorf("login = %q, want octo-dev", got)
+			}
+		})
+	}
+}
+
+func TestParseGitLabMergeRequestEventRejectsOtherKinds(t *testing.T) {
+	if _, err := parseGitLabMergeRequestEvent([]byte(`{"object_kind":"push"}`)); err == nil {
+		t.Error("expected an error for a push payload")
+	}
+	if _, err := parseGitLabMergeRequestEvent([]byte(`{"object_kind":"merge_request"}`)); err == nil {
+		t.Error("expected an error for a payload without iid and project")
+	}
+}
+
+// The cases below are answered before the database is touched
+func TestGitLabWebhookHandlerWithoutDatabase(t *testing.T) {
+	api := &apiConfig{GitLabWebhookToken: testGitLabToken}
+	open := readGitLabFixture(t, "merge_request_open.json")
+	approved := readGitLabFixture(t, "merge_request_approved.json")
+
+	tests := []struct {
+		name   string
+		event  string
+		body   []byte
+		token  string
+		status int
+	}{
+		{"missing token", gitlabMergeRequestHook, open, "", http.StatusUnauthorized},
+		{"wrong token", gitlabMergeRequestHook, open, "other-token", http.StatusUnauthorized},
+		{"unrelated event", "Push Hook", []byte(`{"object_kind":"push"}`), testGitLabToken, http.StatusAccepted},
+		{"ignored action", gitlabMergeRequestHook, approved, testGitLabToken, http.StatusAccepted},
+		{"invalid payload", gitlabMergeRequestHook, []byte(`{"object_kind":"note"}`), testGitLabToken, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/gitlab", bytes.NewReader(tt.body))
+			req.Header.Set(gitlabEventHeader, tt.event)
+			if tt.token != "" {
+				req.Header.Set(gitlabTokenHeader, tt.token)
+			}
+			rec := httptest.NewRecorder()
+
+			api.handlerGitLabWebhook(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d, body %s", rec.Code, tt.status, rec.Body.String())
+			}
+		})
+	}
+}
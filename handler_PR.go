@@ -1,35 +1,24 @@
 package main
 
 import (
-	"GODanilich/avito_backend/internal/database"
+	"GODanilich/avito_backend/internal/service"
 	"database/sql"
 	"encoding/json"
-	"math/rand/v2"
 	"net/http"
-	"time"
 )
 
-// mergePrResponseStruct defines the response structure for merged pull requests
-type mergePrResponseStruct struct {
-	PullRequestID     string            `json:"pull_request_id"`    // Unique identifier for the PR
-	PullRequestName   string            `json:"pull_request_name"`  // Name/title of the PR
-	AuthorID          string            `json:"author_id"`          // ID of the PR author
-	Status            database.PrStatus `json:"status"`             // Current status of the PR
-	AssignedReviewers []string          `json:"assigned_reviewers"` // List of reviewer IDs
-	MergedAt          string            `json:"mergedAt"`           // Timestamp when PR was merged
-}
-
 // rPrResponseStruct defines the response structure for reassigned pull requests
 type rPrResponseStruct struct {
-	PullRequestID     string            `json:"pull_request_id"`    // Unique identifier for the PR
-	PullRequestName   string            `json:"pull_request_name"`  // Name/title of the PR
-	AuthorID          string            `json:"author_id"`          // ID of the PR author
-	Status            database.PrStatus `json:"status"`             // Current status of the PR
-	AssignedReviewers []string          `json:"assigned_reviewers"` // List of reviewer IDs
+	PullRequestID     string           `json:"pull_request_id"`    // Unique identifier for the PR
+	PullRequestName   string           `json:"pull_request_name"`  // Name/title of the PR
+	AuthorID          string           `json:"author_id"`          // ID of the PR author
+	Status            service.PRStatus `json:"status"`             // Current status of the PR
+	AssignedReviewers []string         `json:"assigned_reviewers"` // List of reviewer IDs
 }
 
 // handlerCreatePR handles HTTP POST requests to create a new pull request
-// It creates a PR and automatically assigns random reviewers from the author's team
+// It creates a PR and automatically assigns reviewers from the author's team
+// using the reviewer selection policy configured for that team
 func (api *apiConfig) handlerCreatePR(w http.ResponseWriter, r *http.Request) {
 	// Define the expected request parameters
 	var params struct {
@@ -40,100 +29,27 @@ func (api *apiConfig) handlerCreatePR(w http.ResponseWriter, r *http.Request) {
 
 	// Decode JSON request body
 	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		respondWithError(w, 400, "BAD_REQUEST", "invalid json")
+		respondWithError(w, codeBadRequest, "invalid json")
 		return
 	}
 
 	// Validate required fields
 	if params.PullRequestID == "" {
-		respondWithError(w, http.StatusBadRequest, "BAD_REQUEST", "pull_request_id is required")
+		respondWithError(w, codeBadRequest, "pull_request_id is required")
 		return
 	}
 	if params.PullRequestName == "" {
-		respondWithError(w, http.StatusBadRequest, "BAD_REQUEST", "pull_request_name is required")
-		return
-	}
-
-	ctx := r.Context()
-
-	// Check if PR with the same ID already exists
-	if _, err := api.DB.GetPR(ctx, params.PullRequestID); err == nil {
-		respondWithError(w, 409, "PR_EXISTS", "PR id already exists")
-		return
-	} else if err != sql.ErrNoRows {
-		// Database error other than "not found"
-		respondWithError(w, 500, "DB_ERROR", err.Error())
+		respondWithError(w, codeBadRequest, "pull_request_name is required")
 		return
 	}
 
-	// Verify that the author exists
-	author, err := api.DB.GetUserById(ctx, params.AuthorID)
-	if err == sql.ErrNoRows {
-		respondWithError(w, 404, "NOT_FOUND", "author not found")
-		return
-	}
-	if err != nil {
-		respondWithError(w, 500, "DB_ERROR", err.Error())
-		return
-	}
-
-	// Verify that the author belongs to a team
-	if !author.TeamName.Valid {
-		respondWithError(w, 404, "NOT_FOUND", "author has no team")
-		return
-	}
-
-	teamName := author.TeamName
-
-	// Find active reviewers in the same team (excluding the author)
-	candidates, err := api.DB.GetActiveReviewersForTeam(ctx, database.GetActiveReviewersForTeamParams{
-		TeamName: teamName,
-		UserID:   params.AuthorID, // Exclude the author from reviewers
-	})
-	if err != nil {
-		respondWithError(w, 500, "DB_ERROR", err.Error())
-		return
-	}
-
-	// Randomly select 2 reviewers from available candidates
-	reviewers := chooseRandomReviewers(candidates, 2)
-
-	// Start database transaction to ensure atomic operations
-	tx, err := api.dbConn.BeginTx(ctx, nil)
-	if err != nil {
-		respondWithError(w, 500, "DB_ERROR", "cannot begin tx")
-		return
-	}
-	defer tx.Rollback() // Ensure rollback if transaction fails
-
-	qtx := api.DB.WithTx(tx) // Create query interface with transaction
-
-	// Create the pull request in database
-	err = qtx.CreatePR(ctx, database.CreatePRParams{
+	created, err := api.reviews.CreatePR(r.Context(), principalFromContext(r.Context()).actor(), service.NewPR{
 		PullRequestID:   params.PullRequestID,
 		PullRequestName: params.PullRequestName,
 		AuthorID:        params.AuthorID,
 	})
 	if err != nil {
-		respondWithError(w, 500, "DB_ERROR", err.Error())
-		return
-	}
-
-	// Assign selected reviewers to the PR
-	for _, rID := range reviewers {
-		err = qtx.AddReviewer(ctx, database.AddReviewerParams{
-			PullRequestID: params.PullRequestID,
-			UserID:        rID,
-		})
-		if err != nil {
-			respondWithError(w, 500, "DB_ERROR", err.Error())
-			return
-		}
-	}
-
-	// Commit the transaction - all operations succeed
-	if err := tx.Commit(); err != nil {
-		respondWithError(w, 500, "DB_ERROR", err.Error())
+		respondWithAPIError(w, err)
 		return
 	}
 
@@ -145,11 +61,11 @@ func (api *apiConfig) handlerCreatePR(w http.ResponseWriter, r *http.Request) {
 		Status            string   `json:"status"`
 		AssignedReviewers []string `json:"assigned_reviewers"`
 	}{
-		PullRequestID:     params.PullRequestID,
-		PullRequestName:   params.PullRequestName,
-		AuthorID:          params.AuthorID,
-		Status:            "OPEN", // New PRs are created with OPEN status
-		AssignedReviewers: reviewers,
+		PullRequestID:     created.PullRequestID,
+		PullRequestName:   created.PullRequestName,
+		AuthorID:          created.AuthorID,
+		Status:            string(created.Status), // New PRs are created with OPEN status
+		AssignedReviewers: created.AssignedReviewers,
 	}
 
 	// Return 201 Created with PR details
@@ -167,59 +83,31 @@ func (api *apiConfig) handlerMergePR(w http.ResponseWriter, r *http.Request) {
 
 	// Decode JSON request body
 	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		respondWithError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid json")
+		respondWithError(w, codeBadRequest, "invalid json")
 		return
 	}
 
 	// Validate required field
 	if params.PullRequestID == "" {
-		respondWithError(w, http.StatusBadRequest, "BAD_REQUEST", "pull_request_id is required")
+		respondWithError(w, codeBadRequest, "pull_request_id is required")
 		return
 	}
-	ctx := r.Context()
 
-	// Check if PR exists
-	pr, err := api.DB.GetPR(ctx, params.PullRequestID)
+	pr, err := api.reviews.MergePR(r.Context(), principalFromContext(r.Context()).actor(), params.PullRequestID, true)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			respondWithError(w, http.StatusNotFound, "NOT_FOUND", "PR not found")
-		} else {
-			respondWithError(w, 500, "DB_ERROR", err.Error())
-		}
-		return
-	}
-
-	// Update PR status to MERGED if not already merged
-	if pr.Status != "MERGED" {
-		pr, err = api.DB.SetPRMerged(ctx, params.PullRequestID)
-		if err != nil {
-			respondWithError(w, 500, "DB_ERROR", err.Error())
-			return
-		}
-	}
-
-	// Get the list of reviewers assigned to this PR
-	reviewers, err := api.DB.GetPRReviewers(ctx, params.PullRequestID)
-	if err != nil {
-		respondWithError(w, 500, "DB_ERROR", err.Error())
+		respondWithAPIError(w, err)
 		return
 	}
 
 	// Prepare and send success response
 	respondWithJSON(w, http.StatusOK, map[string]interface{}{
-		"pr": mergePrResponseStruct{
-			PullRequestID:     pr.PullRequestID,
-			PullRequestName:   pr.PullRequestName,
-			AuthorID:          pr.AuthorID,
-			Status:            pr.Status,
-			AssignedReviewers: reviewers,
-			MergedAt:          pr.MergedAt.Time.Format(time.RFC3339), // Format timestamp as RFC3339
-		},
+		"pr": pr,
 	})
 }
 
 // handlerReassignPR handles HTTP POST requests to reassign a reviewer on a pull request
-// It replaces an existing reviewer with a new random reviewer from the same team
+// It replaces an existing reviewer with a new reviewer from the same team
+// chosen by the reviewer selection policy configured for that team
 func (api *apiConfig) handlerReassignPR(w http.ResponseWriter, r *http.Request) {
 	var params struct {
 		PullRequestID string `json:"pull_request_id"` // ID of the PR
@@ -228,176 +116,233 @@ func (api *apiConfig) handlerReassignPR(w http.ResponseWriter, r *http.Request)
 
 	// Decode JSON request body
 	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		respondWithError(w, 400, "BAD_REQUEST", "invalid json")
+		respondWithError(w, codeBadRequest, "invalid json")
 		return
 	}
 
 	// Validate required fields
 	if params.PullRequestID == "" {
-		respondWithError(w, http.StatusBadRequest, "BAD_REQUEST", "pull_request_id is required")
+		respondWithError(w, codeBadRequest, "pull_request_id is required")
 		return
 	}
 	if params.OldreviewerID == "" {
-		respondWithError(w, http.StatusBadRequest, "BAD_REQUEST", "old_reviewer_id is required")
+		respondWithError(w, codeBadRequest, "old_reviewer_id is required")
 		return
 	}
 
-	ctx := r.Context()
-
-	// 1. Check if PR exists
-	pr, err := api.DB.GetPR(ctx, params.PullRequestID)
-	if err == sql.ErrNoRows {
-		respondWithError(w, 404, "NOT_FOUND", "PR not found")
-		return
-	}
+	result, err := api.reviews.ReassignReviewer(r.Context(), principalFromContext(r.Context()).actor(), params.PullRequestID, params.OldreviewerID)
 	if err != nil {
-		respondWithError(w, 500, "DB_ERROR", err.Error())
+		respondWithAPIError(w, err)
 		return
 	}
 
-	// Check PR status - cannot reassign on merged PRs
-	if pr.Status == "MERGED" {
-		respondWithError(w, 409, "PR_MERGED", "cannot reassign on merged PR")
-		return
+	// Prepare success response
+	response := struct {
+		PR         rPrResponseStruct `json:"pr"`          // Updated PR information
+		ReplacedBy string            `json:"replaced_by"` // ID of the new reviewer
+	}{
+		PR: rPrResponseStruct{
+			PullRequestID:     result.PR.PullRequestID,
+			PullRequestName:   result.PR.PullRequestName,
+			AuthorID:          result.PR.AuthorID,
+			Status:            result.PR.Status,
+			AssignedReviewers: result.PR.AssignedReviewers,
+		},
+		ReplacedBy: result.ReplacedBy,
 	}
 
-	// 2. Verify that the old reviewer is actually assigned to this PR
-	isAssigned, err := api.DB.IsReviewerAssigned(ctx, database.IsReviewerAssignedParams{
-		PullRequestID: params.PullRequestID,
-		UserID:        params.OldreviewerID,
-	})
-	if err != nil {
-		respondWithError(w, 500, "DB_ERROR", err.Error())
-		return
+	respondWithJSON(w, 200, response)
+}
+
+// handlerClosePR handles HTTP POST requests to close a pull request without merging
+// Closing is idempotent: an already closed PR is returned unchanged
+func (api *apiConfig) handlerClosePR(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		PullRequestID string `json:"pull_request_id"` // ID of the PR to close
 	}
-	if !isAssigned {
-		respondWithError(w, 409, "NOT_ASSIGNED", "reviewer is not assigned to this PR")
+
+	// Decode JSON request body
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, codeBadRequest, "invalid json")
 		return
 	}
 
-	// 3. Load author information to determine team
-	author, err := api.DB.GetUserById(ctx, pr.AuthorID)
-	if err == sql.ErrNoRows {
-		respondWithError(w, 404, "NOT_FOUND", "author not found")
+	// Validate required field
+	if params.PullRequestID == "" {
+		respondWithError(w, codeBadRequest, "pull_request_id is required")
 		return
 	}
+
+	pr, err := api.reviews.ClosePR(r.Context(), principalFromContext(r.Context()).actor(), params.PullRequestID)
 	if err != nil {
-		respondWithError(w, 500, "DB_ERROR", err.Error())
+		respondWithAPIError(w, err)
 		return
 	}
 
-	// Verify author has a team
-	if !author.TeamName.Valid {
-		respondWithError(w, 404, "NOT_FOUND", "author has no team")
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"pr": pr,
+	})
+}
+
+// handlerReopenPR handles HTTP POST requests to reopen a closed pull request
+// Reviewers that became inactive or left the author's team are unassigned,
+// and the PR is topped up to the team's max_reviewers from active teammates.
+// Reopening is idempotent: an already open PR is returned unchanged
+func (api *apiConfig) handlerReopenPR(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		PullRequestID string `json:"pull_request_id"` // ID of the PR to reopen
+	}
+
+	// Decode JSON request body
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, codeBadRequest, "invalid json")
 		return
 	}
 
-	team := author.TeamName
+	// Validate required field
+	if params.PullRequestID == "" {
+		respondWithError(w, codeBadRequest, "pull_request_id is required")
+		return
+	}
 
-	// 4. Find eligible replacement reviewers
-	candidates, err := api.DB.GetEligibleReassignReviewers(ctx, database.GetEligibleReassignReviewersParams{
-		TeamName:      team,
-		UserID:        params.OldreviewerID, // Exclude the old reviewer
-		PullRequestID: params.PullRequestID, // Exclude current reviewers
-	})
+	result, err := api.reviews.ReopenPR(r.Context(), principalFromContext(r.Context()).actor(), params.PullRequestID)
 	if err != nil {
-		respondWithError(w, 500, "DB_ERROR", err.Error())
+		respondWithAPIError(w, err)
 		return
 	}
 
-	// Check if there are available candidates
-	if len(candidates) == 0 {
-		respondWithError(w, 409, "NO_CANDIDATE", "no active replacement candidate in team")
+	respondWithJSON(w, http.StatusOK, result)
+}
+
+// handlerRenamePR handles HTTP POST requests to change the name of a pull request
+func (api *apiConfig) handlerRenamePR(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		PullRequestID   string `json:"pull_request_id"`   // ID of the PR to rename
+		PullRequestName string `json:"pull_request_name"` // New name of the PR
+	}
+
+	// Decode JSON request body
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, codeBadRequest, "invalid json")
 		return
 	}
 
-	// Randomly select one new reviewer
-	newReviewer := chooseRandomReviewers(candidates, 1)[0]
+	// Validate required fields
+	if params.PullRequestID == "" {
+		respondWithError(w, codeBadRequest, "pull_request_id is required")
+		return
+	}
+	if params.PullRequestName == "" {
+		respondWithError(w, codeBadRequest, "pull_request_name is required")
+		return
+	}
 
-	// 5. Transaction: remove old reviewer and add new one
-	tx, err := api.dbConn.BeginTx(ctx, nil)
+	pr, err := api.reviews.RenamePR(r.Context(), principalFromContext(r.Context()).actor(), params.PullRequestID, params.PullRequestName)
 	if err != nil {
-		respondWithError(w, 500, "DB_ERROR", "cannot begin tx")
+		respondWithAPIError(w, err)
 		return
 	}
-	defer tx.Rollback()
 
-	qtx := api.DB.WithTx(tx)
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"pr": pr,
+	})
+}
 
-	// Remove the old reviewer
-	if err := qtx.DeleteReviewer(ctx, database.DeleteReviewerParams{
-		PullRequestID: params.PullRequestID,
-		UserID:        params.OldreviewerID,
-	}); err != nil {
-		respondWithError(w, 500, "DB_ERROR", err.Error())
-		return
+// handlerReviewPR handles HTTP POST requests to record a reviewer's verdict
+// It sets the review state of an assigned reviewer on an OPEN pull request
+func (api *apiConfig) handlerReviewPR(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		PullRequestID string `json:"pull_request_id"` // ID of the PR
+		ReviewerID    string `json:"reviewer_id"`     // ID of the assigned reviewer
+		State         string `json:"state"`           // New review state
 	}
 
-	// Add the new reviewer
-	err = qtx.AddReviewer(ctx, database.AddReviewerParams{
-		PullRequestID: params.PullRequestID,
-		UserID:        newReviewer,
-	})
-	if err != nil {
-		respondWithError(w, 500, "DB_ERROR", err.Error())
+	// Decode JSON request body
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, codeBadRequest, "invalid json")
 		return
 	}
 
-	// Commit the transaction
-	if err := tx.Commit(); err != nil {
-		respondWithError(w, 500, "DB_ERROR", err.Error())
+	// Validate required fields
+	if params.PullRequestID == "" {
+		respondWithError(w, codeBadRequest, "pull_request_id is required")
 		return
 	}
-
-	// 6. Get updated list of reviewers for the response
-	updatedReviewers, err := api.DB.GetPRReviewers(ctx, params.PullRequestID)
+	if params.ReviewerID == "" {
+		respondWithError(w, codeBadRequest, "reviewer_id is required")
+		return
+	}
+	state, err := service.ParseReviewState(params.State)
 	if err != nil {
-		respondWithError(w, 500, "DB_ERROR", err.Error())
+		respondWithError(w, codeBadRequest, "state must be one of PENDING, APPROVED, CHANGES_REQUESTED")
 		return
 	}
 
-	// Get updated PR information
-	pr, err = api.DB.GetPR(ctx, params.PullRequestID)
+	pr, review, err := api.reviews.ReviewPR(r.Context(), principalFromContext(r.Context()).actor(), params.PullRequestID, params.ReviewerID, state)
 	if err != nil {
-		respondWithError(w, 500, "DB_ERROR", err.Error())
+		respondWithAPIError(w, err)
 		return
 	}
 
-	// Prepare success response
 	response := struct {
-		PR         rPrResponseStruct `json:"pr"`          // Updated PR information
-		ReplacedBy string            `json:"replaced_by"` // ID of the new reviewer
+		PR     PullRequest `json:"pr"`     // Reviewed PR
+		Review Review      `json:"review"` // Recorded verdict
 	}{
-		PR: rPrResponseStruct{
-			PullRequestID:     pr.PullRequestID,
-			PullRequestName:   pr.PullRequestName,
-			AuthorID:          pr.AuthorID,
-			Status:            pr.Status,
-			AssignedReviewers: updatedReviewers,
-		},
-		ReplacedBy: newReviewer,
+		PR:     pr,
+		Review: review,
 	}
 
-	respondWithJSON(w, 200, response)
+	respondWithJSON(w, http.StatusOK, response)
 }
 
-// chooseRandomReviewers randomly selects reviewers from the candidate list
-// count: number of reviewers to select
-// Returns: slice of selected reviewer IDs
-func chooseRandomReviewers(candidates []string, count int) []string {
-	n := len(candidates)
-	if n == 0 {
-		return []string{} // No candidates available
+// handlerGetPR handles HTTP GET requests to fetch a single pull request
+// It returns the full PR together with the author's team and reviewer details
+func (api *apiConfig) handlerGetPR(w http.ResponseWriter, r *http.Request) {
+
+	// Extract pull_request_id from query parameters
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		respondWithError(w, codeBadRequest, "pull_request_id is required")
+		return
+	}
+
+	ctx := r.Context()
+
+	// Check if PR exists
+	pr, err := api.DB.GetPR(ctx, prID)
+	if err == sql.ErrNoRows {
+		respondWithError(w, codeNotFound, "PR not found")
+		return
+	}
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	// Load the author to report their team
+	author, err := api.DB.GetUserById(ctx, pr.AuthorID)
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	// Load reviewers with their user data and verdicts
+	reviewers, err := api.DB.GetPRReviewerDetails(ctx, prID)
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
 	}
-	if n <= count {
-		return candidates // Not enough candidates, return all available
+
+	reviewerIDs := make([]string, len(reviewers))
+	for i, reviewer := range reviewers {
+		reviewerIDs[i] = reviewer.UserID
 	}
 
-	// Shuffle the candidates array randomly
-	rand.Shuffle(n, func(i, j int) {
-		candidates[i], candidates[j] = candidates[j], candidates[i]
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"pr": PullRequestDetails{
+			PullRequest: dbPRToPR(pr, reviewerIDs),
+			AuthorTeam:  nullStringToPtr(author.TeamName),
+			Reviewers:   dbReviewerDetailsToReviewerDetails(reviewers),
+		},
 	})
-
-	// Return the first 'count' elements after shuffling
-	return candidates[:count]
 }
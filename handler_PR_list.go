@@ -0,0 +1,170 @@
+package main
+
+import (
+	"GODanilich/avito_backend/internal/database"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPRPageSize = 50  // Page size when limit is not given
+	maxPRPageSize     = 200 // Largest accepted limit
+)
+
+// encodePRCursor builds an opaque keyset cursor from the last PR of a page
+func encodePRCursor(pr database.PullRequest) string {
+	raw := pr.CreatedAt.Time.Format(time.RFC3339Nano) + "|" + pr.PullRequestID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodePRCursor parses a cursor produced by encodePRCursor
+func decodePRCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, "", fmt.Errorf("malformed cursor")
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	return t, id, nil
+}
+
+// escapeLike escapes LIKE wildcards so the name filter is a plain substring match
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// optionalString converts an empty query value to NULL
+func optionalString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+// parseOptionalTime parses an optional RFC3339 query value
+func parseOptionalTime(value string) (sql.NullTime, error) {
+	if value == "" {
+		return sql.NullTime{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return sql.NullTime{}, err
+	}
+	return sql.NullTime{Time: t, Valid: true}, nil
+}
+
+// handlerListPRs handles HTTP GET requests to list and search pull requests
+// Supported filters: status, author_id, team_name, reviewer_id,
+// created_from / created_to (RFC3339) and q (name substring).
+// Results are ordered by created_at descending and paginated with an opaque cursor
+func (api *apiConfig) handlerListPRs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	params := database.ListPRsParams{
+		AuthorID:   optionalString(query.Get("author_id")),
+		TeamName:   optionalString(query.Get("team_name")),
+		ReviewerID: optionalString(query.Get("reviewer_id")),
+		PageLimit:  defaultPRPageSize,
+	}
+
+	// Validate status filter
+	if status := query.Get("status"); status != "" {
+		switch database.PrStatus(status) {
+		case database.PrStatusOPEN, database.PrStatusMERGED, database.PrStatusCLOSED:
+			params.Status = database.NullPrStatus{PrStatus: database.PrStatus(status), Valid: true}
+		default:
+			respondWithError(w, codeBadRequest, "status must be one of OPEN, MERGED, CLOSED")
+			return
+		}
+	}
+
+	// Validate created_at range
+	var err error
+	if params.CreatedFrom, err = parseOptionalTime(query.Get("created_from")); err != nil {
+		respondWithError(w, codeBadRequest, "created_from must be an RFC3339 timestamp")
+		return
+	}
+	if params.CreatedTo, err = parseOptionalTime(query.Get("created_to")); err != nil {
+		respondWithError(w, codeBadRequest, "created_to must be an RFC3339 timestamp")
+		return
+	}
+
+	// Name substring search
+	if q := query.Get("q"); q != "" {
+		params.NameQuery = sql.NullString{String: escapeLike(q), Valid: true}
+	}
+
+	// Validate page size
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxPRPageSize {
+			respondWithError(w, codeBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxPRPageSize))
+			return
+		}
+		params.PageLimit = int32(n)
+	}
+
+	// Continue after the cursor of the previous page
+	if cursor := query.Get("cursor"); cursor != "" {
+		createdAt, id, err := decodePRCursor(cursor)
+		if err != nil {
+			respondWithError(w, codeBadRequest, "invalid cursor")
+			return
+		}
+		params.CursorCreatedAt = sql.NullTime{Time: createdAt, Valid: true}
+		params.CursorID = sql.NullString{String: id, Valid: true}
+	}
+
+	ctx := r.Context()
+
+	prs, err := api.DB.ListPRs(ctx, params)
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	// Load reviewers for the whole page in one query
+	ids := make([]string, len(prs))
+	for i, pr := range prs {
+		ids[i] = pr.PullRequestID
+	}
+	reviewerRows, err := api.DB.GetReviewersForPRs(ctx, ids)
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+	reviewers := map[string][]string{}
+	for _, row := range reviewerRows {
+		reviewers[row.PullRequestID] = append(reviewers[row.PullRequestID], row.UserID)
+	}
+
+	pullRequests := make([]PullRequest, len(prs))
+	for i, pr := range prs {
+		pullRequests[i] = dbPRToPR(pr, reviewers[pr.PullRequestID])
+	}
+
+	// A full page means there may be more results
+	var nextCursor *string
+	if len(prs) == int(params.PageLimit) {
+		cursor := encodePRCursor(prs[len(prs)-1])
+		nextCursor = &cursor
+	}
+
+	response := struct {
+		PullRequests []PullRequest `json:"pull_requests"` // Page of pull requests
+		NextCursor   *string       `json:"next_cursor"`   // Cursor for the next page, null on the last page
+	}{
+		PullRequests: pullRequests,
+		NextCursor:   nextCursor,
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"GODanilich/avito_backend/internal/database"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultAuditPageSize = 50  // Page size when limit is not given
+	maxAuditPageSize     = 500 // Largest accepted limit
+)
+
+// AuditEvent is a recorded state change
+type AuditEvent struct {
+	ID          int64           `json:"id"`            // Event ID, increases with time
+	OccurredAt  time.Time       `json:"occurredAt"`    // When the change was committed
+	Actor       string          `json:"actor"`         // Name of the API token that made the change
+	ActorUserID *string         `json:"actor_user_id"` // User bound to the token, null for admin tokens
+	Action      string          `json:"action"`        // What was done, e.g. pr.reassign
+	EntityType  string          `json:"entity_type"`   // Kind of the changed entity
+	EntityID    string          `json:"entity_id"`     // ID of the changed entity
+	Before      json.RawMessage `json:"before"`        // Snapshot before the change, null on creation
+	After       json.RawMessage `json:"after"`         // Snapshot after the change
+}
+
+func dbAuditEventToAuditEvent(dbEvent database.AuditEvent) AuditEvent {
+	return AuditEvent{
+		ID:          dbEvent.ID,
+		OccurredAt:  dbEvent.OccurredAt,
+		Actor:       dbEvent.Actor,
+		ActorUserID: nullStringToPtr(dbEvent.ActorUserID),
+		Action:      dbEvent.Action,
+		EntityType:  dbEvent.EntityType,
+		EntityID:    dbEvent.EntityID,
+		Before:      dbEvent.Before,
+		After:       dbEvent.After,
+	}
+}
+
+// encodeAuditCursor builds an opaque keyset cursor from the last event of a page
+func encodeAuditCursor(event database.AuditEvent) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(event.ID, 10)))
+}
+
+// decodeAuditCursor parses a cursor produced by encodeAuditCursor
+func decodeAuditCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(string(raw), 10, 64)
+}
+
+// handlerListAuditEvents handles HTTP GET requests to browse the audit log
+// Supported filters: entity_type, entity_id, action, actor and from / to (RFC3339).
+// Results are ordered newest first and paginated with an opaque cursor
+func (api *apiConfig) handlerListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	params := database.ListAuditEventsParams{
+		EntityType: optionalString(query.Get("entity_type")),
+		EntityID:   optionalString(query.Get("entity_id")),
+		Action:     optionalString(query.Get("action")),
+		Actor:      optionalString(query.Get("actor")),
+		PageLimit:  defaultAuditPageSize,
+	}
+
+	// Validate time range
+	var err error
+	if params.OccurredFrom, err = parseOptionalTime(query.Get("from")); err != nil {
+		respondWithError(w, codeBadRequest, "from must be an RFC3339 timestamp")
+		return
+	}
+	if params.OccurredTo, err = parseOptionalTime(query.Get("to")); err != nil {
+		respondWithError(w, codeBadRequest, "to must be an RFC3339 timestamp")
+		return
+	}
+
+	// Validate page size
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxAuditPageSize {
+			respondWithError(w, codeBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxAuditPageSize))
+			return
+		}
+		params.PageLimit = int32(n)
+	}
+
+	// Continue after the cursor of the previous page
+	if cursor := query.Get("cursor"); cursor != "" {
+		id, err := decodeAuditCursor(cursor)
+		if err != nil {
+			respondWithError(w, codeBadRequest, "invalid cursor")
+			return
+		}
+		params.CursorID = sql.NullInt64{Int64: id, Valid: true}
+	}
+
+	events, err := api.DB.ListAuditEvents(r.Context(), params)
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	auditEvents := make([]AuditEvent, len(events))
+	for i, event := range events {
+		auditEvents[i] = dbAuditEventToAuditEvent(event)
+	}
+
+	// A full page means there may be more results
+	var nextCursor *string
+	if len(events) == int(params.PageLimit) {
+		cursor := encodeAuditCursor(events[len(events)-1])
+		nextCursor = &cursor
+	}
+
+	response := struct {
+		Events     []AuditEvent `json:"events"`      // Page of audit events
+		NextCursor *string      `json:"next_cursor"` // Cursor for the next page, null on the last page
+	}{
+		Events:     auditEvents,
+		NextCursor: nextCursor,
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
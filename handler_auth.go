@@ -0,0 +1,191 @@
+package main
+
+import (
+	"GODanilich/avito_backend/internal/database"
+	"GODanilich/avito_backend/internal/service"
+	"GODanilich/avito_backend/internal/storage"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// APIToken describes an API token without its secret value
+type APIToken struct {
+	Name      string             `json:"name"`      // Token name
+	Role      database.TokenRole `json:"role"`      // Token role
+	UserID    *string            `json:"user_id"`   // User the token acts as
+	CreatedAt time.Time          `json:"createdAt"` // Creation time
+	RevokedAt *time.Time         `json:"revokedAt"` // Revocation time, null while active
+}
+
+func dbAPITokenToAPIToken(dbToken database.ApiToken) APIToken {
+	return APIToken{
+		Name:      dbToken.Name,
+		Role:      dbToken.Role,
+		UserID:    nullStringToPtr(dbToken.UserID),
+		CreatedAt: dbToken.CreatedAt,
+		RevokedAt: nullTimeToPtr(dbToken.RevokedAt),
+	}
+}
+
+// handlerCreateToken handles HTTP POST requests to issue a new API token
+// The plain token is returned only once, the service keeps just its hash
+func (apiCFG *apiConfig) handlerCreateToken(w http.ResponseWriter, r *http.Request) {
+
+	// requestBody defines the structure of the expected JSON request
+	type requestBody struct {
+		Name   string `json:"name"`    // Unique token name used for revocation
+		Role   string `json:"role"`    // admin or user
+		UserID string `json:"user_id"` // User the token acts as, required for the user role
+	}
+
+	// Decode the JSON request body into the params struct
+	params := requestBody{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, codeBadRequest, "invalid json")
+		return
+	}
+
+	// Validate required fields
+	if params.Name == "" {
+		respondWithError(w, codeBadRequest, "name is required")
+		return
+	}
+	role := database.TokenRole(params.Role)
+	if role != database.TokenRoleAdmin && role != database.TokenRoleUser {
+		respondWithError(w, codeBadRequest, "role must be one of admin, user")
+		return
+	}
+	if role == database.TokenRoleUser && params.UserID == "" {
+		respondWithError(w, codeBadRequest, "user_id is required for user tokens")
+		return
+	}
+
+	ctx := r.Context()
+
+	// Verify that the user exists
+	if params.UserID != "" {
+		_, err := apiCFG.DB.GetUserById(ctx, params.UserID)
+		if err == sql.ErrNoRows {
+			respondWithError(w, codeNotFound, "user not found")
+			return
+		}
+		if err != nil {
+			respondWithAPIError(w, err)
+			return
+		}
+	}
+
+	// Generate the token and store its hash
+	token, err := generateAPIToken()
+	if err != nil {
+		respondWithError(w, codeInternal, "failed to generate token")
+		return
+	}
+	// Start a transaction so the token and its audit event commit together
+	tx, err := apiCFG.DB.BeginTx(ctx)
+	if err != nil {
+		respondWithAPIError(w, fmt.Errorf("cannot begin tx: %w", err))
+		return
+	}
+	defer tx.Rollback()
+
+	apiToken, err := tx.CreateAPIToken(ctx, database.CreateAPITokenParams{
+		Name:      params.Name,
+		TokenHash: hashAPIToken(token),
+		Role:      role,
+		UserID:    optionalString(params.UserID),
+	})
+	if err == sql.ErrNoRows {
+		// Nothing was inserted because the name is taken
+		respondWithError(w, codeTokenExists, "token name already exists")
+		return
+	}
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	// Record the new token in the audit log, the snapshot never contains the secret
+	if err := service.RecordAudit(ctx, storage.NewTxRepository(tx), principalFromContext(ctx).actor(), auditActionTokenCreate, auditEntityAPIToken, apiToken.Name, nil, dbAPITokenToAPIToken(apiToken)); err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	response := struct {
+		APIToken
+		Token string `json:"token"` // Plain token, shown only once
+	}{
+		APIToken: dbAPITokenToAPIToken(apiToken),
+		Token:    token,
+	}
+
+	// Return 201 Created with the new token
+	respondWithJSON(w, http.StatusCreated, response)
+}
+
+// handlerRevokeToken handles HTTP POST requests to revoke an API token by name
+func (apiCFG *apiConfig) handlerRevokeToken(w http.ResponseWriter, r *http.Request) {
+
+	// requestBody defines the structure of the expected JSON request
+	type requestBody struct {
+		Name string `json:"name"` // Name of the token to revoke
+	}
+
+	// Decode the JSON request body into the params struct
+	params := requestBody{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, codeBadRequest, "invalid json")
+		return
+	}
+	if params.Name == "" {
+		respondWithError(w, codeBadRequest, "name is required")
+		return
+	}
+
+	ctx := r.Context()
+
+	// Start a transaction so the revocation and its audit event commit together
+	tx, err := apiCFG.DB.BeginTx(ctx)
+	if err != nil {
+		respondWithAPIError(w, fmt.Errorf("cannot begin tx: %w", err))
+		return
+	}
+	defer tx.Rollback()
+
+	// Revoke the token, no row means unknown or already revoked
+	revoked, err := tx.RevokeAPIToken(ctx, params.Name)
+	if err == sql.ErrNoRows {
+		respondWithError(w, codeNotFound, "active token not found")
+		return
+	}
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	// Record the revocation in the audit log
+	before := dbAPITokenToAPIToken(revoked)
+	before.RevokedAt = nil
+	if err := service.RecordAudit(ctx, storage.NewTxRepository(tx), principalFromContext(ctx).actor(), auditActionTokenRevoke, auditEntityAPIToken, revoked.Name, before, dbAPITokenToAPIToken(revoked)); err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	// Return 200 OK with the revoked token
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"token": dbAPITokenToAPIToken(revoked),
+	})
+}
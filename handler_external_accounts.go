@@ -0,0 +1,225 @@
+package main
+
+import (
+	"GODanilich/avito_backend/internal/database"
+	"GODanilich/avito_backend/internal/service"
+	"GODanilich/avito_backend/internal/storage"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Code hosts whose accounts can be mapped to users
+const (
+	externalProviderGitHub = "github"
+	externalProviderGitLab = "gitlab"
+)
+
+// externalAccountProviders lists the accepted provider values
+var externalAccountProviders = []string{externalProviderGitHub, externalProviderGitLab}
+
+// ExternalAccount maps a code host login to a user
+type ExternalAccount struct {
+	Provider  string    `json:"provider"`  // Code host, e.g. github
+	Login     string    `json:"login"`     // Login on the code host, lower case
+	UserID    string    `json:"user_id"`   // Mapped user
+	CreatedAt time.Time `json:"createdAt"` // When the mapping was first created
+}
+
+func dbExternalAccountToExternalAccount(dbAccount database.ExternalAccount) ExternalAccount {
+	return ExternalAccount{
+		Provider:  dbAccount.Provider,
+		Login:     dbAccount.Login,
+		UserID:    dbAccount.UserID,
+		CreatedAt: dbAccount.CreatedAt,
+	}
+}
+
+// normalizeExternalLogin lower-cases a login, code host logins are case-insensitive
+func normalizeExternalLogin(login string) string {
+	return strings.ToLower(strings.TrimSpace(login))
+}
+
+// validateExternalProvider accepts known code hosts
+func validateExternalProvider(provider string) error {
+	if !slices.Contains(externalAccountProviders, provider) {
+		return fmt.Errorf("provider must be one of %s", strings.Join(externalAccountProviders, ", "))
+	}
+	return nil
+}
+
+// handlerSetExternalAccount handles HTTP POST requests to map a code host login to a user
+// An existing mapping of the login is replaced
+func (api *apiConfig) handlerSetExternalAccount(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		Provider string `json:"provider"` // Code host
+		Login    string `json:"login"`    // Login on the code host
+		UserID   string `json:"user_id"`  // User to map the login to
+	}
+
+	// Decode JSON request body
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, codeBadRequest, "invalid json")
+		return
+	}
+
+	// Validate request fields
+	if err := validateExternalProvider(params.Provider); err != nil {
+		respondWithError(w, codeBadRequest, err.Error())
+		return
+	}
+	params.Login = normalizeExternalLogin(params.Login)
+	if params.Login == "" {
+		respondWithError(w, codeBadRequest, "login is required")
+		return
+	}
+	if params.UserID == "" {
+		respondWithError(w, codeBadRequest, "user_id is required")
+		return
+	}
+
+	ctx := r.Context()
+
+	// Transaction: store the mapping together with its audit event
+	tx, err := api.DB.BeginTx(ctx)
+	if err != nil {
+		respondWithAPIError(w, fmt.Errorf("cannot begin tx: %w", err))
+		return
+	}
+	defer tx.Rollback()
+
+	// Verify that the user exists
+	if _, err := tx.GetUserById(ctx, params.UserID); err == sql.ErrNoRows {
+		respondWithError(w, codeNotFound, "user not found")
+		return
+	} else if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	// Remember the previous mapping for the audit log
+	var before interface{}
+	previousUserID, err := tx.GetExternalAccountUser(ctx, database.GetExternalAccountUserParams{
+		Provider: params.Provider,
+		Login:    params.Login,
+	})
+	if err == nil {
+		before = map[string]string{"user_id": previousUserID}
+	} else if err != sql.ErrNoRows {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	account, err := tx.UpsertExternalAccount(ctx, database.UpsertExternalAccountParams{
+		Provider: params.Provider,
+		Login:    params.Login,
+		UserID:   params.UserID,
+	})
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	mapped := dbExternalAccountToExternalAccount(account)
+	if err := service.RecordAudit(ctx, storage.NewTxRepository(tx), principalFromContext(ctx).actor(), auditActionExternalAccountSet, auditEntityExternalAccount, account.Provider+":"+account.Login, before, mapped); err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"account": mapped,
+	})
+}
+
+// handlerListExternalAccounts handles HTTP GET requests to list login mappings
+// The optional provider query parameter limits the list to one code host
+func (api *apiConfig) handlerListExternalAccounts(w http.ResponseWriter, r *http.Request) {
+	provider := r.URL.Query().Get("provider")
+	if provider != "" {
+		if err := validateExternalProvider(provider); err != nil {
+			respondWithError(w, codeBadRequest, err.Error())
+			return
+		}
+	}
+
+	dbAccounts, err := api.DB.ListExternalAccounts(r.Context(), optionalString(provider))
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	accounts := make([]ExternalAccount, len(dbAccounts))
+	for i, account := range dbAccounts {
+		accounts[i] = dbExternalAccountToExternalAccount(account)
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"accounts": accounts,
+	})
+}
+
+// handlerDeleteExternalAccount handles HTTP POST requests to remove a login mapping
+func (api *apiConfig) handlerDeleteExternalAccount(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		Provider string `json:"provider"` // Code host
+		Login    string `json:"login"`    // Login on the code host
+	}
+
+	// Decode JSON request body
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, codeBadRequest, "invalid json")
+		return
+	}
+	if err := validateExternalProvider(params.Provider); err != nil {
+		respondWithError(w, codeBadRequest, err.Error())
+		return
+	}
+	params.Login = normalizeExternalLogin(params.Login)
+
+	ctx := r.Context()
+
+	// Transaction: delete the mapping together with its audit event
+	tx, err := api.DB.BeginTx(ctx)
+	if err != nil {
+		respondWithAPIError(w, fmt.Errorf("cannot begin tx: %w", err))
+		return
+	}
+	defer tx.Rollback()
+
+	account, err := tx.DeleteExternalAccount(ctx, database.DeleteExternalAccountParams{
+		Provider: params.Provider,
+		Login:    params.Login,
+	})
+	if err == sql.ErrNoRows {
+		respondWithError(w, codeNotFound, "external account not found")
+		return
+	}
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	deleted := dbExternalAccountToExternalAccount(account)
+	if err := service.RecordAudit(ctx, storage.NewTxRepository(tx), principalFromContext(ctx).actor(), auditActionExternalAccountDelete, auditEntityExternalAccount, account.Provider+":"+account.Login, deleted, nil); err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"account": deleted,
+	})
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"GODanilich/avito_backend/internal/service"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// orgCSVHeader is the header row of the CSV org snapshot, one row per user
+// A row with only team_name declares a team without members
+var orgCSVHeader = []string{"team_name", "user_id", "username", "is_active"}
+
+// handlerImportOrg handles HTTP POST requests to import the whole team structure
+// The body is a JSON or CSV snapshot as produced by /export. With dry_run=true
+// the response lists the changes without making them
+func (api *apiConfig) handlerImportOrg(w http.ResponseWriter, r *http.Request) {
+	dryRun := false
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			respondWithError(w, codeBadRequest, "dry_run must be true or false")
+			return
+		}
+	}
+
+	// Decode the snapshot in the format given by the Content-Type
+	var snapshot service.OrgSnapshot
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "text/csv" {
+		var err error
+		if snapshot, err = readOrgCSV(r.Body); err != nil {
+			respondWithError(w, codeBadRequest, err.Error())
+			return
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&snapshot); err != nil {
+		respondWithError(w, codeBadRequest, "invalid json")
+		return
+	}
+
+	diff, err := api.reviews.ImportOrg(r.Context(), principalFromContext(r.Context()).actor(), snapshot, dryRun)
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	// Return 200 OK with the changes made, or the ones a real run would make
+	respondWithJSON(w, http.StatusOK, diff)
+}
+
+// handlerExportOrg handles HTTP GET requests to export the whole team structure
+// format=csv returns CSV, JSON is the default
+func (api *apiConfig) handlerExportOrg(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		respondWithError(w, codeBadRequest, "format must be json or csv")
+		return
+	}
+
+	snapshot, err := api.reviews.ExportOrg(r.Context())
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="org.csv"`)
+		w.WriteHeader(http.StatusOK)
+		if err := writeOrgCSV(w, snapshot); err != nil {
+			log.Printf("Writing CSV export failed: %v", err)
+		}
+		return
+	}
+	respondWithJSON(w, http.StatusOK, snapshot)
+}
+
+// readOrgCSV parses a CSV snapshot, the header row is required
+// Rows of the same team don't have to be adjacent
+func readOrgCSV(body io.Reader) (service.OrgSnapshot, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = len(orgCSVHeader)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return service.OrgSnapshot{}, fmt.Errorf("invalid csv: %w", err)
+	}
+	for i, column := range orgCSVHeader {
+		if header[i] != column {
+			return service.OrgSnapshot{}, fmt.Errorf("invalid csv: header must be %v", orgCSVHeader)
+		}
+	}
+
+	snapshot := service.OrgSnapshot{Teams: []service.Team{}}
+	teamIndex := map[string]int{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return service.OrgSnapshot{}, fmt.Errorf("invalid csv: %w", err)
+		}
+		teamName, userID, username, isActive := record[0], record[1], record[2], record[3]
+		line, _ := reader.FieldPos(0)
+
+		i, ok := teamIndex[teamName]
+		if !ok {
+			i = len(snapshot.Teams)
+			teamIndex[teamName] = i
+			snapshot.Teams = append(snapshot.Teams, service.Team{TeamName: teamName, Members: []service.TeamMember{}})
+		}
+
+		// A team without members
+		if userID == "" && username == "" && isActive == "" {
+			continue
+		}
+
+		active, err := strconv.ParseBool(isActive)
+		if err != nil {
+			return service.OrgSnapshot{}, fmt.Errorf("invalid csv: line %d: is_active must be true or false", line)
+		}
+		snapshot.Teams[i].Members = append(snapshot.Teams[i].Members, service.TeamMember{
+			UserID:   userID,
+			Username: username,
+			IsActive: active,
+		})
+	}
+	return snapshot, nil
+}
+
+// writeOrgCSV writes the snapshot in the format readOrgCSV reads
+func writeOrgCSV(w io.Writer, snapshot service.OrgSnapshot) error {
+	writer := csv.NewWriter(w)
+	writer.Write(orgCSVHeader)
+	for _, team := range snapshot.Teams {
+		if len(team.Members) == 0 {
+			writer.Write([]string{team.TeamName, "", "", ""})
+		}
+		for _, member := range team.Members {
+			writer.Write([]string{team.TeamName, member.UserID, member.Username, strconv.FormatBool(member.IsActive)})
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
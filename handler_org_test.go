@@ -0,0 +1,187 @@
+package main
+
+import (
+	"GODanilich/avito_backend/internal/service"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testOrgSnapshot = `{"teams":[
+	{"team_name":"backend","members":[
+		{"user_id":"u1","username":"Alice","is_active":true},
+		{"user_id":"u4","username":"name-u4","is_active":true}]},
+	{"team_name":"design","members":[{"user_id":"u9","username":"Ivan","is_active":true}]}]}`
+
+// doCSVRequest sends body as text/csv with the admin token
+func doCSVRequest(handler http.Handler, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	req.Header.Set("Content-Type", "text/csv")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestOrgImport(t *testing.T) {
+	handler, _ := newTestAPI(t)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/team/add", teamBody("backend", []string{"u1", "u2", "u3"}, "u3"), http.StatusCreated)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/team/add", teamBody("payments", []string{"u4"}), http.StatusCreated)
+	userToken := createUserToken(t, handler, "u1-token", "u1")
+
+	runAPICases(t, handler, []apiCase{
+		{name: "dry run", method: http.MethodPost, path: "/api/v1/import?dry_run=true", body: testOrgSnapshot, status: http.StatusOK,
+			check: func(t *testing.T, body map[string]interface{}) {
+				expectField([]string{"dry_run"}, true)(t, body)
+				expectField([]string{"created_teams"}, []string{"design"})(t, body)
+				expectLen([]string{"created"}, 1)(t, body)
+				expectLen([]string{"moved"}, 1)(t, body)
+				expectLen([]string{"renamed"}, 1)(t, body)
+				expectLen([]string{"deactivated"}, 1)(t, body)
+				expectField([]string{"unchanged"}, 1)(t, body) // u3 is missing but already inactive
+			}},
+		{name: "dry run changes nothing", method: http.MethodGet, path: "/api/v1/team/get?team_name=design",
+			status: http.StatusNotFound, code: "NOT_FOUND"},
+		{name: "import", method: http.MethodPost, path: "/api/v1/import", body: testOrgSnapshot, status: http.StatusOK,
+			check: expectField([]string{"dry_run"}, false)},
+		{name: "new team", method: http.MethodGet, path: "/api/v1/team/get?team_name=design",
+			status: http.StatusOK, check: expectLen([]string{"members"}, 1)},
+		{name: "missing member deactivated", method: http.MethodGet, path: "/api/v1/team/get?team_name=backend",
+			status: http.StatusOK, check: expectLen([]string{"members"}, 4)},
+		{name: "import again changes nothing", method: http.MethodPost, path: "/api/v1/import?dry_run=true", body: testOrgSnapshot, status: http.StatusOK,
+			check: func(t *testing.T, body map[string]interface{}) {
+				expectLen([]string{"created"}, 0)(t, body)
+				expectLen([]string{"deactivated"}, 0)(t, body)
+				expectField([]string{"unchanged"}, 5)(t, body)
+			}},
+		{name: "team listed twice", method: http.MethodPost, path: "/api/v1/import",
+			body: `{"teams":[{"team_name":"a","members":[]},{"team_name":"a","members":[]}]}`, status: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "user in two teams", method: http.MethodPost, path: "/api/v1/import",
+			body:   `{"teams":[{"team_name":"a","members":[{"user_id":"u1","username":"A","is_active":true}]},{"team_name":"b","members":[{"user_id":"u1","username":"A","is_active":true}]}]}`,
+			status: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "invalid dry_run", method: http.MethodPost, path: "/api/v1/import?dry_run=maybe", body: testOrgSnapshot,
+			status: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "user token", method: http.MethodPost, path: "/api/v1/import", body: testOrgSnapshot, token: userToken,
+			status: http.StatusForbidden, code: "FORBIDDEN"},
+		{name: "invalid export format", method: http.MethodGet, path: "/api/v1/export?format=xml",
+			status: http.StatusBadRequest, code: "BAD_REQUEST"},
+	})
+}
+
+// Reviews of users the import deactivates move like with /team/deactivateUsers
+func TestOrgImportReassignsReviews(t *testing.T) {
+	handler, _ := newTestAPI(t)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/team/add", teamBody("backend", []string{"u1", "u2", "u3"}), http.StatusCreated)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/team/add", teamBody("payments", []string{"p1", "p2"}), http.StatusCreated)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/pullRequest/create",
+		`{"pull_request_id":"pr-1","pull_request_name":"x","author_id":"u1"}`, http.StatusCreated)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/pullRequest/create",
+		`{"pull_request_id":"pr-2","pull_request_name":"x","author_id":"p1"}`, http.StatusCreated)
+
+	// u3 is replaced by the new u4, payments has nobody left to review pr-2
+	snapshot := `{"teams":[
+		{"team_name":"backend","members":[
+			{"user_id":"u1","username":"name-u1","is_active":true},
+			{"user_id":"u2","username":"name-u2","is_active":true},
+			{"user_id":"u4","username":"name-u4","is_active":true}]},
+		{"team_name":"payments","members":[{"user_id":"p1","username":"name-p1","is_active":true}]}]}`
+	expectReviewChanges := expectAll(
+		expectLen([]string{"deactivated"}, 2),
+		expectField([]string{"reassignments"}, []map[string]interface{}{
+			{"pull_request_id": "pr-1", "old_reviewer_id": "u3", "new_reviewer_id": "u4"},
+		}),
+		expectField([]string{"short_of_reviewers"}, []map[string]interface{}{
+			{"pull_request_id": "pr-2", "removed_reviewer_id": "p2", "assigned_reviewers": []string{}},
+		}),
+	)
+
+	runAPICases(t, handler, []apiCase{
+		{name: "dry run", method: http.MethodPost, path: "/api/v1/import?dry_run=true", body: snapshot,
+			status: http.StatusOK, check: expectReviewChanges},
+		{name: "dry run keeps reviewers", method: http.MethodGet, path: "/api/v1/pullRequest/get?pull_request_id=pr-1",
+			status: http.StatusOK, check: expectReviewers([]string{"pr", "assigned_reviewers"}, "u2", "u3")},
+		{name: "import", method: http.MethodPost, path: "/api/v1/import", body: snapshot,
+			status: http.StatusOK, check: expectReviewChanges},
+		{name: "reviewer replaced", method: http.MethodGet, path: "/api/v1/pullRequest/get?pull_request_id=pr-1",
+			status: http.StatusOK, check: expectReviewers([]string{"pr", "assigned_reviewers"}, "u2", "u4")},
+		{name: "reviewer removed", method: http.MethodGet, path: "/api/v1/pullRequest/get?pull_request_id=pr-2",
+			status: http.StatusOK, check: expectReviewers([]string{"pr", "assigned_reviewers"})},
+	})
+}
+
+func TestOrgImportCSV(t *testing.T) {
+	handler, _ := newTestAPI(t)
+
+	rec := doCSVRequest(handler, "/api/v1/import", "team_name,user_id,username,is_active\nbackend,u1,Alice,true\ndesign,,,\nbackend,u2,Bob,false\n")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200, body: %s", rec.Code, rec.Body)
+	}
+	body := decodeBody(t, rec)
+	expectField([]string{"created_teams"}, []string{"backend", "design"})(t, body)
+	expectLen([]string{"created"}, 2)(t, body)
+
+	team := mustRequest(t, handler, http.MethodGet, "/api/v1/team/get?team_name=backend", "", http.StatusOK)
+	expectLen([]string{"members"}, 2)(t, team)
+
+	for name, csv := range map[string]string{
+		"wrong header":     "team,user,name,active\n",
+		"missing column":   "team_name,user_id,username,is_active\nbackend,u1,Alice\n",
+		"invalid activity": "team_name,user_id,username,is_active\nbackend,u1,Alice,yes please\n",
+		"empty":            "",
+	} {
+		if rec := doCSVRequest(handler, "/api/v1/import", csv); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400, body: %s", name, rec.Code, rec.Body)
+		}
+	}
+}
+
+// An export imports back without changes, in both formats
+func TestOrgExportRoundTrip(t *testing.T) {
+	handler, _ := newTestAPI(t)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/team/add", teamBody("backend", []string{"u2", "u1"}, "u2"), http.StatusCreated)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/team/add", teamBody("empty", nil), http.StatusCreated)
+
+	rec := doRequest(handler, http.MethodGet, "/api/v1/export", testAdminToken, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("export: status = %d, body: %s", rec.Code, rec.Body)
+	}
+	var snapshot service.OrgSnapshot
+	if err := json.Unmarshal(rec.Body.Bytes(), &snapshot); err != nil {
+		t.Fatalf("decode export: %v", err)
+	}
+	want := service.OrgSnapshot{Teams: []service.Team{
+		{TeamName: "backend", Members: []service.TeamMember{
+			{UserID: "u1", Username: "name-u1", IsActive: true},
+			{UserID: "u2", Username: "name-u2", IsActive: false},
+		}},
+		{TeamName: "empty", Members: []service.TeamMember{}},
+	}}
+	if !reflect.DeepEqual(snapshot, want) {
+		t.Errorf("export = %+v, want %+v", snapshot, want)
+	}
+
+	diff := mustRequest(t, handler, http.MethodPost, "/api/v1/import?dry_run=true", rec.Body.String(), http.StatusOK)
+	expectField([]string{"unchanged"}, 2)(t, diff)
+	expectLen([]string{"created_teams"}, 0)(t, diff)
+
+	rec = doRequest(handler, http.MethodGet, "/api/v1/export?format=csv", testAdminToken, "")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("csv export: status = %d, content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	wantCSV := "team_name,user_id,username,is_active\nbackend,u1,name-u1,true\nbackend,u2,name-u2,false\nempty,,,\n"
+	if rec.Body.String() != wantCSV {
+		t.Errorf("csv export = %q, want %q", rec.Body, wantCSV)
+	}
+
+	rec = doCSVRequest(handler, "/api/v1/import?dry_run=true", rec.Body.String())
+	if rec.Code != http.StatusOK {
+		t.Fatalf("csv import: status = %d, body: %s", rec.Code, rec.Body)
+	}
+	diff = decodeBody(t, rec)
+	expectField([]string{"unchanged"}, 2)(t, diff)
+	expectLen([]string{"created_teams"}, 0)(t, diff)
+}
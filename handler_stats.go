@@ -4,13 +4,49 @@ import (
 	"GODanilich/avito_backend/internal/database"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 // StatsResponse defines the structure for the statistics API response
 // It contains two main sections: PR statistics and assignment statistics
 type StatsResponse struct {
-	PRStats         []PRStatusCount   `json:"pr_stats"`         // Statistics of pull requests grouped by status
-	AssignmentStats []UserAssignCount `json:"assignment_stats"` // Statistics of user assignments count
+	PRStats         []PRStatusCount   `json:"pr_stats"`          // Statistics of pull requests grouped by status
+	AssignmentStats []UserAssignCount `json:"assignment_stats"`  // Statistics of user assignments count
+	Window          StatsWindow       `json:"window"`            // Time window applied to duration statistics
+	MergeTime       DurationStats     `json:"merge_time"`        // Time from creation to merge
+	FirstReviewTime DurationStats     `json:"first_review_time"` // Time from creation to the first verdict
+}
+
+// StatsWindow is the optional time window of duration statistics
+type StatsWindow struct {
+	From *time.Time `json:"from"` // Inclusive lower bound, null if unbounded
+	To   *time.Time `json:"to"`   // Exclusive upper bound, null if unbounded
+}
+
+// DurationStats holds duration percentiles broken down by team and by author
+type DurationStats struct {
+	ByTeam   []TeamDurationPercentiles   `json:"by_team"`   // Percentiles per author team
+	ByAuthor []AuthorDurationPercentiles `json:"by_author"` // Percentiles per PR author
+}
+
+// DurationPercentiles holds duration percentiles in seconds
+type DurationPercentiles struct {
+	Count int64   `json:"count"`       // Number of PRs the percentiles are computed over
+	P50   float64 `json:"p50_seconds"` // Median duration
+	P90   float64 `json:"p90_seconds"` // 90th percentile duration
+	P99   float64 `json:"p99_seconds"` // 99th percentile duration
+}
+
+// TeamDurationPercentiles represents duration percentiles of one team
+type TeamDurationPercentiles struct {
+	TeamName *string `json:"team_name"` // Author team, null for authors without a team
+	DurationPercentiles
+}
+
+// AuthorDurationPercentiles represents duration percentiles of one author
+type AuthorDurationPercentiles struct {
+	AuthorID string `json:"author_id"` // PR author
+	DurationPercentiles
 }
 
 // PRStatusCount represents the count of pull requests for a specific status
@@ -26,16 +62,31 @@ type UserAssignCount struct {
 }
 
 // handlerGetStats handles HTTP GET requests to retrieve system statistics
-// Returns aggregated data about PR statuses and user assignment counts
+// Returns aggregated data about PR statuses and user assignment counts,
+// plus time-to-merge and time-to-first-review percentiles.
+// Optional from/to query parameters (RFC3339) limit the duration statistics
+// to PRs merged (or first reviewed) inside the window
 func (api *apiConfig) handlerGetStats(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	// Parse the optional time window
+	windowStart, err := parseOptionalTime(r.URL.Query().Get("from"))
+	if err != nil {
+		respondWithError(w, codeBadRequest, "from must be an RFC3339 timestamp")
+		return
+	}
+	windowEnd, err := parseOptionalTime(r.URL.Query().Get("to"))
+	if err != nil {
+		respondWithError(w, codeBadRequest, "to must be an RFC3339 timestamp")
+		return
+	}
+
 	// Get PR statistics grouped by status from the database
 	// This typically returns counts for each PR status (OPEN, MERGED, CLOSED, etc.)
 	prStatsRaw, err := api.DB.GetPRStats(ctx)
 	if err != nil {
 		// Return 500 Internal Server Error if database query fails
-		respondWithError(w, 500, "DB_ERROR", fmt.Sprintf("cannot fetch PR stats: %v", err))
+		respondWithAPIError(w, fmt.Errorf("cannot fetch PR stats: %w", err))
 		return
 	}
 
@@ -43,7 +94,7 @@ func (api *apiConfig) handlerGetStats(w http.ResponseWriter, r *http.Request) {
 	assignStatsRaw, err := api.DB.GetAssignmentStats(ctx)
 	if err != nil {
 		// Return 500 Internal Server Error if database query fails
-		respondWithError(w, 500, "DB_ERROR", fmt.Sprintf("cannot fetch assignment stats: %v", err))
+		respondWithAPIError(w, fmt.Errorf("cannot fetch assignment stats: %w", err))
 		return
 	}
 
@@ -66,10 +117,87 @@ func (api *apiConfig) handlerGetStats(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Get time-to-merge percentiles by team and by author
+	mergeByTeam, err := api.DB.GetMergeTimeByTeam(ctx, database.GetMergeTimeByTeamParams{
+		WindowStart: windowStart,
+		WindowEnd:   windowEnd,
+	})
+	if err != nil {
+		respondWithAPIError(w, fmt.Errorf("cannot fetch merge time stats: %w", err))
+		return
+	}
+	mergeByAuthor, err := api.DB.GetMergeTimeByAuthor(ctx, database.GetMergeTimeByAuthorParams{
+		WindowStart: windowStart,
+		WindowEnd:   windowEnd,
+	})
+	if err != nil {
+		respondWithAPIError(w, fmt.Errorf("cannot fetch merge time stats: %w", err))
+		return
+	}
+
+	// Get time-to-first-review percentiles by team and by author
+	reviewByTeam, err := api.DB.GetFirstReviewTimeByTeam(ctx, database.GetFirstReviewTimeByTeamParams{
+		WindowStart: windowStart,
+		WindowEnd:   windowEnd,
+	})
+	if err != nil {
+		respondWithAPIError(w, fmt.Errorf("cannot fetch first review time stats: %w", err))
+		return
+	}
+	reviewByAuthor, err := api.DB.GetFirstReviewTimeByAuthor(ctx, database.GetFirstReviewTimeByAuthorParams{
+		WindowStart: windowStart,
+		WindowEnd:   windowEnd,
+	})
+	if err != nil {
+		respondWithAPIError(w, fmt.Errorf("cannot fetch first review time stats: %w", err))
+		return
+	}
+
+	// Convert duration rows to API response format
+	mergeTime := DurationStats{
+		ByTeam:   make([]TeamDurationPercentiles, len(mergeByTeam)),
+		ByAuthor: make([]AuthorDurationPercentiles, len(mergeByAuthor)),
+	}
+	for i, s := range mergeByTeam {
+		mergeTime.ByTeam[i] = TeamDurationPercentiles{
+			TeamName:            nullStringToPtr(s.TeamName),
+			DurationPercentiles: DurationPercentiles{Count: s.MergedCount, P50: s.P50Seconds, P90: s.P90Seconds, P99: s.P99Seconds},
+		}
+	}
+	for i, s := range mergeByAuthor {
+		mergeTime.ByAuthor[i] = AuthorDurationPercentiles{
+			AuthorID:            s.AuthorID,
+			DurationPercentiles: DurationPercentiles{Count: s.MergedCount, P50: s.P50Seconds, P90: s.P90Seconds, P99: s.P99Seconds},
+		}
+	}
+
+	firstReviewTime := DurationStats{
+		ByTeam:   make([]TeamDurationPercentiles, len(reviewByTeam)),
+		ByAuthor: make([]AuthorDurationPercentiles, len(reviewByAuthor)),
+	}
+	for i, s := range reviewByTeam {
+		firstReviewTime.ByTeam[i] = TeamDurationPercentiles{
+			TeamName:            nullStringToPtr(s.TeamName),
+			DurationPercentiles: DurationPercentiles{Count: s.ReviewedCount, P50: s.P50Seconds, P90: s.P90Seconds, P99: s.P99Seconds},
+		}
+	}
+	for i, s := range reviewByAuthor {
+		firstReviewTime.ByAuthor[i] = AuthorDurationPercentiles{
+			AuthorID:            s.AuthorID,
+			DurationPercentiles: DurationPercentiles{Count: s.ReviewedCount, P50: s.P50Seconds, P90: s.P90Seconds, P99: s.P99Seconds},
+		}
+	}
+
 	// Build the complete response structure
 	resp := StatsResponse{
 		PRStats:         prStats,         // PR status distribution
 		AssignmentStats: assignmentStats, // User assignment counts
+		Window: StatsWindow{
+			From: nullTimeToPtr(windowStart),
+			To:   nullTimeToPtr(windowEnd),
+		},
+		MergeTime:       mergeTime,       // Time-to-merge percentiles
+		FirstReviewTime: firstReviewTime, // Time-to-first-review percentiles
 	}
 
 	// Return 200 OK with the statistics data
@@ -1,18 +1,14 @@
 package main
 
 import (
-	"GODanilich/avito_backend/internal/database"
+	"GODanilich/avito_backend/internal/service"
 	"database/sql"
 	"encoding/json"
-	"fmt"
 	"net/http"
 )
 
 // TeamStruct represents the structure of a team with its members
-type TeamStruct struct {
-	TeamName string            `json:"team_name"` // Name of the team
-	Members  []UserWithoutTeam `json:"members"`   // List of team members
-}
+type TeamStruct = service.Team
 
 // handlerAddTeam handles HTTP POST requests to create a new team
 // It creates a team and adds all specified members to it in a transactional manner
@@ -30,84 +26,42 @@ func (apiCFG *apiConfig) handlerAddTeam(w http.ResponseWriter, r *http.Request)
 	err := decoder.Decode(&params)
 	if err != nil {
 		// Return 400 Bad Request if JSON parsing fails
-		respondWithError(w, http.StatusBadRequest, "Error parsing JSON", fmt.Sprint(err))
+		respondWithError(w, codeBadRequest, "invalid json")
 		return
 	}
 
 	// Validate that team_name is provided and not empty
 	if params.TeamName == "" {
-		respondWithError(w, http.StatusBadRequest, "BAD_REQUEST", "team_name is required")
+		respondWithError(w, codeBadRequest, "team_name is required")
 		return
 	}
 
 	// Validate each member in the members list
 	for _, user := range params.Members {
 		if user.UserID == "" {
-			respondWithError(w, http.StatusBadRequest, "INVALID_USER_ID", "user_id cannot be empty")
+			respondWithError(w, codeInvalidUserID, "user_id cannot be empty")
 			return
 		}
 		if user.Username == "" {
-			respondWithError(w, http.StatusBadRequest, "INVALID_USERNAME", "username cannot be empty")
+			respondWithError(w, codeInvalidUsername, "username cannot be empty")
 			return
 		}
 	}
 
-	// Check if a team with the same name already exists
-	_, err = apiCFG.DB.GetTeam(r.Context(), params.TeamName)
-	if err == nil {
-		// Team already exists - return error
-		respondWithError(w, http.StatusBadRequest, "TEAM_EXISTS", "team_name already exists")
-		return
-	} else if err != sql.ErrNoRows {
-		// Some other database error occurred
-		respondWithError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
-		return
-	}
-
-	// Start a database transaction to ensure atomicity
-	// This ensures either all operations succeed or all fail
-	tx, err := apiCFG.dbConn.BeginTx(r.Context(), nil)
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "DB_ERROR", "cannot begin tx")
-		return
-	}
-
-	// Ensure transaction is rolled back if not committed
-	defer tx.Rollback()
-
-	// Create the team in the database
-	err = apiCFG.DB.WithTx(tx).CreateTeam(r.Context(), params.TeamName)
+	// Create the team and its members
+	team, moved, err := apiCFG.reviews.AddTeam(r.Context(), principalFromContext(r.Context()).actor(), TeamStruct{
+		TeamName: params.TeamName,
+		Members:  params.Members,
+	})
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		respondWithAPIError(w, err)
 		return
 	}
 
-	// Add each member to the team
-	for _, user := range params.Members {
-		err = apiCFG.DB.WithTx(tx).UpsertUser(r.Context(), database.UpsertUserParams{
-			UserID:   user.UserID,
-			Username: user.Username,
-			TeamName: sql.NullString{
-				String: params.TeamName, // Set the team name for this user
-				Valid:  true,            // Mark that team name is set
-			},
-			IsActive: user.IsActive,
-		})
-		if err != nil {
-			respondWithError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
-			return
-		}
-	}
-
-	// Commit the transaction - all operations succeed
-	if err := tx.Commit(); err != nil {
-		respondWithError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
-		return
-	}
-
-	// Return 201 Created with the team details
+	// Return 201 Created with the team details and the members taken from other teams
 	respondWithJSON(w, http.StatusCreated, map[string]interface{}{
-		"team": params,
+		"team":  team,
+		"moved": moved,
 	})
 }
 
@@ -118,7 +72,7 @@ func (apiCFG *apiConfig) handlerGetTeam(w http.ResponseWriter, r *http.Request)
 	// Extract team_name from query parameters
 	teamName := r.URL.Query().Get("team_name")
 	if teamName == "" {
-		respondWithError(w, http.StatusBadRequest, "BAD_REQUEST", "team_name is required")
+		respondWithError(w, codeBadRequest, "team_name is required")
 		return
 	}
 
@@ -126,11 +80,11 @@ func (apiCFG *apiConfig) handlerGetTeam(w http.ResponseWriter, r *http.Request)
 	team, err := apiCFG.DB.GetTeam(r.Context(), teamName)
 	if err == sql.ErrNoRows {
 		// Team not found - return 404
-		respondWithError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
+		respondWithError(w, codeNotFound, "team not found")
 		return
 	} else if err != nil {
 		// Other database error
-		respondWithError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		respondWithAPIError(w, err)
 		return
 	}
 
@@ -140,7 +94,7 @@ func (apiCFG *apiConfig) handlerGetTeam(w http.ResponseWriter, r *http.Request)
 		Valid:  true,
 	})
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "DB_ERROR", err.Error())
+		respondWithAPIError(w, err)
 		return
 	}
 
@@ -153,3 +107,47 @@ func (apiCFG *apiConfig) handlerGetTeam(w http.ResponseWriter, r *http.Request)
 	// Return 200 OK with team information
 	respondWithJSON(w, http.StatusOK, response)
 }
+
+// handlerDeactivateTeamUsers handles HTTP POST requests to deactivate several team members at once
+// In a single transaction it marks the users inactive and moves each of their
+// OPEN PR assignments to an eligible active teammate of the PR author
+func (apiCFG *apiConfig) handlerDeactivateTeamUsers(w http.ResponseWriter, r *http.Request) {
+
+	// requestBody defines the structure of the expected JSON request
+	type requestBody struct {
+		TeamName string   `json:"team_name"` // Team the users belong to
+		UserIDs  []string `json:"user_ids"`  // Users to deactivate
+	}
+
+	// Decode the JSON request body into the params struct
+	params := requestBody{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, codeBadRequest, "invalid json")
+		return
+	}
+
+	// Validate request fields
+	if params.TeamName == "" {
+		respondWithError(w, codeBadRequest, "team_name is required")
+		return
+	}
+	if len(params.UserIDs) == 0 {
+		respondWithError(w, codeBadRequest, "user_ids cannot be empty")
+		return
+	}
+	for _, userID := range params.UserIDs {
+		if userID == "" {
+			respondWithError(w, codeInvalidUserID, "user_id cannot be empty")
+			return
+		}
+	}
+
+	result, err := apiCFG.reviews.DeactivateTeamUsers(r.Context(), principalFromContext(r.Context()).actor(), params.TeamName, params.UserIDs)
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	// Return 200 OK with the outcome of every assignment
+	respondWithJSON(w, http.StatusOK, result)
+}
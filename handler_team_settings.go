@@ -0,0 +1,88 @@
+package main
+
+import (
+	"GODanilich/avito_backend/internal/service"
+	"encoding/json"
+	"net/http"
+)
+
+// TeamSettings represents the reviewer assignment settings of a team
+type TeamSettings = service.TeamSettings
+
+// handlerGetTeamSettings handles HTTP GET requests to retrieve team settings
+// Teams that were never configured report the service defaults
+func (apiCFG *apiConfig) handlerGetTeamSettings(w http.ResponseWriter, r *http.Request) {
+
+	// Extract team_name from query parameters
+	teamName := r.URL.Query().Get("team_name")
+	if teamName == "" {
+		respondWithError(w, codeBadRequest, "team_name is required")
+		return
+	}
+
+	// Load effective settings
+	settings, err := apiCFG.reviews.GetTeamSettings(r.Context(), teamName)
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	// Return 200 OK with team settings
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"settings": settings,
+	})
+}
+
+// handlerSetTeamSettings handles HTTP POST requests to update team settings
+// Omitted fields keep their current values
+func (apiCFG *apiConfig) handlerSetTeamSettings(w http.ResponseWriter, r *http.Request) {
+
+	// requestBody defines the structure of the expected JSON request
+	type requestBody struct {
+		TeamName          string  `json:"team_name"`          // Name of the team to configure
+		MinReviewers      *int    `json:"min_reviewers"`      // New minimum reviewer count
+		MaxReviewers      *int    `json:"max_reviewers"`      // New maximum reviewer count
+		Strategy          *string `json:"strategy"`           // New selection strategy
+		RequiredApprovals *int    `json:"required_approvals"` // New number of approvals required to merge
+	}
+
+	// Decode the JSON request body into the params struct
+	params := requestBody{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, codeBadRequest, "invalid json")
+		return
+	}
+
+	// Validate that team_name is provided and not empty
+	if params.TeamName == "" {
+		respondWithError(w, codeBadRequest, "team_name is required")
+		return
+	}
+
+	update := service.TeamSettingsUpdate{
+		TeamName:          params.TeamName,
+		MinReviewers:      params.MinReviewers,
+		MaxReviewers:      params.MaxReviewers,
+		RequiredApprovals: params.RequiredApprovals,
+	}
+	if params.Strategy != nil {
+		strategy, err := service.ParseReviewerStrategy(*params.Strategy)
+		if err != nil {
+			respondWithError(w, codeBadRequest, "strategy must be one of random, least_loaded, round_robin")
+			return
+		}
+		update.Strategy = &strategy
+	}
+
+	// Validate and persist settings
+	settings, err := apiCFG.reviews.SetTeamSettings(r.Context(), principalFromContext(r.Context()).actor(), update)
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	// Return 200 OK with the stored settings
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"settings": settings,
+	})
+}
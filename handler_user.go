@@ -2,9 +2,9 @@ package main
 
 import (
 	"GODanilich/avito_backend/internal/database"
+	"GODanilich/avito_backend/internal/service"
 	"database/sql"
 	"encoding/json"
-	"fmt"
 	"net/http"
 )
 
@@ -24,78 +24,72 @@ func (apiCFG *apiConfig) handlerSetIsActive(w http.ResponseWriter, r *http.Reque
 	err := decoder.Decode(&params)
 	if err != nil {
 		// Return 400 Bad Request if JSON parsing fails
-		respondWithError(w, http.StatusBadRequest, "Error parsing JSON", fmt.Sprint(err))
+		respondWithError(w, codeBadRequest, "invalid json")
 		return
 	}
 
 	// Validate that user_id is provided
 	if params.UserId == "" {
-		respondWithError(w, http.StatusBadRequest, "BAD_REQUEST", "user_id is required")
+		respondWithError(w, codeBadRequest, "user_id is required")
 		return
 	}
 
-	// Check if user exists before attempting to update
-	_, err = apiCFG.DB.GetUserById(r.Context(), params.UserId)
-	if err == sql.ErrNoRows {
-		// Return 404 Not Found if user doesn't exist
-		respondWithError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
-		return
-	}
+	// Update user's active status
+	user, err := apiCFG.reviews.SetUserActive(r.Context(), principalFromContext(r.Context()).actor(), params.UserId, params.IsActive)
 	if err != nil {
-		// Return 500 Internal Server Error for other database errors
-		respondWithError(w, http.StatusInternalServerError, "DB_ERROR", "internal error")
-		return
-	}
-
-	// Update user's active status in the database
-	user, err := apiCFG.DB.SetUserActive(r.Context(), database.SetUserActiveParams{
-		UserID:   params.UserId,
-		IsActive: params.IsActive,
-	})
-	if err != nil {
-		// Handle update errors
-		if err == sql.ErrNoRows {
-			respondWithError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
-		} else {
-			respondWithError(w, http.StatusInternalServerError, "DB_ERROR", "failed to update user")
-		}
+		respondWithAPIError(w, err)
 		return
 	}
 
 	// Return 200 OK with the updated user information
 	respondWithJSON(w, http.StatusOK, map[string]interface{}{
-		"user": dbUserToUser(user), // Convert database user to API response format
+		"user": user,
 	})
 }
 
 // handlerGetReview handles HTTP requests to get pull requests for a reviewer
-// It expects a user_id query parameter
+// It expects a user_id query parameter and an optional state filter
+// (PENDING, APPROVED or CHANGES_REQUESTED)
 func (apiCFG *apiConfig) handlerGetReview(w http.ResponseWriter, r *http.Request) {
 
 	// Extract user_id from query parameters
 	userID := r.URL.Query().Get("user_id")
 	if userID == "" {
 		// Return 400 Bad Request if user_id is missing
-		respondWithError(w, http.StatusBadRequest, "BAD_REQUEST", "user_id is required")
+		respondWithError(w, codeBadRequest, "user_id is required")
 		return
 	}
 
+	// Parse optional review state filter
+	state := database.NullReviewState{}
+	if value := r.URL.Query().Get("state"); value != "" {
+		parsed, err := service.ParseReviewState(value)
+		if err != nil {
+			respondWithError(w, codeBadRequest, "state must be one of PENDING, APPROVED, CHANGES_REQUESTED")
+			return
+		}
+		state = database.NullReviewState{ReviewState: database.ReviewState(parsed), Valid: true}
+	}
+
 	// Verify that the user exists
 	_, err := apiCFG.DB.GetUserById(r.Context(), userID)
 	if err == sql.ErrNoRows {
-		respondWithError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+		respondWithError(w, codeNotFound, "user not found")
 		return
 	}
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "DB_ERROR", "internal error")
+		respondWithAPIError(w, err)
 		return
 	}
 
 	// Retrieve pull requests assigned to the reviewer
-	prs, err := apiCFG.DB.GetPRsForReviewer(r.Context(), userID)
+	prs, err := apiCFG.DB.GetPRsForReviewer(r.Context(), database.GetPRsForReviewerParams{
+		UserID: userID,
+		State:  state,
+	})
 	if err != nil && err != sql.ErrNoRows {
 		// Return 500 only for actual errors, not for empty results
-		respondWithError(w, http.StatusInternalServerError, "DB_ERROR", "internal error")
+		respondWithAPIError(w, err)
 		return
 	}
 
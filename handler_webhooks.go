@@ -0,0 +1,496 @@
+package main
+
+import (
+	"GODanilich/avito_backend/internal/database"
+	"GODanilich/avito_backend/internal/service"
+	"GODanilich/avito_backend/internal/storage"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultDeliveryPageSize = 50  // Page size when limit is not given
+	maxDeliveryPageSize     = 500 // Largest accepted limit
+)
+
+// WebhookSubscription is a registered webhook receiver, the secret is never listed
+type WebhookSubscription struct {
+	ID         int64     `json:"id"`          // Subscription ID
+	URL        string    `json:"url"`         // Receiver URL
+	EventTypes []string  `json:"event_types"` // Delivered event types, empty for all
+	IsActive   bool      `json:"is_active"`   // Inactive subscriptions receive no new events, queued ones wait until it is resumed
+	CreatedAt  time.Time `json:"createdAt"`   // Creation time
+}
+
+func dbWebhookSubscriptionToWebhookSubscription(dbSub database.WebhookSubscription) WebhookSubscription {
+	eventTypes := dbSub.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	return WebhookSubscription{
+		ID:         dbSub.ID,
+		URL:        dbSub.Url,
+		EventTypes: eventTypes,
+		IsActive:   dbSub.IsActive,
+		CreatedAt:  dbSub.CreatedAt,
+	}
+}
+
+// WebhookDelivery is an outbox row, one event for one subscription
+type WebhookDelivery struct {
+	ID             int64                 `json:"id"`              // Delivery ID
+	SubscriptionID int64                 `json:"subscription_id"` // Receiving subscription
+	EventID        string                `json:"event_id"`        // Event ID sent in X-Webhook-ID
+	EventType      string                `json:"event_type"`      // Event type
+	Payload        json.RawMessage       `json:"payload"`         // Body posted to the receiver
+	Status         database.OutboxStatus `json:"status"`          // PENDING, DELIVERED or DEAD
+	Attempts       int32                 `json:"attempts"`        // Attempts made so far
+	NextAttemptAt  time.Time             `json:"nextAttemptAt"`   // When the next attempt is due
+	LastError      *string               `json:"last_error"`      // Error of the last failed attempt
+	CreatedAt      time.Time             `json:"createdAt"`       // When the event was enqueued
+	DeliveredAt    *time.Time            `json:"deliveredAt"`     // When the receiver accepted the event
+}
+
+func dbOutboxToWebhookDelivery(dbOutbox database.Outbox) WebhookDelivery {
+	return WebhookDelivery{
+		ID:             dbOutbox.ID,
+		SubscriptionID: dbOutbox.SubscriptionID,
+		EventID:        dbOutbox.EventID,
+		EventType:      dbOutbox.EventType,
+		Payload:        dbOutbox.Payload,
+		Status:         dbOutbox.Status,
+		Attempts:       dbOutbox.Attempts,
+		NextAttemptAt:  dbOutbox.NextAttemptAt,
+		LastError:      nullStringToPtr(dbOutbox.LastError),
+		CreatedAt:      dbOutbox.CreatedAt,
+		DeliveredAt:    nullTimeToPtr(dbOutbox.DeliveredAt),
+	}
+}
+
+// validateWebhookURL accepts absolute http and https URLs
+func validateWebhookURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an absolute http or https URL")
+	}
+	return nil
+}
+
+// validateWebhookEventTypes accepts known event types, an empty list means all events
+func validateWebhookEventTypes(eventTypes []string) error {
+	for _, eventType := range eventTypes {
+		if !slices.Contains(webhookEventTypes, eventType) {
+			return fmt.Errorf("event_types must contain only %s", strings.Join(webhookEventTypes, ", "))
+		}
+	}
+	return nil
+}
+
+// parseIDQuery parses a positive integer ID from a query parameter
+func parseIDQuery(r *http.Request, name string) (int64, error) {
+	id, err := strconv.ParseInt(r.URL.Query().Get(name), 10, 64)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer", name)
+	}
+	return id, nil
+}
+
+// handlerCreateWebhook handles HTTP POST requests to register a webhook receiver
+// A signing secret is generated unless one is given, it is returned only once
+func (api *apiConfig) handlerCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		URL        string   `json:"url"`         // Receiver URL
+		EventTypes []string `json:"event_types"` // Event types to deliver, empty for all
+		Secret     string   `json:"secret"`      // Optional HMAC secret
+	}
+
+	// Decode JSON request body
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, codeBadRequest, "invalid json")
+		return
+	}
+
+	// Validate request fields
+	if err := validateWebhookURL(params.URL); err != nil {
+		respondWithError(w, codeBadRequest, err.Error())
+		return
+	}
+	if params.EventTypes == nil {
+		params.EventTypes = []string{}
+	}
+	if err := validateWebhookEventTypes(params.EventTypes); err != nil {
+		respondWithError(w, codeBadRequest, err.Error())
+		return
+	}
+	if params.Secret == "" {
+		secret, err := randomHex(32)
+		if err != nil {
+			respondWithError(w, codeInternal, "failed to generate secret")
+			return
+		}
+		params.Secret = secret
+	}
+
+	ctx := r.Context()
+
+	// Transaction: store the subscription together with its audit event
+	tx, err := api.DB.BeginTx(ctx)
+	if err != nil {
+		respondWithAPIError(w, fmt.Errorf("cannot begin tx: %w", err))
+		return
+	}
+	defer tx.Rollback()
+
+	sub, err := tx.CreateWebhookSubscription(ctx, database.CreateWebhookSubscriptionParams{
+		Url:        params.URL,
+		Secret:     params.Secret,
+		EventTypes: params.EventTypes,
+	})
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	created := dbWebhookSubscriptionToWebhookSubscription(sub)
+	if err := service.RecordAudit(ctx, storage.NewTxRepository(tx), principalFromContext(ctx).actor(), auditActionWebhookCreate, auditEntityWebhookSubscription, strconv.FormatInt(sub.ID, 10), nil, created); err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	// Return 201 Created with the subscription and its secret
+	respondWithJSON(w, http.StatusCreated, map[string]interface{}{
+		"subscription": created,
+		"secret":       sub.Secret,
+	})
+}
+
+// handlerListWebhooks handles HTTP GET requests to list webhook receivers
+func (api *apiConfig) handlerListWebhooks(w http.ResponseWriter, r *http.Request) {
+	subs, err := api.DB.ListWebhookSubscriptions(r.Context())
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	subscriptions := make([]WebhookSubscription, len(subs))
+	for i, sub := range subs {
+		subscriptions[i] = dbWebhookSubscriptionToWebhookSubscription(sub)
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"subscriptions": subscriptions,
+	})
+}
+
+// handlerGetWebhook handles HTTP GET requests to fetch a webhook receiver by id
+func (api *apiConfig) handlerGetWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDQuery(r, "id")
+	if err != nil {
+		respondWithError(w, codeBadRequest, err.Error())
+		return
+	}
+
+	sub, err := api.DB.GetWebhookSubscription(r.Context(), id)
+	if err == sql.ErrNoRows {
+		respondWithError(w, codeNotFound, "subscription not found")
+		return
+	}
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"subscription": dbWebhookSubscriptionToWebhookSubscription(sub),
+	})
+}
+
+// handlerUpdateWebhook handles HTTP POST requests to change a webhook receiver
+// Omitted fields keep their current values
+func (api *apiConfig) handlerUpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		ID         int64     `json:"id"`          // Subscription ID
+		URL        *string   `json:"url"`         // New receiver URL
+		EventTypes *[]string `json:"event_types"` // New event type filter
+		IsActive   *bool     `json:"is_active"`   // Pause or resume deliveries
+	}
+
+	// Decode JSON request body
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, codeBadRequest, "invalid json")
+		return
+	}
+	if params.ID < 1 {
+		respondWithError(w, codeBadRequest, "id is required")
+		return
+	}
+
+	ctx := r.Context()
+
+	// Transaction: update the subscription together with its audit event
+	tx, err := api.DB.BeginTx(ctx)
+	if err != nil {
+		respondWithAPIError(w, fmt.Errorf("cannot begin tx: %w", err))
+		return
+	}
+	defer tx.Rollback()
+
+	current, err := tx.GetWebhookSubscription(ctx, params.ID)
+	if err == sql.ErrNoRows {
+		respondWithError(w, codeNotFound, "subscription not found")
+		return
+	}
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	// Apply requested changes
+	update := database.UpdateWebhookSubscriptionParams{
+		ID:         current.ID,
+		Url:        current.Url,
+		EventTypes: current.EventTypes,
+		IsActive:   current.IsActive,
+	}
+	if params.URL != nil {
+		if err := validateWebhookURL(*params.URL); err != nil {
+			respondWithError(w, codeBadRequest, err.Error())
+			return
+		}
+		update.Url = *params.URL
+	}
+	if params.EventTypes != nil {
+		if err := validateWebhookEventTypes(*params.EventTypes); err != nil {
+			respondWithError(w, codeBadRequest, err.Error())
+			return
+		}
+		update.EventTypes = *params.EventTypes
+	}
+	if update.EventTypes == nil {
+		update.EventTypes = []string{}
+	}
+	if params.IsActive != nil {
+		update.IsActive = *params.IsActive
+	}
+
+	sub, err := tx.UpdateWebhookSubscription(ctx, update)
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	updated := dbWebhookSubscriptionToWebhookSubscription(sub)
+	if err := service.RecordAudit(ctx, storage.NewTxRepository(tx), principalFromContext(ctx).actor(), auditActionWebhookUpdate, auditEntityWebhookSubscription, strconv.FormatInt(sub.ID, 10),
+		dbWebhookSubscriptionToWebhookSubscription(current), updated); err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"subscription": updated,
+	})
+}
+
+// handlerDeleteWebhook handles HTTP POST requests to remove a webhook receiver
+// Pending deliveries of the subscription are dropped with it
+func (api *apiConfig) handlerDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		ID int64 `json:"id"` // Subscription ID
+	}
+
+	// Decode JSON request body
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, codeBadRequest, "invalid json")
+		return
+	}
+	if params.ID < 1 {
+		respondWithError(w, codeBadRequest, "id is required")
+		return
+	}
+
+	ctx := r.Context()
+
+	// Transaction: delete the subscription together with its audit event
+	tx, err := api.DB.BeginTx(ctx)
+	if err != nil {
+		respondWithAPIError(w, fmt.Errorf("cannot begin tx: %w", err))
+		return
+	}
+	defer tx.Rollback()
+
+	sub, err := tx.DeleteWebhookSubscription(ctx, params.ID)
+	if err == sql.ErrNoRows {
+		respondWithError(w, codeNotFound, "subscription not found")
+		return
+	}
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	deleted := dbWebhookSubscriptionToWebhookSubscription(sub)
+	if err := service.RecordAudit(ctx, storage.NewTxRepository(tx), principalFromContext(ctx).actor(), auditActionWebhookDelete, auditEntityWebhookSubscription, strconv.FormatInt(sub.ID, 10), deleted, nil); err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"subscription": deleted,
+	})
+}
+
+// handlerListWebhookDeliveries handles HTTP GET requests to inspect the outbox
+// Supported filters: subscription_id and status (PENDING, DELIVERED, DEAD).
+// Results are ordered newest first and paginated with an opaque cursor
+func (api *apiConfig) handlerListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	params := database.ListOutboxDeliveriesParams{
+		PageLimit: defaultDeliveryPageSize,
+	}
+
+	// Validate filters
+	if query.Get("subscription_id") != "" {
+		id, err := parseIDQuery(r, "subscription_id")
+		if err != nil {
+			respondWithError(w, codeBadRequest, err.Error())
+			return
+		}
+		params.SubscriptionID = sql.NullInt64{Int64: id, Valid: true}
+	}
+	if status := query.Get("status"); status != "" {
+		switch database.OutboxStatus(status) {
+		case database.OutboxStatusPENDING, database.OutboxStatusDELIVERED, database.OutboxStatusDEAD:
+			params.Status = database.NullOutboxStatus{OutboxStatus: database.OutboxStatus(status), Valid: true}
+		default:
+			respondWithError(w, codeBadRequest, "status must be one of PENDING, DELIVERED, DEAD")
+			return
+		}
+	}
+
+	// Validate page size
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxDeliveryPageSize {
+			respondWithError(w, codeBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxDeliveryPageSize))
+			return
+		}
+		params.PageLimit = int32(n)
+	}
+
+	// Continue after the cursor of the previous page
+	if cursor := query.Get("cursor"); cursor != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(cursor)
+		id, parseErr := strconv.ParseInt(string(raw), 10, 64)
+		if err != nil || parseErr != nil {
+			respondWithError(w, codeBadRequest, "invalid cursor")
+			return
+		}
+		params.CursorID = sql.NullInt64{Int64: id, Valid: true}
+	}
+
+	rows, err := api.DB.ListOutboxDeliveries(r.Context(), params)
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	deliveries := make([]WebhookDelivery, len(rows))
+	for i, row := range rows {
+		deliveries[i] = dbOutboxToWebhookDelivery(row)
+	}
+
+	// A full page means there may be more results
+	var nextCursor *string
+	if len(rows) == int(params.PageLimit) {
+		cursor := base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(rows[len(rows)-1].ID, 10)))
+		nextCursor = &cursor
+	}
+
+	response := struct {
+		Deliveries []WebhookDelivery `json:"deliveries"`  // Page of deliveries
+		NextCursor *string           `json:"next_cursor"` // Cursor for the next page, null on the last page
+	}{
+		Deliveries: deliveries,
+		NextCursor: nextCursor,
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// handlerRetryWebhookDelivery handles HTTP POST requests to requeue a dead delivery
+// The delivery gets a fresh set of attempts and is picked up on the next poll
+func (api *apiConfig) handlerRetryWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		ID int64 `json:"id"` // Delivery ID
+	}
+
+	// Decode JSON request body
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		respondWithError(w, codeBadRequest, "invalid json")
+		return
+	}
+	if params.ID < 1 {
+		respondWithError(w, codeBadRequest, "id is required")
+		return
+	}
+
+	ctx := r.Context()
+
+	// Transaction: requeue the delivery together with its audit event
+	tx, err := api.DB.BeginTx(ctx)
+	if err != nil {
+		respondWithAPIError(w, fmt.Errorf("cannot begin tx: %w", err))
+		return
+	}
+	defer tx.Rollback()
+
+	// No row means the delivery doesn't exist or isn't DEAD
+	delivery, err := tx.RetryOutboxDelivery(ctx, params.ID)
+	if err == sql.ErrNoRows {
+		respondWithError(w, codeNotFound, "dead delivery not found")
+		return
+	}
+	if err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	requeued := dbOutboxToWebhookDelivery(delivery)
+	if err := service.RecordAudit(ctx, storage.NewTxRepository(tx), principalFromContext(ctx).actor(), auditActionDeliveryRetry, auditEntityWebhookDelivery, strconv.FormatInt(delivery.ID, 10), nil, requeued); err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithAPIError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"delivery": requeued,
+	})
+}
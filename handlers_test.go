@@ -0,0 +1,825 @@
+package main
+
+import (
+	"GODanilich/avito_backend/internal/database"
+	"GODanilich/avito_backend/internal/service"
+	"GODanilich/avito_backend/internal/storage"
+	"GODanilich/avito_backend/internal/storage/memory"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testAdminToken = "test-admin-token"
+
+// newTestAPI returns the API router on an empty in-memory store
+func newTestAPI(t *testing.T) (http.Handler, *memory.Store) {
+	t.Helper()
+	store := memory.New()
+	return newTestAPIWithStore(t, store), store
+}
+
+// newTestAPIWithStore returns the API router on store
+// The admin token is testAdminToken, both code host webhooks are enabled and
+// responses that don't match openapi.yml fail the test
+func newTestAPIWithStore(t *testing.T, store storage.Store) http.Handler {
+	t.Helper()
+	if err := bootstrapAdminToken(context.Background(), store, testAdminToken); err != nil {
+		t.Fatalf("bootstrap admin token: %v", err)
+	}
+
+	// Every response has to match openapi.yml
+	validator, err := newOpenAPIValidator(func(r *http.Request, err error) {
+		t.Errorf("%s %s: response doesn't match openapi.yml: %v", r.Method, r.URL, err)
+	})
+	if err != nil {
+		t.Fatalf("load openapi.yml: %v", err)
+	}
+
+	api := &apiConfig{
+		DB:                  store,
+		reviews:             service.New(storage.NewRepository(store), service.StrategyLeastLoaded),
+		metrics:             newAppMetrics(),
+		openAPI:             validator,
+		GitHubWebhookSecret: testGitHubSecret,
+		GitLabWebhookToken:  testGitLabToken,
+	}
+	return api.routes()
+}
+
+// apiCase is one request in a table-driven test
+// Cases run in order against the same store, so later cases see earlier changes
+type apiCase struct {
+	name   string
+	method string
+	path   string
+	body   string
+	token  string // Bearer token, testAdminToken when empty
+	status int
+	code   string                                          // Expected error code, empty for success responses
+	check  func(t *testing.T, body map[string]interface{}) // Optional assertions on the response body
+}
+
+func runAPICases(t *testing.T, handler http.Handler, cases []apiCase) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			token := tc.token
+			if token == "" {
+				token = testAdminToken
+			}
+			rec := doRequest(handler, tc.method, tc.path, token, tc.body)
+			if rec.Code != tc.status {
+				t.Fatalf("%s %s: status = %d, want %d, body: %s", tc.method, tc.path, rec.Code, tc.status, rec.Body)
+			}
+
+			body := decodeBody(t, rec)
+			if tc.code != "" {
+				if got := responseErrorCode(body); got != tc.code {
+					t.Errorf("error code = %q, want %q, body: %s", got, tc.code, rec.Body)
+				}
+			}
+			if tc.check != nil {
+				tc.check(t, body)
+			}
+		})
+	}
+}
+
+func doRequest(handler http.Handler, method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// decodeBody parses a JSON object response, empty bodies decode to nil
+func decodeBody(t *testing.T, rec *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+	if rec.Body.Len() == 0 {
+		return nil
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response %q: %v", rec.Body, err)
+	}
+	return body
+}
+
+func responseErrorCode(body map[string]interface{}) string {
+	apiErr, _ := body["error"].(map[string]interface{})
+	code, _ := apiErr["code"].(string)
+	return code
+}
+
+// field walks nested JSON objects, e.g. field(body, "pr", "status")
+func field(body map[string]interface{}, path ...string) interface{} {
+	var value interface{} = body
+	for _, key := range path {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+func expectField(path []string, want interface{}) func(t *testing.T, body map[string]interface{}) {
+	return func(t *testing.T, body map[string]interface{}) {
+		t.Helper()
+		if got := field(body, path...); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%v = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func expectReviewers(path []string, want ...string) func(t *testing.T, body map[string]interface{}) {
+	return expectField(path, want)
+}
+
+func expectLen(path []string, want int) func(t *testing.T, body map[string]interface{}) {
+	return func(t *testing.T, body map[string]interface{}) {
+		t.Helper()
+		items, _ := field(body, path...).([]interface{})
+		if len(items) != want {
+			t.Errorf("len(%v) = %d, want %d", path, len(items), want)
+		}
+	}
+}
+
+// expectAll runs every check on the response body
+func expectAll(checks ...func(t *testing.T, body map[string]interface{})) func(t *testing.T, body map[string]interface{}) {
+	return func(t *testing.T, body map[string]interface{}) {
+		t.Helper()
+		for _, check := range checks {
+			check(t, body)
+		}
+	}
+}
+
+// teamBody builds a /team/add request, members are active unless listed in inactive
+func teamBody(teamName string, members []string, inactive ...string) string {
+	type member struct {
+		UserID   string `json:"user_id"`
+		Username string `json:"username"`
+		IsActive bool   `json:"is_active"`
+	}
+	body := struct {
+		TeamName string   `json:"team_name"`
+		Members  []member `json:"members"`
+	}{TeamName: teamName, Members: []member{}}
+	for _, id := range members {
+		active := true
+		for _, off := range inactive {
+			if off == id {
+				active = false
+			}
+		}
+		body.Members = append(body.Members, member{UserID: id, Username: "name-" + id, IsActive: active})
+	}
+	buf, _ := json.Marshal(body)
+	return string(buf)
+}
+
+// mustRequest runs a setup request and fails the test unless it returns status
+func mustRequest(t *testing.T, handler http.Handler, method, path, body string, status int) map[string]interface{} {
+	t.Helper()
+	rec := doRequest(handler, method, path, testAdminToken, body)
+	if rec.Code != status {
+		t.Fatalf("%s %s: status = %d, want %d, body: %s", method, path, rec.Code, status, rec.Body)
+	}
+	return decodeBody(t, rec)
+}
+
+// createUserToken issues a token bound to userID and returns its plain value
+func createUserToken(t *testing.T, handler http.Handler, name, userID string) string {
+	t.Helper()
+	body := mustRequest(t, handler, http.MethodPost, "/api/v1/auth/tokens/create",
+		fmt.Sprintf(`{"name":%q,"role":"user","user_id":%q}`, name, userID), http.StatusCreated)
+	return body["token"].(string)
+}
+
+func TestHealthAndMetrics(t *testing.T) {
+	handler, _ := newTestAPI(t)
+
+	for _, path := range []string{"/api/v1/health", "/api/v1/health/live", "/api/v1/health/ready", "/metrics"} {
+		if rec := doRequest(handler, http.MethodGet, path, "", ""); rec.Code != http.StatusOK {
+			t.Errorf("GET %s: status = %d, want 200", path, rec.Code)
+		}
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	handler, _ := newTestAPI(t)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/team/add", teamBody("backend", []string{"u1"}), http.StatusCreated)
+	userToken := createUserToken(t, handler, "u1-token", "u1")
+
+	runAPICases(t, handler, []apiCase{
+		{name: "invalid token", method: http.MethodGet, path: "/api/v1/team/get?team_name=backend", token: "wrong", status: http.StatusUnauthorized, code: "UNAUTHORIZED"},
+		{name: "user token on user route", method: http.MethodGet, path: "/api/v1/team/get?team_name=backend", token: userToken, status: http.StatusOK},
+		{name: "user token on admin route", method: http.MethodPost, path: "/api/v1/team/add", token: userToken, body: teamBody("other", nil), status: http.StatusForbidden, code: "FORBIDDEN"},
+		{name: "user creates PR for another author", method: http.MethodPost, path: "/api/v1/pullRequest/create", token: userToken,
+			body: `{"pull_request_id":"pr-1","pull_request_name":"x","author_id":"u2"}`, status: http.StatusForbidden, code: "FORBIDDEN"},
+	})
+
+	// Requests without any token are rejected before routing to handlers
+	if rec := doRequest(handler, http.MethodGet, "/api/v1/team/get?team_name=backend", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("missing token: status = %d, want 401", rec.Code)
+	}
+
+	// A principal already in the context doesn't replace the token
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/team/get?team_name=backend", nil)
+	req = req.WithContext(context.WithValue(req.Context(), principalContextKey{}, principal{TokenName: "ctx", Role: database.TokenRoleAdmin}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("principal without token: status = %d, want 401", rec.Code)
+	}
+}
+
+func TestTeamEndpoints(t *testing.T) {
+	handler, _ := newTestAPI(t)
+
+	runAPICases(t, handler, []apiCase{
+		{name: "add team", method: http.MethodPost, path: "/api/v1/team/add", body: teamBody("backend", []string{"u1", "u2", "u3"}, "u3"),
+			status: http.StatusCreated, check: expectLen([]string{"team", "members"}, 3)},
+		{name: "add existing team", method: http.MethodPost, path: "/api/v1/team/add", body: teamBody("backend", []string{"u4"}),
+			status: http.StatusBadRequest, code: "TEAM_EXISTS"},
+		{name: "add team with invalid json", method: http.MethodPost, path: "/api/v1/team/add", body: `{`,
+			status: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "add team with member of wrong type", method: http.MethodPost, path: "/api/v1/team/add", body: `{"team_name":"frontend","members":[{"user_id":1}]}`,
+			status: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "add team without name", method: http.MethodPost, path: "/api/v1/team/add", body: teamBody("", nil),
+			status: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "add team with empty user_id", method: http.MethodPost, path: "/api/v1/team/add", body: teamBody("frontend", []string{""}),
+			status: http.StatusBadRequest, code: "INVALID_USER_ID"},
+		{name: "get team", method: http.MethodGet, path: "/api/v1/team/get?team_name=backend",
+			status: http.StatusOK, check: expectField([]string{"team_name"}, "backend")},
+		{name: "get unknown team", method: http.MethodGet, path: "/api/v1/team/get?team_name=nope",
+			status: http.StatusNotFound, code: "NOT_FOUND"},
+		{name: "get team without name", method: http.MethodGet, path: "/api/v1/team/get",
+			status: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "get default settings", method: http.MethodGet, path: "/api/v1/team/settings/get?team_name=backend",
+			status: http.StatusOK, check: expectField([]string{"settings", "max_reviewers"}, 2)},
+		{name: "get settings of unknown team", method: http.MethodGet, path: "/api/v1/team/settings/get?team_name=nope",
+			status: http.StatusNotFound, code: "NOT_FOUND"},
+		{name: "set settings", method: http.MethodPost, path: "/api/v1/team/settings/set",
+			body:   `{"team_name":"backend","max_reviewers":1,"strategy":"round_robin"}`,
+			status: http.StatusOK, check: expectField([]string{"settings", "strategy"}, "round_robin")},
+		{name: "set min above max", method: http.MethodPost, path: "/api/v1/team/settings/set",
+			body: `{"team_name":"backend","min_reviewers":3}`, status: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "set unknown strategy", method: http.MethodPost, path: "/api/v1/team/settings/set",
+			body: `{"team_name":"backend","strategy":"fastest"}`, status: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "set settings of unknown team", method: http.MethodPost, path: "/api/v1/team/settings/set",
+			body: `{"team_name":"nope","max_reviewers":1}`, status: http.StatusNotFound, code: "NOT_FOUND"},
+		{name: "settings survive", method: http.MethodGet, path: "/api/v1/team/settings/get?team_name=backend",
+			status: http.StatusOK, check: expectField([]string{"settings", "max_reviewers"}, 1)},
+		{name: "create PR reviewed by u2", method: http.MethodPost, path: "/api/v1/pullRequest/create",
+			body:   `{"pull_request_id":"pr-1","pull_request_name":"x","author_id":"u1"}`,
+			status: http.StatusCreated, check: expectReviewers([]string{"pr", "assigned_reviewers"}, "u2")},
+		{name: "activate replacement", method: http.MethodPost, path: "/api/v1/users/setIsActive",
+			body: `{"user_id":"u3","is_active":true}`, status: http.StatusOK},
+		{name: "deactivate reviewer", method: http.MethodPost, path: "/api/v1/team/deactivateUsers",
+			body: `{"team_name":"backend","user_ids":["u2"]}`, status: http.StatusOK, check: expectAll(
+				expectLen([]string{"deactivated"}, 1),
+				expectField([]string{"reassignments"}, []map[string]interface{}{
+					{"pull_request_id": "pr-1", "old_reviewer_id": "u2", "new_reviewer_id": "u3"},
+				}),
+				expectLen([]string{"short_of_reviewers"}, 0),
+			)},
+		{name: "deactivate last free reviewer", method: http.MethodPost, path: "/api/v1/team/deactivateUsers",
+			body: `{"team_name":"backend","user_ids":["u3"]}`, status: http.StatusOK, check: expectAll(
+				expectLen([]string{"reassignments"}, 0),
+				expectField([]string{"short_of_reviewers"}, []map[string]interface{}{
+					{"pull_request_id": "pr-1", "removed_reviewer_id": "u3", "assigned_reviewers": []string{}},
+				}),
+			)},
+		{name: "deactivate user without reviews", method: http.MethodPost, path: "/api/v1/team/deactivateUsers",
+			body: `{"team_name":"backend","user_ids":["u1"]}`, status: http.StatusOK, check: expectAll(
+				expectLen([]string{"deactivated"}, 1),
+				expectLen([]string{"reassignments"}, 0),
+				expectLen([]string{"short_of_reviewers"}, 0),
+			)},
+		{name: "deactivate user of another team", method: http.MethodPost, path: "/api/v1/team/deactivateUsers",
+			body: `{"team_name":"backend","user_ids":["u9"]}`, status: http.StatusNotFound, code: "NOT_FOUND"},
+		{name: "deactivate without users", method: http.MethodPost, path: "/api/v1/team/deactivateUsers",
+			body: `{"team_name":"backend","user_ids":[]}`, status: http.StatusBadRequest, code: "BAD_REQUEST"},
+	})
+}
+
+func TestAddTeamReportsMovedMembers(t *testing.T) {
+	handler, _ := newTestAPI(t)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/team/add", teamBody("backend", []string{"u1", "u2"}), http.StatusCreated)
+
+	runAPICases(t, handler, []apiCase{
+		{name: "new members only", method: http.MethodPost, path: "/api/v1/team/add", body: teamBody("frontend", []string{"u3"}),
+			status: http.StatusCreated, check: expectLen([]string{"moved"}, 0)},
+		{name: "member of another team", method: http.MethodPost, path: "/api/v1/team/add", body: teamBody("payments", []string{"u2", "u4"}),
+			status: http.StatusCreated, check: func(t *testing.T, body map[string]interface{}) {
+				moved, _ := body["moved"].([]interface{})
+				if len(moved) != 1 {
+					t.Fatalf("moved = %v, want one move", body["moved"])
+				}
+				move, _ := moved[0].(map[string]interface{})
+				expectField([]string{"user_id"}, "u2")(t, move)
+				expectField([]string{"from_team"}, "backend")(t, move)
+				expectField([]string{"to_team"}, "payments")(t, move)
+			}},
+		{name: "moved member left the old team", method: http.MethodGet, path: "/api/v1/team/get?team_name=backend",
+			status: http.StatusOK, check: expectLen([]string{"members"}, 1)},
+	})
+}
+
+func TestUserEndpoints(t *testing.T) {
+	handler, _ := newTestAPI(t)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/team/add", teamBody("backend", []string{"u1", "u2"}), http.StatusCreated)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/pullRequest/create",
+		`{"pull_request_id":"pr-1","pull_request_name":"Add search","author_id":"u1"}`, http.StatusCreated)
+
+	runAPICases(t, handler, []apiCase{
+		{name: "set inactive", method: http.MethodPost, path: "/api/v1/users/setIsActive", body: `{"user_id":"u2","is_active":false}`,
+			status: http.StatusOK, check: expectField([]string{"user", "is_active"}, false)},
+		{name: "set unknown user", method: http.MethodPost, path: "/api/v1/users/setIsActive", body: `{"user_id":"u9","is_active":false}`,
+			status: http.StatusNotFound, code: "NOT_FOUND"},
+		{name: "set without user_id", method: http.MethodPost, path: "/api/v1/users/setIsActive", body: `{"is_active":false}`,
+			status: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "reviews of user", method: http.MethodGet, path: "/api/v1/users/getReview?user_id=u2",
+			status: http.StatusOK, check: expectLen([]string{"pull_requests"}, 1)},
+		{name: "reviews filtered by state", method: http.MethodGet, path: "/api/v1/users/getReview?user_id=u2&state=APPROVED",
+			status: http.StatusOK, check: expectLen([]string{"pull_requests"}, 0)},
+		{name: "reviews with unknown state", method: http.MethodGet, path: "/api/v1/users/getReview?user_id=u2&state=DONE",
+			status: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "reviews of unknown user", method: http.MethodGet, path: "/api/v1/users/getReview?user_id=u9",
+			status: http.StatusNotFound, code: "NOT_FOUND"},
+	})
+}
+
+func TestPullRequestLifecycle(t *testing.T) {
+	handler, store := newTestAPI(t)
+
+	// Round robin makes the choice deterministic: u2 and u3 first, then u4
+	mustRequest(t, handler, http.MethodPost, "/api/v1/team/add", teamBody("backend", []string{"u1", "u2", "u3", "u4", "u5"}, "u5"), http.StatusCreated)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/team/settings/set", `{"team_name":"backend","strategy":"round_robin"}`, http.StatusOK)
+
+	// A user outside of any team, the API can't create one
+	if err := store.UpsertUser(context.Background(), database.UpsertUserParams{UserID: "loner", Username: "loner", IsActive: true}); err != nil {
+		t.Fatalf("insert user without team: %v", err)
+	}
+
+	runAPICases(t, handler, []apiCase{
+		{name: "create", method: http.MethodPost, path: "/api/v1/pullRequest/create",
+			body:   `{"pull_request_id":"pr-1","pull_request_name":"Add search","author_id":"u1"}`,
+			status: http.StatusCreated, check: expectReviewers([]string{"pr", "assigned_reviewers"}, "u2", "u3")},
+		{name: "create existing", method: http.MethodPost, path: "/api/v1/pullRequest/create",
+			body:   `{"pull_request_id":"pr-1","pull_request_name":"Add search","author_id":"u1"}`,
+			status: http.StatusConflict, code: "PR_EXISTS"},
+		{name: "create for unknown author", method: http.MethodPost, path: "/api/v1/pullRequest/create",
+			body:   `{"pull_request_id":"pr-2","pull_request_name":"x","author_id":"u9"}`,
+			status: http.StatusNotFound, code: "NOT_FOUND"},
+		{name: "create for author without team", method: http.MethodPost, path: "/api/v1/pullRequest/create",
+			body:   `{"pull_request_id":"pr-2","pull_request_name":"x","author_id":"loner"}`,
+			status: http.StatusNotFound, code: "NOT_FOUND"},
+		{name: "create without name", method: http.MethodPost, path: "/api/v1/pullRequest/create",
+			body:   `{"pull_request_id":"pr-2","author_id":"u1"}`,
+			status: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "get", method: http.MethodGet, path: "/api/v1/pullRequest/get?pull_request_id=pr-1",
+			status: http.StatusOK, check: expectField([]string{"pr", "status"}, "OPEN")},
+		{name: "get unknown", method: http.MethodGet, path: "/api/v1/pullRequest/get?pull_request_id=nope",
+			status: http.StatusNotFound, code: "NOT_FOUND"},
+		{name: "list", method: http.MethodGet, path: "/api/v1/pullRequest/list?team_name=backend",
+			status: http.StatusOK, check: expectLen([]string{"pull_requests"}, 1)},
+		{name: "list by reviewer", method: http.MethodGet, path: "/api/v1/pullRequest/list?reviewer_id=u4",
+			status: http.StatusOK, check: expectLen([]string{"pull_requests"}, 0)},
+		{name: "list with unknown status", method: http.MethodGet, path: "/api/v1/pullRequest/list?status=DONE",
+			status: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "rename", method: http.MethodPost, path: "/api/v1/pullRequest/rename",
+			body:   `{"pull_request_id":"pr-1","pull_request_name":"Add full text search"}`,
+			status: http.StatusOK, check: expectField([]string{"pr", "pull_request_name"}, "Add full text search")},
+		{name: "rename unknown", method: http.MethodPost, path: "/api/v1/pullRequest/rename",
+			body:   `{"pull_request_id":"nope","pull_request_name":"x"}`,
+			status: http.StatusNotFound, code: "NOT_FOUND"},
+		{name: "review", method: http.MethodPost, path: "/api/v1/pullRequest/review",
+			body:   `{"pull_request_id":"pr-1","reviewer_id":"u3","state":"APPROVED"}`,
+			status: http.StatusOK, check: expectField([]string{"review", "state"}, "APPROVED")},
+		{name: "review by reviewer not assigned", method: http.MethodPost, path: "/api/v1/pullRequest/review",
+			body:   `{"pull_request_id":"pr-1","reviewer_id":"u4","state":"APPROVED"}`,
+			status: http.StatusConflict, code: "NOT_ASSIGNED"},
+		{name: "review with unknown state", method: http.MethodPost, path: "/api/v1/pullRequest/review",
+			body:   `{"pull_request_id":"pr-1","reviewer_id":"u3","state":"LGTM"}`,
+			status: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "reassign", method: http.MethodPost, path: "/api/v1/pullRequest/reassign",
+			body:   `{"pull_request_id":"pr-1","old_reviewer_id":"u2"}`,
+			status: http.StatusOK, check: expectField([]string{"replaced_by"}, "u4")},
+		{name: "reassign reviewer not assigned", method: http.MethodPost, path: "/api/v1/pullRequest/reassign",
+			body:   `{"pull_request_id":"pr-1","old_reviewer_id":"u2"}`,
+			status: http.StatusConflict, code: "NOT_ASSIGNED"},
+		{name: "deactivate replaced reviewer", method: http.MethodPost, path: "/api/v1/users/setIsActive",
+			body: `{"user_id":"u2","is_active":false}`, status: http.StatusOK},
+		{name: "reassign without free teammate", method: http.MethodPost, path: "/api/v1/pullRequest/reassign",
+			body:   `{"pull_request_id":"pr-1","old_reviewer_id":"u3"}`,
+			status: http.StatusConflict, code: "NO_CANDIDATE"},
+		{name: "reassign on unknown PR", method: http.MethodPost, path: "/api/v1/pullRequest/reassign",
+			body:   `{"pull_request_id":"nope","old_reviewer_id":"u3"}`,
+			status: http.StatusNotFound, code: "NOT_FOUND"},
+		{name: "close", method: http.MethodPost, path: "/api/v1/pullRequest/close", body: `{"pull_request_id":"pr-1"}`,
+			status: http.StatusOK, check: expectField([]string{"pr", "status"}, "CLOSED")},
+		{name: "merge closed", method: http.MethodPost, path: "/api/v1/pullRequest/merge", body: `{"pull_request_id":"pr-1"}`,
+			status: http.StatusConflict, code: "PR_CLOSED"},
+		{name: "deactivate reviewer of closed PR", method: http.MethodPost, path: "/api/v1/users/setIsActive",
+			body: `{"user_id":"u4","is_active":false}`, status: http.StatusOK},
+		{name: "reactivate former reviewer", method: http.MethodPost, path: "/api/v1/users/setIsActive",
+			body: `{"user_id":"u2","is_active":true}`, status: http.StatusOK},
+		{name: "reopen", method: http.MethodPost, path: "/api/v1/pullRequest/reopen", body: `{"pull_request_id":"pr-1"}`,
+			status: http.StatusOK, check: expectAll(
+				expectField([]string{"pr", "status"}, "OPEN"),
+				expectReviewers([]string{"removed_reviewers"}, "u4"),
+				expectReviewers([]string{"added_reviewers"}, "u2"),
+				expectReviewers([]string{"pr", "assigned_reviewers"}, "u2", "u3"),
+			)},
+		{name: "reopen open PR", method: http.MethodPost, path: "/api/v1/pullRequest/reopen", body: `{"pull_request_id":"pr-1"}`,
+			status: http.StatusOK, check: expectAll(
+				expectReviewers([]string{"removed_reviewers"}),
+				expectReviewers([]string{"added_reviewers"}),
+			)},
+		{name: "merge", method: http.MethodPost, path: "/api/v1/pullRequest/merge", body: `{"pull_request_id":"pr-1"}`,
+			status: http.StatusOK, check: expectField([]string{"pr", "status"}, "MERGED")},
+		{name: "merge unknown", method: http.MethodPost, path: "/api/v1/pullRequest/merge", body: `{"pull_request_id":"nope"}`,
+			status: http.StatusNotFound, code: "NOT_FOUND"},
+		{name: "reassign on merged PR", method: http.MethodPost, path: "/api/v1/pullRequest/reassign",
+			body:   `{"pull_request_id":"pr-1","old_reviewer_id":"u3"}`,
+			status: http.StatusConflict, code: "PR_MERGED"},
+		{name: "review merged PR", method: http.MethodPost, path: "/api/v1/pullRequest/review",
+			body:   `{"pull_request_id":"pr-1","reviewer_id":"u3","state":"PENDING"}`,
+			status: http.StatusConflict, code: "PR_MERGED"},
+		{name: "close merged PR", method: http.MethodPost, path: "/api/v1/pullRequest/close", body: `{"pull_request_id":"pr-1"}`,
+			status: http.StatusConflict, code: "PR_MERGED"},
+		{name: "reopen merged PR", method: http.MethodPost, path: "/api/v1/pullRequest/reopen", body: `{"pull_request_id":"pr-1"}`,
+			status: http.StatusConflict, code: "PR_MERGED"},
+	})
+}
+
+// Wildcards in q are literal, the same way in the memory store as in PostgreSQL
+func TestListPRsNameFilter(t *testing.T) {
+	handler, _ := newTestAPI(t)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/team/add", teamBody("backend", []string{"u1", "u2"}), http.StatusCreated)
+	for i, name := range []string{"100% done", "1000 done", "Rename snake_case", "Rename snakeXcase", `Fix C:\tmp`, "Fix Ctmp"} {
+		mustRequest(t, handler, http.MethodPost, "/api/v1/pullRequest/create",
+			fmt.Sprintf(`{"pull_request_id":"pr-%d","pull_request_name":%q,"author_id":"u1"}`, i, name), http.StatusCreated)
+	}
+
+	runAPICases(t, handler, []apiCase{
+		{name: "case insensitive", method: http.MethodGet, path: "/api/v1/pullRequest/list?q=DONE",
+			status: http.StatusOK, check: expectLen([]string{"pull_requests"}, 2)},
+		{name: "percent", method: http.MethodGet, path: "/api/v1/pullRequest/list?q=0%25",
+			status: http.StatusOK, check: expectLen([]string{"pull_requests"}, 1)},
+		{name: "underscore", method: http.MethodGet, path: "/api/v1/pullRequest/list?q=e_c",
+			status: http.StatusOK, check: expectLen([]string{"pull_requests"}, 1)},
+		{name: "backslash", method: http.MethodGet, path: "/api/v1/pullRequest/list?q=%5Ct",
+			status: http.StatusOK, check: expectLen([]string{"pull_requests"}, 1)},
+		{name: "no match", method: http.MethodGet, path: "/api/v1/pullRequest/list?q=%25%25",
+			status: http.StatusOK, check: expectLen([]string{"pull_requests"}, 0)},
+	})
+}
+
+func TestCreatePRWithFewCandidates(t *testing.T) {
+	handler, _ := newTestAPI(t)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/team/add", teamBody("pair", []string{"p1", "p2"}), http.StatusCreated)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/team/add", teamBody("solo", []string{"s1"}), http.StatusCreated)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/team/add", teamBody("strict", []string{"t1", "t2", "t3"}, "t3"), http.StatusCreated)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/team/settings/set", `{"team_name":"strict","min_reviewers":2}`, http.StatusOK)
+
+	runAPICases(t, handler, []apiCase{
+		{name: "single candidate", method: http.MethodPost, path: "/api/v1/pullRequest/create",
+			body:   `{"pull_request_id":"pair-1","pull_request_name":"x","author_id":"p1"}`,
+			status: http.StatusCreated, check: expectReviewers([]string{"pr", "assigned_reviewers"}, "p2")},
+		{name: "no candidates", method: http.MethodPost, path: "/api/v1/pullRequest/create",
+			body:   `{"pull_request_id":"solo-1","pull_request_name":"x","author_id":"s1"}`,
+			status: http.StatusCreated, check: expectReviewers([]string{"pr", "assigned_reviewers"})},
+		{name: "fewer than min_reviewers", method: http.MethodPost, path: "/api/v1/pullRequest/create",
+			body:   `{"pull_request_id":"strict-1","pull_request_name":"x","author_id":"t1"}`,
+			status: http.StatusConflict, code: "NO_CANDIDATE"},
+		{name: "rejected PR is not stored", method: http.MethodGet, path: "/api/v1/pullRequest/get?pull_request_id=strict-1",
+			status: http.StatusNotFound, code: "NOT_FOUND"},
+		{name: "reassign the only candidate", method: http.MethodPost, path: "/api/v1/pullRequest/reassign",
+			body:   `{"pull_request_id":"pair-1","old_reviewer_id":"p2"}`,
+			status: http.StatusConflict, code: "NO_CANDIDATE"},
+	})
+}
+
+func TestMergeIsIdempotent(t *testing.T) {
+	handler, _ := newTestAPI(t)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/team/add", teamBody("backend", []string{"u1", "u2"}), http.StatusCreated)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/pullRequest/create",
+		`{"pull_request_id":"pr-1","pull_request_name":"x","author_id":"u1"}`, http.StatusCreated)
+
+	first := mustRequest(t, handler, http.MethodPost, "/api/v1/pullRequest/merge", `{"pull_request_id":"pr-1"}`, http.StatusOK)
+	second := mustRequest(t, handler, http.MethodPost, "/api/v1/pullRequest/merge", `{"pull_request_id":"pr-1"}`, http.StatusOK)
+
+	if got, want := field(second, "pr", "mergedAt"), field(first, "pr", "mergedAt"); got != want {
+		t.Errorf("mergedAt changed on repeated merge: %v, then %v", want, got)
+	}
+	if got := field(second, "pr", "status"); got != "MERGED" {
+		t.Errorf("status = %v, want MERGED", got)
+	}
+
+	// Only the first merge is a change worth auditing
+	audit := mustRequest(t, handler, http.MethodGet, "/api/v1/audit/list?action=pr.merge", "", http.StatusOK)
+	if events, _ := audit["events"].([]interface{}); len(events) != 1 {
+		t.Errorf("pr.merge audit events = %d, want 1", len(events))
+	}
+}
+
+func TestMergeRequiresApprovals(t *testing.T) {
+	handler, _ := newTestAPI(t)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/team/add", teamBody("backend", []string{"u1", "u2", "u3"}), http.StatusCreated)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/team/settings/set", `{"team_name":"backend","required_approvals":1}`, http.StatusOK)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/pullRequest/create",
+		`{"pull_request_id":"pr-1","pull_request_name":"x","author_id":"u1"}`, http.StatusCreated)
+
+	runAPICases(t, handler, []apiCase{
+		{name: "merge without approvals", method: http.MethodPost, path: "/api/v1/pullRequest/merge", body: `{"pull_request_id":"pr-1"}`,
+			status: http.StatusConflict, code: "NOT_APPROVED"},
+		{name: "approve", method: http.MethodPost, path: "/api/v1/pullRequest/review",
+			body: `{"pull_request_id":"pr-1","reviewer_id":"u2","state":"APPROVED"}`, status: http.StatusOK},
+		{name: "request changes after approving", method: http.MethodPost, path: "/api/v1/pullRequest/review",
+			body: `{"pull_request_id":"pr-1","reviewer_id":"u2","state":"CHANGES_REQUESTED"}`, status: http.StatusOK},
+		{name: "merge with changes requested", method: http.MethodPost, path: "/api/v1/pullRequest/merge", body: `{"pull_request_id":"pr-1"}`,
+			status: http.StatusConflict, code: "NOT_APPROVED"},
+		{name: "approve again", method: http.MethodPost, path: "/api/v1/pullRequest/review",
+			body: `{"pull_request_id":"pr-1","reviewer_id":"u2","state":"APPROVED"}`, status: http.StatusOK},
+		{name: "merge approved", method: http.MethodPost, path: "/api/v1/pullRequest/merge", body: `{"pull_request_id":"pr-1"}`,
+			status: http.StatusOK, check: expectField([]string{"pr", "status"}, "MERGED")},
+	})
+}
+
+func TestStatsEndpoint(t *testing.T) {
+	handler, _ := newTestAPI(t)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/team/add", teamBody("backend", []string{"u1", "u2"}), http.StatusCreated)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/pullRequest/create",
+		`{"pull_request_id":"pr-1","pull_request_name":"x","author_id":"u1"}`, http.StatusCreated)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/pullRequest/merge", `{"pull_request_id":"pr-1"}`, http.StatusOK)
+
+	runAPICases(t, handler, []apiCase{
+		{name: "stats", method: http.MethodGet, path: "/api/v1/stats/get", status: http.StatusOK,
+			check: expectLen([]string{"merge_time", "by_team"}, 1)},
+		{name: "stats in window", method: http.MethodGet, path: "/api/v1/stats/get?from=2000-01-01T00:00:00Z&to=2000-01-02T00:00:00Z",
+			status: http.StatusOK, check: expectLen([]string{"merge_time", "by_team"}, 0)},
+		{name: "stats with invalid window", method: http.MethodGet, path: "/api/v1/stats/get?from=yesterday",
+			status: http.StatusBadRequest, code: "BAD_REQUEST"},
+	})
+}
+
+// Time to first review keeps the first verdict when it is changed or the reviewer is replaced
+func TestFirstReviewTimeIsSetOnce(t *testing.T) {
+	handler, _ := newTestAPI(t)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/team/add", teamBody("backend", []string{"u1", "u2", "u3", "u4"}), http.StatusCreated)
+	created := mustRequest(t, handler, http.MethodPost, "/api/v1/pullRequest/create",
+		`{"pull_request_id":"pr-1","pull_request_name":"x","author_id":"u1"}`, http.StatusCreated)
+	reviewer := field(created, "pr", "assigned_reviewers").([]interface{})[0].(string)
+
+	firstReview := func() interface{} {
+		t.Helper()
+		stats := mustRequest(t, handler, http.MethodGet, "/api/v1/stats/get", "", http.StatusOK)
+		byAuthor, _ := field(stats, "first_review_time", "by_author").([]interface{})
+		if len(byAuthor) != 1 {
+			t.Fatalf("first_review_time.by_author = %v, want one author", byAuthor)
+		}
+		return byAuthor[0].(map[string]interface{})["p50_seconds"]
+	}
+
+	mustRequest(t, handler, http.MethodPost, "/api/v1/pullRequest/review",
+		fmt.Sprintf(`{"pull_request_id":"pr-1","reviewer_id":%q,"state":"APPROVED"}`, reviewer), http.StatusOK)
+	want := firstReview()
+
+	time.Sleep(20 * time.Millisecond)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/pullRequest/review",
+		fmt.Sprintf(`{"pull_request_id":"pr-1","reviewer_id":%q,"state":"CHANGES_REQUESTED"}`, reviewer), http.StatusOK)
+	if got := firstReview(); got != want {
+		t.Errorf("after a changed verdict p50 = %v, want %v", got, want)
+	}
+
+	mustRequest(t, handler, http.MethodPost, "/api/v1/pullRequest/reassign",
+		fmt.Sprintf(`{"pull_request_id":"pr-1","old_reviewer_id":%q}`, reviewer), http.StatusOK)
+	if got := firstReview(); got != want {
+		t.Errorf("after reassignment p50 = %v, want %v", got, want)
+	}
+}
+
+func TestAuditEndpoint(t *testing.T) {
+	handler, _ := newTestAPI(t)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/team/add", teamBody("backend", []string{"u1"}), http.StatusCreated)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/team/add", teamBody("frontend", []string{"u2"}), http.StatusCreated)
+
+	runAPICases(t, handler, []apiCase{
+		{name: "list", method: http.MethodGet, path: "/api/v1/audit/list?entity_type=team", status: http.StatusOK,
+			check: expectLen([]string{"events"}, 2)},
+		{name: "first page", method: http.MethodGet, path: "/api/v1/audit/list?entity_type=team&limit=1", status: http.StatusOK,
+			check: expectField([]string{"events", "0", "entity_id"}, nil)},
+		{name: "invalid limit", method: http.MethodGet, path: "/api/v1/audit/list?limit=0",
+			status: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "invalid cursor", method: http.MethodGet, path: "/api/v1/audit/list?cursor=not-a-cursor!",
+			status: http.StatusBadRequest, code: "BAD_REQUEST"},
+	})
+
+	// Walk the pages, newest event first
+	page := mustRequest(t, handler, http.MethodGet, "/api/v1/audit/list?entity_type=team&limit=1", "", http.StatusOK)
+	events := page["events"].([]interface{})
+	if got := events[0].(map[string]interface{})["entity_id"]; got != "frontend" {
+		t.Errorf("first event entity_id = %v, want frontend", got)
+	}
+	cursor, _ := page["next_cursor"].(string)
+	page = mustRequest(t, handler, http.MethodGet, "/api/v1/audit/list?entity_type=team&limit=1&cursor="+cursor, "", http.StatusOK)
+	events = page["events"].([]interface{})
+	if got := events[0].(map[string]interface{})["entity_id"]; got != "backend" {
+		t.Errorf("second event entity_id = %v, want backend", got)
+	}
+}
+
+func TestTokenEndpoints(t *testing.T) {
+	handler, _ := newTestAPI(t)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/team/add", teamBody("backend", []string{"u1"}), http.StatusCreated)
+	userToken := createUserToken(t, handler, "u1-token", "u1")
+
+	runAPICases(t, handler, []apiCase{
+		{name: "create admin token", method: http.MethodPost, path: "/api/v1/auth/tokens/create", body: `{"name":"ci","role":"admin"}`,
+			status: http.StatusCreated, check: expectField([]string{"role"}, "admin")},
+		{name: "create existing name", method: http.MethodPost, path: "/api/v1/auth/tokens/create", body: `{"name":"ci","role":"admin"}`,
+			status: http.StatusConflict, code: "TOKEN_EXISTS"},
+		{name: "create for unknown user", method: http.MethodPost, path: "/api/v1/auth/tokens/create", body: `{"name":"x","role":"user","user_id":"u9"}`,
+			status: http.StatusNotFound, code: "NOT_FOUND"},
+		{name: "create user token without user", method: http.MethodPost, path: "/api/v1/auth/tokens/create", body: `{"name":"x","role":"user"}`,
+			status: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "create with unknown role", method: http.MethodPost, path: "/api/v1/auth/tokens/create", body: `{"name":"x","role":"root"}`,
+			status: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "revoke", method: http.MethodPost, path: "/api/v1/auth/tokens/revoke", body: `{"name":"u1-token"}`,
+			status: http.StatusOK, check: expectField([]string{"token", "name"}, "u1-token")},
+		{name: "revoke again", method: http.MethodPost, path: "/api/v1/auth/tokens/revoke", body: `{"name":"u1-token"}`,
+			status: http.StatusNotFound, code: "NOT_FOUND"},
+		{name: "revoked token is rejected", method: http.MethodGet, path: "/api/v1/team/get?team_name=backend", token: userToken,
+			status: http.StatusUnauthorized, code: "UNAUTHORIZED"},
+		{name: "revoke is audited", method: http.MethodGet, path: "/api/v1/audit/list?action=token.revoke", status: http.StatusOK,
+			check: func(t *testing.T, body map[string]interface{}) {
+				events, _ := body["events"].([]interface{})
+				if len(events) != 1 {
+					t.Fatalf("events = %v, want one token.revoke event", body["events"])
+				}
+				event, _ := events[0].(map[string]interface{})
+				expectField([]string{"actor"}, bootstrapTokenName)(t, event)
+				expectField([]string{"entity_id"}, "u1-token")(t, event)
+			}},
+	})
+}
+
+func TestWebhookSubscriptionEndpoints(t *testing.T) {
+	handler, store := newTestAPI(t)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/team/add", teamBody("backend", []string{"u1", "u2"}), http.StatusCreated)
+
+	runAPICases(t, handler, []apiCase{
+		{name: "create", method: http.MethodPost, path: "/api/v1/webhooks/subscriptions/create",
+			body:   `{"url":"https://example.com/hook","event_types":["pr.created"],"secret":"s3cret"}`,
+			status: http.StatusCreated, check: expectField([]string{"subscription", "id"}, 1)},
+		{name: "create with invalid url", method: http.MethodPost, path: "/api/v1/webhooks/subscriptions/create",
+			body: `{"url":"ftp://example.com"}`, status: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "create with unknown event type", method: http.MethodPost, path: "/api/v1/webhooks/subscriptions/create",
+			body: `{"url":"https://example.com/hook","event_types":["pr.deleted"]}`, status: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "list", method: http.MethodGet, path: "/api/v1/webhooks/subscriptions/list",
+			status: http.StatusOK, check: expectLen([]string{"subscriptions"}, 1)},
+		{name: "get", method: http.MethodGet, path: "/api/v1/webhooks/subscriptions/get?id=1",
+			status: http.StatusOK, check: expectField([]string{"subscription", "url"}, "https://example.com/hook")},
+		{name: "get unknown", method: http.MethodGet, path: "/api/v1/webhooks/subscriptions/get?id=42",
+			status: http.StatusNotFound, code: "NOT_FOUND"},
+		{name: "get with invalid id", method: http.MethodGet, path: "/api/v1/webhooks/subscriptions/get?id=abc",
+			status: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "update", method: http.MethodPost, path: "/api/v1/webhooks/subscriptions/update",
+			body:   `{"id":1,"event_types":["pr.created","pr.merged"]}`,
+			status: http.StatusOK, check: expectLen([]string{"subscription", "event_types"}, 2)},
+		{name: "update unknown", method: http.MethodPost, path: "/api/v1/webhooks/subscriptions/update",
+			body: `{"id":42,"is_active":false}`, status: http.StatusNotFound, code: "NOT_FOUND"},
+	})
+
+	// Changes enqueue deliveries for matching subscriptions
+	mustRequest(t, handler, http.MethodPost, "/api/v1/pullRequest/create",
+		`{"pull_request_id":"pr-1","pull_request_name":"x","author_id":"u1"}`, http.StatusCreated)
+
+	runAPICases(t, handler, []apiCase{
+		{name: "list deliveries", method: http.MethodGet, path: "/api/v1/webhooks/deliveries/list?subscription_id=1",
+			status: http.StatusOK, check: expectField([]string{"deliveries", "0"}, nil)},
+		{name: "list deliveries with unknown status", method: http.MethodGet, path: "/api/v1/webhooks/deliveries/list?status=LOST",
+			status: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "retry pending delivery", method: http.MethodPost, path: "/api/v1/webhooks/deliveries/retry", body: `{"id":1}`,
+			status: http.StatusNotFound, code: "NOT_FOUND"},
+	})
+
+	deliveries := mustRequest(t, handler, http.MethodGet, "/api/v1/webhooks/deliveries/list?subscription_id=1", "", http.StatusOK)
+	items, _ := deliveries["deliveries"].([]interface{})
+	if len(items) != 1 || field(items[0].(map[string]interface{}), "event_type") != service.EventPRCreated {
+		t.Fatalf("deliveries = %v, want one %s delivery", items, service.EventPRCreated)
+	}
+
+	// Give up on the delivery as the dispatcher would after the last attempt
+	if err := store.MarkOutboxFailed(context.Background(), database.MarkOutboxFailedParams{
+		ID:            1,
+		Status:        database.OutboxStatusDEAD,
+		NextAttemptAt: time.Now(),
+		LastError:     sql.NullString{String: "connection refused", Valid: true},
+	}); err != nil {
+		t.Fatalf("mark delivery dead: %v", err)
+	}
+
+	runAPICases(t, handler, []apiCase{
+		{name: "retry dead delivery", method: http.MethodPost, path: "/api/v1/webhooks/deliveries/retry", body: `{"id":1}`,
+			status: http.StatusOK, check: expectField([]string{"delivery", "status"}, "PENDING")},
+		{name: "delete", method: http.MethodPost, path: "/api/v1/webhooks/subscriptions/delete", body: `{"id":1}`,
+			status: http.StatusOK},
+		{name: "delete again", method: http.MethodPost, path: "/api/v1/webhooks/subscriptions/delete", body: `{"id":1}`,
+			status: http.StatusNotFound, code: "NOT_FOUND"},
+		{name: "deliveries are deleted with the subscription", method: http.MethodGet, path: "/api/v1/webhooks/deliveries/list",
+			status: http.StatusOK, check: expectLen([]string{"deliveries"}, 0)},
+	})
+}
+
+func TestExternalAccountEndpoints(t *testing.T) {
+	handler, _ := newTestAPI(t)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/team/add", teamBody("backend", []string{"u1"}), http.StatusCreated)
+
+	runAPICases(t, handler, []apiCase{
+		{name: "set", method: http.MethodPost, path: "/api/v1/externalAccounts/set", body: `{"provider":"github","login":"Octo-Dev","user_id":"u1"}`,
+			status: http.StatusOK, check: expectField([]string{"account", "login"}, "octo-dev")},
+		{name: "set for unknown user", method: http.MethodPost, path: "/api/v1/externalAccounts/set", body: `{"provider":"github","login":"x","user_id":"u9"}`,
+			status: http.StatusNotFound, code: "NOT_FOUND"},
+		{name: "set for unknown provider", method: http.MethodPost, path: "/api/v1/externalAccounts/set", body: `{"provider":"svn","login":"x","user_id":"u1"}`,
+			status: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "list", method: http.MethodGet, path: "/api/v1/externalAccounts/list?provider=github",
+			status: http.StatusOK, check: expectLen([]string{"accounts"}, 1)},
+		{name: "list other provider", method: http.MethodGet, path: "/api/v1/externalAccounts/list?provider=gitlab",
+			status: http.StatusOK, check: expectLen([]string{"accounts"}, 0)},
+		{name: "delete", method: http.MethodPost, path: "/api/v1/externalAccounts/delete", body: `{"provider":"github","login":"octo-dev"}`,
+			status: http.StatusOK},
+		{name: "delete again", method: http.MethodPost, path: "/api/v1/externalAccounts/delete", body: `{"provider":"github","login":"octo-dev"}`,
+			status: http.StatusNotFound, code: "NOT_FOUND"},
+	})
+}
+
+func TestCodeHostWebhooks(t *testing.T) {
+	handler, _ := newTestAPI(t)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/team/add", teamBody("backend", []string{"u1", "u2"}), http.StatusCreated)
+
+	github := func(event, fixture string) *httptest.ResponseRecorder {
+		body := readGitHubFixture(t, fixture)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/github", bytes.NewReader(body))
+		req.Header.Set(githubEventHeader, event)
+		req.Header.Set(githubSignatureHeader, signWebhookPayload(testGitHubSecret, body))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	gitlab := func(fixture string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/gitlab", bytes.NewReader(readGitLabFixture(t, fixture)))
+		req.Header.Set(gitlabEventHeader, gitlabMergeRequestHook)
+		req.Header.Set(gitlabTokenHeader, testGitLabToken)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// The author's login is not mapped yet
+	if rec := github("pull_request", "pull_request_opened.json"); rec.Code != http.StatusUnprocessableEntity || responseErrorCode(decodeBody(t, rec)) != "UNKNOWN_ACCOUNT" {
+		t.Fatalf("unmapped author: status = %d, body: %s", rec.Code, rec.Body)
+	}
+
+	mustRequest(t, handler, http.MethodPost, "/api/v1/externalAccounts/set", `{"provider":"github","login":"octo-dev","user_id":"u1"}`, http.StatusOK)
+	mustRequest(t, handler, http.MethodPost, "/api/v1/externalAccounts/set", `{"provider":"gitlab","login":"octo-dev","user_id":"u1"}`, http.StatusOK)
+
+	tests := []struct {
+		name   string
+		send   func() *httptest.ResponseRecorder
+		status string
+	}{
+		{"github opened", func() *httptest.ResponseRecorder { return github("pull_request", "pull_request_opened.json") }, "OPEN"},
+		{"github redelivery", func() *httptest.ResponseRecorder { return github("pull_request", "pull_request_opened.json") }, "OPEN"},
+		{"github merged", func() *httptest.ResponseRecorder { return github("pull_request", "pull_request_closed_merged.json") }, "MERGED"},
+		{"gitlab opened", func() *httptest.ResponseRecorder { return gitlab("merge_request_open.json") }, "OPEN"},
+		{"gitlab closed", func() *httptest.ResponseRecorder { return gitlab("merge_request_close.json") }, "CLOSED"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := tt.send()
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200, body: %s", rec.Code, rec.Body)
+			}
+			if got := field(decodeBody(t, rec), "pr", "status"); got != tt.status {
+				t.Errorf("pr.status = %v, want %s", got, tt.status)
+			}
+		})
+	}
+}
@@ -1,11 +1,163 @@
 package main
 
 import (
+	"GODanilich/avito_backend/internal/storage"
+	"context"
+	"fmt"
+	"log"
 	"net/http"
+	"sync"
+	"time"
 )
 
-func (apiCFG *apiConfig) handlerHealth(w http.ResponseWriter, r *http.Request) {
+// readinessTimeout bounds all readiness checks together, probes usually time out after a few seconds
+const readinessTimeout = 2 * time.Second
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(200)
+// Values of the status fields in health responses
+const (
+	healthOK       = "ok"
+	healthDegraded = "degraded" // Only non-critical checks failed, the instance still serves requests
+	healthFail     = "fail"
+)
+
+// healthCheck is the result of one readiness check
+type healthCheck struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+	critical  bool    // A failure makes the instance not ready
+}
+
+type healthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]healthCheck `json:"checks,omitempty"`
+}
+
+// workerStatus tracks a background worker for the readiness probe
+type workerStatus struct {
+	staleAfter time.Duration // A worker that hasn't finished a run for this long is stuck
+
+	mu       sync.Mutex
+	running  bool
+	lastRun  time.Time
+	lastErr  error
+	finished bool
+}
+
+func newWorkerStatus(staleAfter time.Duration) *workerStatus {
+	return &workerStatus{staleAfter: staleAfter}
+}
+
+// start marks the worker as running, a fresh worker counts as having just run
+func (s *workerStatus) start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = true
+	s.lastRun = time.Now()
+}
+
+// record stores the result of a run
+func (s *workerStatus) record(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastRun = time.Now()
+	s.lastErr = err
+}
+
+// stop marks the worker as stopped, e.g. on shutdown
+func (s *workerStatus) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = false
+	s.finished = true
+}
+
+// check returns nil when the worker is running and its last run succeeded recently
+func (s *workerStatus) check() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case s.finished:
+		return fmt.Errorf("worker stopped")
+	case !s.running:
+		return fmt.Errorf("worker not started")
+	case time.Since(s.lastRun) > s.staleAfter:
+		return fmt.Errorf("no run finished for %v", time.Since(s.lastRun).Round(time.Second))
+	case s.lastErr != nil:
+		return fmt.Errorf("last run failed: %v", s.lastErr)
+	}
+	return nil
+}
+
+// handlerLiveness reports that the process is up and serving HTTP
+// It checks no dependencies, a database outage must not get the pod restarted
+func (apiCFG *apiConfig) handlerLiveness(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, healthResponse{Status: healthOK})
+}
+
+// handlerReadiness reports whether the instance can serve API requests
+// Responds with 503 when the database is unreachable or its schema is older than the binary needs
+func (apiCFG *apiConfig) handlerReadiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	// running the checks, each with its own latency
+	checks := map[string]healthCheck{
+		"database": runHealthCheck("database", true, func() error { return apiCFG.DB.Ping(ctx) }),
+		"schema":   runHealthCheck("schema", true, func() error { return checkSchemaVersion(ctx, apiCFG.DB) }),
+	}
+	for name, worker := range apiCFG.workers {
+		checks[name] = runHealthCheck(name, false, worker.check)
+	}
+
+	// failed critical checks make the instance unready, other failures only degrade it
+	response := healthResponse{Status: healthOK, Checks: checks}
+	for _, check := range checks {
+		if check.Status == healthOK {
+			continue
+		}
+		if check.critical {
+			response.Status = healthFail
+			break
+		}
+		response.Status = healthDegraded
+	}
+
+	status := http.StatusOK
+	if response.Status == healthFail {
+		status = http.StatusServiceUnavailable
+	}
+	respondWithJSON(w, status, response)
+}
+
+// runHealthCheck runs check and measures how long it took
+// The probe is unauthenticated, so failures are logged and reported without details
+func runHealthCheck(name string, critical bool, check func() error) healthCheck {
+	start := time.Now()
+	err := check()
+	result := healthCheck{
+		Status:    healthOK,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+		critical:  critical,
+	}
+	if err != nil {
+		log.Printf("Readiness check %s failed: %v", name, err)
+		result.Status = healthFail
+		result.Error = name + " check failed"
+	}
+	return result
+}
+
+// checkSchemaVersion fails when migrations the queries need aren't applied
+// A newer schema passes like in requireSchema, so old pods stay ready while a
+// rolling deploy migrates the database ahead of them
+func checkSchemaVersion(ctx context.Context, db storage.Store) error {
+	version, err := db.AppliedSchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if version < storage.SchemaVersion {
+		return fmt.Errorf("schema version is %d, expected %d", version, storage.SchemaVersion)
+	}
+	return nil
 }
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"GODanilich/avito_backend/internal/storage"
+	"GODanilich/avito_backend/internal/storage/memory"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// unhealthyStore is a memory store with a broken connection or an outdated schema
+type unhealthyStore struct {
+	*memory.Store
+	pingErr error
+	version int64
+}
+
+func (s *unhealthyStore) Ping(ctx context.Context) error {
+	return s.pingErr
+}
+
+func (s *unhealthyStore) AppliedSchemaVersion(ctx context.Context) (int64, error) {
+	return s.version, nil
+}
+
+func TestReadiness(t *testing.T) {
+	tests := []struct {
+		name   string
+		store  storage.Store
+		status int
+		failed string // Check expected to fail, empty if all pass
+	}{
+		{"healthy", memory.New(), http.StatusOK, ""},
+		{"database down", &unhealthyStore{Store: memory.New(), pingErr: errors.New("connection refused"), version: storage.SchemaVersion}, http.StatusServiceUnavailable, "database"},
+		{"schema behind", &unhealthyStore{Store: memory.New(), version: storage.SchemaVersion - 1}, http.StatusServiceUnavailable, "schema"},
+		{"schema ahead during a rolling deploy", &unhealthyStore{Store: memory.New(), version: storage.SchemaVersion + 1}, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newTestAPIWithStore(t, tt.store)
+			rec := doRequest(handler, http.MethodGet, "/api/v1/health/ready", "", "")
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d, body: %s", rec.Code, tt.status, rec.Body)
+			}
+
+			body := decodeBody(t, rec)
+			for _, name := range []string{"database", "schema"} {
+				want := healthOK
+				if name == tt.failed {
+					want = healthFail
+				}
+				if got := field(body, "checks", name, "status"); got != want {
+					t.Errorf("%s check status = %v, want %s", name, got, want)
+				}
+				if _, ok := field(body, "checks", name, "latency_ms").(float64); !ok {
+					t.Errorf("%s check has no latency: %s", name, rec.Body)
+				}
+			}
+
+			// The cause is only logged, the probe is unauthenticated
+			if strings.Contains(rec.Body.String(), "connection refused") {
+				t.Errorf("response leaks the error: %s", rec.Body)
+			}
+		})
+	}
+}
+
+// A stuck worker degrades the instance but keeps it in the load balancer
+func TestReadinessWorkers(t *testing.T) {
+	running := newWorkerStatus(time.Minute)
+	running.start()
+	stuck := newWorkerStatus(time.Minute)
+	stuck.start()
+	stuck.lastRun = time.Now().Add(-2 * time.Minute)
+
+	tests := []struct {
+		name   string
+		worker *workerStatus
+		status string
+	}{
+		{"running", running, healthOK},
+		{"stuck", stuck, healthDegraded},
+		{"not started", newWorkerStatus(time.Minute), healthDegraded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &apiConfig{DB: memory.New(), workers: map[string]*workerStatus{"dispatcher": tt.worker}}
+			rec := httptest.NewRecorder()
+			api.handlerReadiness(rec, httptest.NewRequest(http.MethodGet, "/api/v1/health/ready", nil))
+
+			if rec.Code != http.StatusOK {
+				t.Errorf("status = %d, want 200", rec.Code)
+			}
+			if got := field(decodeBody(t, rec), "status"); got != tt.status {
+				t.Errorf("status = %v, want %s, body: %s", got, tt.status, rec.Body)
+			}
+		})
+	}
+}
+
+func TestWorkerStatus(t *testing.T) {
+	status := newWorkerStatus(time.Minute)
+	status.start()
+	if err := status.check(); err != nil {
+		t.Errorf("started worker: %v", err)
+	}
+
+	status.record(errors.New("connection refused"))
+	if err := status.check(); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("failed run: check = %v", err)
+	}
+
+	status.record(nil)
+	status.stop()
+	if err := status.check(); err == nil {
+		t.Errorf("stopped worker passes the check")
+	}
+}
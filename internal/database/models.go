@@ -7,14 +7,60 @@ package database
 import (
 	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
+	"time"
 )
 
+type OutboxStatus string
+
+const (
+	OutboxStatusPENDING   OutboxStatus = "PENDING"
+	OutboxStatusDELIVERED OutboxStatus = "DELIVERED"
+	OutboxStatusDEAD      OutboxStatus = "DEAD"
+)
+
+func (e *OutboxStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = OutboxStatus(s)
+	case string:
+		*e = OutboxStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for OutboxStatus: %T", src)
+	}
+	return nil
+}
+
+type NullOutboxStatus struct {
+	OutboxStatus OutboxStatus
+	Valid        bool // Valid is true if OutboxStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullOutboxStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.OutboxStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.OutboxStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullOutboxStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.OutboxStatus), nil
+}
+
 type PrStatus string
 
 const (
 	PrStatusOPEN   PrStatus = "OPEN"
 	PrStatusMERGED PrStatus = "MERGED"
+	PrStatusCLOSED PrStatus = "CLOSED"
 )
 
 func (e *PrStatus) Scan(src interface{}) error {
@@ -52,6 +98,176 @@ func (ns NullPrStatus) Value() (driver.Value, error) {
 	return string(ns.PrStatus), nil
 }
 
+type ReviewState string
+
+const (
+	ReviewStatePENDING          ReviewState = "PENDING"
+	ReviewStateAPPROVED         ReviewState = "APPROVED"
+	ReviewStateCHANGESREQUESTED ReviewState = "CHANGES_REQUESTED"
+)
+
+func (e *ReviewState) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ReviewState(s)
+	case string:
+		*e = ReviewState(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ReviewState: %T", src)
+	}
+	return nil
+}
+
+type NullReviewState struct {
+	ReviewState ReviewState
+	Valid       bool // Valid is true if ReviewState is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullReviewState) Scan(value interface{}) error {
+	if value == nil {
+		ns.ReviewState, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ReviewState.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullReviewState) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ReviewState), nil
+}
+
+type ReviewerStrategy string
+
+const (
+	ReviewerStrategyRandom      ReviewerStrategy = "random"
+	ReviewerStrategyLeastLoaded ReviewerStrategy = "least_loaded"
+	ReviewerStrategyRoundRobin  ReviewerStrategy = "round_robin"
+)
+
+func (e *ReviewerStrategy) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ReviewerStrategy(s)
+	case string:
+		*e = ReviewerStrategy(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ReviewerStrategy: %T", src)
+	}
+	return nil
+}
+
+type NullReviewerStrategy struct {
+	ReviewerStrategy ReviewerStrategy
+	Valid            bool // Valid is true if ReviewerStrategy is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullReviewerStrategy) Scan(value interface{}) error {
+	if value == nil {
+		ns.ReviewerStrategy, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ReviewerStrategy.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullReviewerStrategy) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ReviewerStrategy), nil
+}
+
+type TokenRole string
+
+const (
+	TokenRoleAdmin TokenRole = "admin"
+	TokenRoleUser  TokenRole = "user"
+)
+
+func (e *TokenRole) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = TokenRole(s)
+	case string:
+		*e = TokenRole(s)
+	default:
+		return fmt.Errorf("unsupported scan type for TokenRole: %T", src)
+	}
+	return nil
+}
+
+type NullTokenRole struct {
+	TokenRole TokenRole
+	Valid     bool // Valid is true if TokenRole is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullTokenRole) Scan(value interface{}) error {
+	if value == nil {
+		ns.TokenRole, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.TokenRole.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullTokenRole) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.TokenRole), nil
+}
+
+type ApiToken struct {
+	Name      string
+	TokenHash string
+	Role      TokenRole
+	UserID    sql.NullString
+	CreatedAt time.Time
+	RevokedAt sql.NullTime
+}
+
+type AuditEvent struct {
+	ID          int64
+	OccurredAt  time.Time
+	Actor       string
+	ActorUserID sql.NullString
+	Action      string
+	EntityType  string
+	EntityID    string
+	Before      json.RawMessage
+	After       json.RawMessage
+}
+
+type ExternalAccount struct {
+	Provider  string
+	Login     string
+	UserID    string
+	CreatedAt time.Time
+}
+
+type Outbox struct {
+	ID             int64
+	SubscriptionID int64
+	EventID        string
+	EventType      string
+	Payload        json.RawMessage
+	Status         OutboxStatus
+	Attempts       int32
+	NextAttemptAt  time.Time
+	LastError      sql.NullString
+	CreatedAt      time.Time
+	DeliveredAt    sql.NullTime
+}
+
 type PullRequest struct {
 	PullRequestID   string
 	PullRequestName string
@@ -59,20 +275,43 @@ type PullRequest struct {
 	Status          PrStatus
 	CreatedAt       sql.NullTime
 	MergedAt        sql.NullTime
+	ClosedAt        sql.NullTime
+	ProjectPath     sql.NullString
+	FirstReviewedAt sql.NullTime
 }
 
 type PullRequestReviewer struct {
 	PullRequestID string
 	UserID        string
+	State         ReviewState
+	ReviewedAt    sql.NullTime
 }
 
 type Team struct {
 	TeamName string
 }
 
+type TeamSetting struct {
+	TeamName          string
+	MinReviewers      int32
+	MaxReviewers      int32
+	Strategy          ReviewerStrategy
+	RoundRobinCursor  sql.NullString
+	RequiredApprovals int32
+}
+
 type User struct {
 	UserID   string
 	Username string
 	TeamName sql.NullString
 	IsActive bool
 }
+
+type WebhookSubscription struct {
+	ID         int64
+	Url        string
+	Secret     string
+	EventTypes []string
+	IsActive   bool
+	CreatedAt  time.Time
+}
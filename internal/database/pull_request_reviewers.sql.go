@@ -7,6 +7,9 @@ package database
 
 import (
 	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
 )
 
 const addReviewer = `-- name: AddReviewer :exec
@@ -24,6 +27,19 @@ func (q *Queries) AddReviewer(ctx context.Context, arg AddReviewerParams) error
 	return err
 }
 
+const countApprovals = `-- name: CountApprovals :one
+SELECT COUNT(*)
+FROM pull_request_reviewers
+WHERE pull_request_id = $1 AND state = 'APPROVED'
+`
+
+func (q *Queries) CountApprovals(ctx context.Context, pullRequestID string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countApprovals, pullRequestID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const deleteReviewer = `-- name: DeleteReviewer :exec
 DELETE FROM pull_request_reviewers
 WHERE pull_request_id = $1 AND user_id = $2
@@ -39,6 +55,98 @@ func (q *Queries) DeleteReviewer(ctx context.Context, arg DeleteReviewerParams)
 	return err
 }
 
+const getOpenAssignmentsForReviewers = `-- name: GetOpenAssignmentsForReviewers :many
+SELECT r.pull_request_id, r.user_id, p.author_id, a.team_name AS author_team_name
+FROM pull_request_reviewers r
+JOIN pull_requests p ON p.pull_request_id = r.pull_request_id
+JOIN users a ON a.user_id = p.author_id
+WHERE r.user_id = ANY($1::text[])
+  AND p.status = 'OPEN'
+ORDER BY p.created_at, r.pull_request_id, r.user_id
+`
+
+type GetOpenAssignmentsForReviewersRow struct {
+	PullRequestID  string
+	UserID         string
+	AuthorID       string
+	AuthorTeamName sql.NullString
+}
+
+func (q *Queries) GetOpenAssignmentsForReviewers(ctx context.Context, userIds []string) ([]GetOpenAssignmentsForReviewersRow, error) {
+	rows, err := q.db.QueryContext(ctx, getOpenAssignmentsForReviewers, pq.Array(userIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetOpenAssignmentsForReviewersRow
+	for rows.Next() {
+		var i GetOpenAssignmentsForReviewersRow
+		if err := rows.Scan(
+			&i.PullRequestID,
+			&i.UserID,
+			&i.AuthorID,
+			&i.AuthorTeamName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPRReviewerDetails = `-- name: GetPRReviewerDetails :many
+SELECT u.user_id, u.username, u.team_name, u.is_active, r.state, r.reviewed_at
+FROM users u
+JOIN pull_request_reviewers r ON u.user_id = r.user_id
+WHERE r.pull_request_id = $1
+ORDER BY u.user_id
+`
+
+type GetPRReviewerDetailsRow struct {
+	UserID     string
+	Username   string
+	TeamName   sql.NullString
+	IsActive   bool
+	State      ReviewState
+	ReviewedAt sql.NullTime
+}
+
+func (q *Queries) GetPRReviewerDetails(ctx context.Context, pullRequestID string) ([]GetPRReviewerDetailsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPRReviewerDetails, pullRequestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPRReviewerDetailsRow
+	for rows.Next() {
+		var i GetPRReviewerDetailsRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Username,
+			&i.TeamName,
+			&i.IsActive,
+			&i.State,
+			&i.ReviewedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getPRReviewers = `-- name: GetPRReviewers :many
 SELECT u.user_id FROM users u
 JOIN pull_request_reviewers r ON u.user_id = r.user_id
@@ -70,22 +178,29 @@ func (q *Queries) GetPRReviewers(ctx context.Context, pullRequestID string) ([]s
 }
 
 const getPRsForReviewer = `-- name: GetPRsForReviewer :many
-SELECT p.pull_request_id, p.pull_request_name, p.author_id, p.status
+SELECT p.pull_request_id, p.pull_request_name, p.author_id, p.status, r.state
 FROM pull_requests p
 JOIN pull_request_reviewers r ON p.pull_request_id = r.pull_request_id
 WHERE r.user_id = $1
+  AND ($2::review_state IS NULL OR r.state = $2)
 ORDER BY p.created_at DESC
 `
 
+type GetPRsForReviewerParams struct {
+	UserID string
+	State  NullReviewState
+}
+
 type GetPRsForReviewerRow struct {
 	PullRequestID   string
 	PullRequestName string
 	AuthorID        string
 	Status          PrStatus
+	State           ReviewState
 }
 
-func (q *Queries) GetPRsForReviewer(ctx context.Context, userID string) ([]GetPRsForReviewerRow, error) {
-	rows, err := q.db.QueryContext(ctx, getPRsForReviewer, userID)
+func (q *Queries) GetPRsForReviewer(ctx context.Context, arg GetPRsForReviewerParams) ([]GetPRsForReviewerRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPRsForReviewer, arg.UserID, arg.State)
 	if err != nil {
 		return nil, err
 	}
@@ -98,6 +213,7 @@ func (q *Queries) GetPRsForReviewer(ctx context.Context, userID string) ([]GetPR
 			&i.PullRequestName,
 			&i.AuthorID,
 			&i.Status,
+			&i.State,
 		); err != nil {
 			return nil, err
 		}
@@ -112,6 +228,41 @@ func (q *Queries) GetPRsForReviewer(ctx context.Context, userID string) ([]GetPR
 	return items, nil
 }
 
+const getReviewersForPRs = `-- name: GetReviewersForPRs :many
+SELECT pull_request_id, user_id
+FROM pull_request_reviewers
+WHERE pull_request_id = ANY($1::text[])
+ORDER BY pull_request_id, user_id
+`
+
+type GetReviewersForPRsRow struct {
+	PullRequestID string
+	UserID        string
+}
+
+func (q *Queries) GetReviewersForPRs(ctx context.Context, pullRequestIds []string) ([]GetReviewersForPRsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getReviewersForPRs, pq.Array(pullRequestIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetReviewersForPRsRow
+	for rows.Next() {
+		var i GetReviewersForPRsRow
+		if err := rows.Scan(&i.PullRequestID, &i.UserID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const isReviewerAssigned = `-- name: IsReviewerAssigned :one
 SELECT COUNT(*) > 0
 FROM pull_request_reviewers
@@ -129,3 +280,28 @@ func (q *Queries) IsReviewerAssigned(ctx context.Context, arg IsReviewerAssigned
 	err := row.Scan(&column_1)
 	return column_1, err
 }
+
+const setReviewState = `-- name: SetReviewState :one
+UPDATE pull_request_reviewers
+SET state = $3, reviewed_at = now()
+WHERE pull_request_id = $1 AND user_id = $2
+RETURNING pull_request_id, user_id, state, reviewed_at
+`
+
+type SetReviewStateParams struct {
+	PullRequestID string
+	UserID        string
+	State         ReviewState
+}
+
+func (q *Queries) SetReviewState(ctx context.Context, arg SetReviewStateParams) (PullRequestReviewer, error) {
+	row := q.db.QueryRowContext(ctx, setReviewState, arg.PullRequestID, arg.UserID, arg.State)
+	var i PullRequestReviewer
+	err := row.Scan(
+		&i.PullRequestID,
+		&i.UserID,
+		&i.State,
+		&i.ReviewedAt,
+	)
+	return i, err
+}
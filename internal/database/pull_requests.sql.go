@@ -11,27 +11,37 @@ import (
 )
 
 const createPR = `-- name: CreatePR :exec
-INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, status, created_at)
-VALUES ($1,$2,$3,'OPEN', NOW())
+INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, status, created_at, project_path)
+VALUES ($1,$2,$3,'OPEN', NOW(), $4)
 `
 
 type CreatePRParams struct {
 	PullRequestID   string
 	PullRequestName string
 	AuthorID        string
+	ProjectPath     sql.NullString
 }
 
 func (q *Queries) CreatePR(ctx context.Context, arg CreatePRParams) error {
-	_, err := q.db.ExecContext(ctx, createPR, arg.PullRequestID, arg.PullRequestName, arg.AuthorID)
+	_, err := q.db.ExecContext(ctx, createPR,
+		arg.PullRequestID,
+		arg.PullRequestName,
+		arg.AuthorID,
+		arg.ProjectPath,
+	)
 	return err
 }
 
 const getActiveReviewersForTeam = `-- name: GetActiveReviewersForTeam :many
-SELECT user_id
-FROM users
-WHERE team_name = $1
-AND is_active = TRUE
-AND user_id <> $2
+SELECT u.user_id, COUNT(p.pull_request_id) AS open_reviews
+FROM users u
+LEFT JOIN pull_request_reviewers r ON r.user_id = u.user_id
+LEFT JOIN pull_requests p ON p.pull_request_id = r.pull_request_id AND p.status = 'OPEN'
+WHERE u.team_name = $1
+  AND u.is_active = TRUE
+  AND u.user_id <> $2
+GROUP BY u.user_id
+ORDER BY u.user_id
 `
 
 type GetActiveReviewersForTeamParams struct {
@@ -39,19 +49,24 @@ type GetActiveReviewersForTeamParams struct {
 	UserID   string
 }
 
-func (q *Queries) GetActiveReviewersForTeam(ctx context.Context, arg GetActiveReviewersForTeamParams) ([]string, error) {
+type GetActiveReviewersForTeamRow struct {
+	UserID      string
+	OpenReviews int64
+}
+
+func (q *Queries) GetActiveReviewersForTeam(ctx context.Context, arg GetActiveReviewersForTeamParams) ([]GetActiveReviewersForTeamRow, error) {
 	rows, err := q.db.QueryContext(ctx, getActiveReviewersForTeam, arg.TeamName, arg.UserID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []string
+	var items []GetActiveReviewersForTeamRow
 	for rows.Next() {
-		var user_id string
-		if err := rows.Scan(&user_id); err != nil {
+		var i GetActiveReviewersForTeamRow
+		if err := rows.Scan(&i.UserID, &i.OpenReviews); err != nil {
 			return nil, err
 		}
-		items = append(items, user_id)
+		items = append(items, i)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -63,8 +78,10 @@ func (q *Queries) GetActiveReviewersForTeam(ctx context.Context, arg GetActiveRe
 }
 
 const getEligibleReassignReviewers = `-- name: GetEligibleReassignReviewers :many
-SELECT u.user_id
+SELECT u.user_id, COUNT(p.pull_request_id) AS open_reviews
 FROM users u
+LEFT JOIN pull_request_reviewers r ON r.user_id = u.user_id
+LEFT JOIN pull_requests p ON p.pull_request_id = r.pull_request_id AND p.status = 'OPEN'
 WHERE u.team_name = $1
   AND u.is_active = TRUE
   AND u.user_id <> $2
@@ -73,6 +90,13 @@ WHERE u.team_name = $1
       FROM pull_request_reviewers prr
       WHERE prr.pull_request_id = $3
   )
+  AND u.user_id NOT IN (
+      SELECT pr.author_id
+      FROM pull_requests pr
+      WHERE pr.pull_request_id = $3
+  )
+GROUP BY u.user_id
+ORDER BY u.user_id
 `
 
 type GetEligibleReassignReviewersParams struct {
@@ -81,19 +105,24 @@ type GetEligibleReassignReviewersParams struct {
 	PullRequestID string
 }
 
-func (q *Queries) GetEligibleReassignReviewers(ctx context.Context, arg GetEligibleReassignReviewersParams) ([]string, error) {
+type GetEligibleReassignReviewersRow struct {
+	UserID      string
+	OpenReviews int64
+}
+
+func (q *Queries) GetEligibleReassignReviewers(ctx context.Context, arg GetEligibleReassignReviewersParams) ([]GetEligibleReassignReviewersRow, error) {
 	rows, err := q.db.QueryContext(ctx, getEligibleReassignReviewers, arg.TeamName, arg.UserID, arg.PullRequestID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []string
+	var items []GetEligibleReassignReviewersRow
 	for rows.Next() {
-		var user_id string
-		if err := rows.Scan(&user_id); err != nil {
+		var i GetEligibleReassignReviewersRow
+		if err := rows.Scan(&i.UserID, &i.OpenReviews); err != nil {
 			return nil, err
 		}
-		items = append(items, user_id)
+		items = append(items, i)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -105,7 +134,7 @@ func (q *Queries) GetEligibleReassignReviewers(ctx context.Context, arg GetEligi
 }
 
 const getPR = `-- name: GetPR :one
-SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at
+SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at, closed_at, project_path, first_reviewed_at
 FROM pull_requests
 WHERE pull_request_id = $1
 `
@@ -120,6 +149,35 @@ func (q *Queries) GetPR(ctx context.Context, pullRequestID string) (PullRequest,
 		&i.Status,
 		&i.CreatedAt,
 		&i.MergedAt,
+		&i.ClosedAt,
+		&i.ProjectPath,
+		&i.FirstReviewedAt,
+	)
+	return i, err
+}
+
+const getPRForUpdate = `-- name: GetPRForUpdate :one
+SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at, closed_at, project_path, first_reviewed_at
+FROM pull_requests
+WHERE pull_request_id = $1
+FOR UPDATE
+`
+
+// Locks the PR row until the end of the transaction, so concurrent changes to
+// the same PR and its reviewers run one after another
+func (q *Queries) GetPRForUpdate(ctx context.Context, pullRequestID string) (PullRequest, error) {
+	row := q.db.QueryRowContext(ctx, getPRForUpdate, pullRequestID)
+	var i PullRequest
+	err := row.Scan(
+		&i.PullRequestID,
+		&i.PullRequestName,
+		&i.AuthorID,
+		&i.Status,
+		&i.CreatedAt,
+		&i.MergedAt,
+		&i.ClosedAt,
+		&i.ProjectPath,
+		&i.FirstReviewedAt,
 	)
 	return i, err
 }
@@ -137,11 +195,153 @@ func (q *Queries) IsMerged(ctx context.Context, pullRequestID string) (bool, err
 	return column_1, err
 }
 
+const listPRs = `-- name: ListPRs :many
+SELECT p.pull_request_id, p.pull_request_name, p.author_id, p.status, p.created_at, p.merged_at, p.closed_at, p.project_path, p.first_reviewed_at
+FROM pull_requests p
+JOIN users a ON a.user_id = p.author_id
+WHERE ($1::pr_status IS NULL OR p.status = $1)
+  AND ($2::text IS NULL OR p.author_id = $2)
+  AND ($3::text IS NULL OR a.team_name = $3)
+  AND ($4::text IS NULL OR EXISTS (
+      SELECT 1 FROM pull_request_reviewers r
+      WHERE r.pull_request_id = p.pull_request_id AND r.user_id = $4
+  ))
+  AND ($5::timestamptz IS NULL OR p.created_at >= $5)
+  AND ($6::timestamptz IS NULL OR p.created_at < $6)
+  AND ($7::text IS NULL OR p.pull_request_name ILIKE '%' || $7 || '%')
+  AND ($8::timestamptz IS NULL
+       OR (p.created_at, p.pull_request_id) < ($8, $9::text))
+ORDER BY p.created_at DESC, p.pull_request_id DESC
+LIMIT $10
+`
+
+type ListPRsParams struct {
+	Status          NullPrStatus
+	AuthorID        sql.NullString
+	TeamName        sql.NullString
+	ReviewerID      sql.NullString
+	CreatedFrom     sql.NullTime
+	CreatedTo       sql.NullTime
+	NameQuery       sql.NullString
+	CursorCreatedAt sql.NullTime
+	CursorID        sql.NullString
+	PageLimit       int32
+}
+
+func (q *Queries) ListPRs(ctx context.Context, arg ListPRsParams) ([]PullRequest, error) {
+	rows, err := q.db.QueryContext(ctx, listPRs,
+		arg.Status,
+		arg.AuthorID,
+		arg.TeamName,
+		arg.ReviewerID,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.NameQuery,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PullRequest
+	for rows.Next() {
+		var i PullRequest
+		if err := rows.Scan(
+			&i.PullRequestID,
+			&i.PullRequestName,
+			&i.AuthorID,
+			&i.Status,
+			&i.CreatedAt,
+			&i.MergedAt,
+			&i.ClosedAt,
+			&i.ProjectPath,
+			&i.FirstReviewedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const renamePR = `-- name: RenamePR :one
+UPDATE pull_requests
+SET pull_request_name = $2
+WHERE pull_request_id = $1
+RETURNING pull_request_id, pull_request_name, author_id, status, created_at, merged_at, closed_at, project_path, first_reviewed_at
+`
+
+type RenamePRParams struct {
+	PullRequestID   string
+	PullRequestName string
+}
+
+func (q *Queries) RenamePR(ctx context.Context, arg RenamePRParams) (PullRequest, error) {
+	row := q.db.QueryRowContext(ctx, renamePR, arg.PullRequestID, arg.PullRequestName)
+	var i PullRequest
+	err := row.Scan(
+		&i.PullRequestID,
+		&i.PullRequestName,
+		&i.AuthorID,
+		&i.Status,
+		&i.CreatedAt,
+		&i.MergedAt,
+		&i.ClosedAt,
+		&i.ProjectPath,
+		&i.FirstReviewedAt,
+	)
+	return i, err
+}
+
+const setPRClosed = `-- name: SetPRClosed :one
+UPDATE pull_requests
+SET status='CLOSED', closed_at = now()
+WHERE pull_request_id = $1
+RETURNING pull_request_id, pull_request_name, author_id, status, created_at, merged_at, closed_at, project_path, first_reviewed_at
+`
+
+func (q *Queries) SetPRClosed(ctx context.Context, pullRequestID string) (PullRequest, error) {
+	row := q.db.QueryRowContext(ctx, setPRClosed, pullRequestID)
+	var i PullRequest
+	err := row.Scan(
+		&i.PullRequestID,
+		&i.PullRequestName,
+		&i.AuthorID,
+		&i.Status,
+		&i.CreatedAt,
+		&i.MergedAt,
+		&i.ClosedAt,
+		&i.ProjectPath,
+		&i.FirstReviewedAt,
+	)
+	return i, err
+}
+
+const setPRFirstReviewed = `-- name: SetPRFirstReviewed :exec
+UPDATE pull_requests
+SET first_reviewed_at = now()
+WHERE pull_request_id = $1 AND first_reviewed_at IS NULL
+`
+
+// Set once, later verdicts and reassignments don't move it
+func (q *Queries) SetPRFirstReviewed(ctx context.Context, pullRequestID string) error {
+	_, err := q.db.ExecContext(ctx, setPRFirstReviewed, pullRequestID)
+	return err
+}
+
 const setPRMerged = `-- name: SetPRMerged :one
 UPDATE pull_requests
 SET status='MERGED', merged_at = now()
 WHERE pull_request_id = $1
-RETURNING pull_request_id, pull_request_name, author_id, status, created_at, merged_at
+RETURNING pull_request_id, pull_request_name, author_id, status, created_at, merged_at, closed_at, project_path, first_reviewed_at
 `
 
 func (q *Queries) SetPRMerged(ctx context.Context, pullRequestID string) (PullRequest, error) {
@@ -154,6 +354,33 @@ func (q *Queries) SetPRMerged(ctx context.Context, pullRequestID string) (PullRe
 		&i.Status,
 		&i.CreatedAt,
 		&i.MergedAt,
+		&i.ClosedAt,
+		&i.ProjectPath,
+		&i.FirstReviewedAt,
+	)
+	return i, err
+}
+
+const setPRReopened = `-- name: SetPRReopened :one
+UPDATE pull_requests
+SET status='OPEN', closed_at = NULL
+WHERE pull_request_id = $1
+RETURNING pull_request_id, pull_request_name, author_id, status, created_at, merged_at, closed_at, project_path, first_reviewed_at
+`
+
+func (q *Queries) SetPRReopened(ctx context.Context, pullRequestID string) (PullRequest, error) {
+	row := q.db.QueryRowContext(ctx, setPRReopened, pullRequestID)
+	var i PullRequest
+	err := row.Scan(
+		&i.PullRequestID,
+		&i.PullRequestName,
+		&i.AuthorID,
+		&i.Status,
+		&i.CreatedAt,
+		&i.MergedAt,
+		&i.ClosedAt,
+		&i.ProjectPath,
+		&i.FirstReviewedAt,
 	)
 	return i, err
 }
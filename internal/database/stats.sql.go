@@ -7,8 +7,39 @@ package database
 
 import (
 	"context"
+	"database/sql"
 )
 
+const countOpenPRs = `-- name: CountOpenPRs :one
+SELECT COUNT(*)
+FROM pull_requests
+WHERE status = 'OPEN'
+`
+
+func (q *Queries) CountOpenPRs(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countOpenPRs)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countUnderstaffedOpenPRs = `-- name: CountUnderstaffedOpenPRs :one
+SELECT COUNT(*)
+FROM pull_requests p
+JOIN users a ON a.user_id = p.author_id
+LEFT JOIN team_settings ts ON ts.team_name = a.team_name
+WHERE p.status = 'OPEN'
+  AND (SELECT COUNT(*) FROM pull_request_reviewers r WHERE r.pull_request_id = p.pull_request_id)
+      < COALESCE(ts.max_reviewers, $1::int)
+`
+
+func (q *Queries) CountUnderstaffedOpenPRs(ctx context.Context, defaultMaxReviewers int32) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countUnderstaffedOpenPRs, defaultMaxReviewers)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const getAssignmentStats = `-- name: GetAssignmentStats :many
 SELECT user_id, COUNT(*) AS count
 FROM pull_request_reviewers
@@ -43,6 +74,240 @@ func (q *Queries) GetAssignmentStats(ctx context.Context) ([]GetAssignmentStatsR
 	return items, nil
 }
 
+const getFirstReviewTimeByAuthor = `-- name: GetFirstReviewTimeByAuthor :many
+SELECT p.author_id,
+       COUNT(*) AS reviewed_count,
+       percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM p.first_reviewed_at - p.created_at))::float8 AS p50_seconds,
+       percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM p.first_reviewed_at - p.created_at))::float8 AS p90_seconds,
+       percentile_cont(0.99) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM p.first_reviewed_at - p.created_at))::float8 AS p99_seconds
+FROM pull_requests p
+JOIN users a ON a.user_id = p.author_id
+WHERE p.created_at IS NOT NULL
+  AND p.first_reviewed_at IS NOT NULL
+  AND ($1::timestamptz IS NULL OR p.first_reviewed_at >= $1)
+  AND ($2::timestamptz IS NULL OR p.first_reviewed_at < $2)
+GROUP BY p.author_id
+ORDER BY p.author_id
+`
+
+type GetFirstReviewTimeByAuthorParams struct {
+	WindowStart sql.NullTime
+	WindowEnd   sql.NullTime
+}
+
+type GetFirstReviewTimeByAuthorRow struct {
+	AuthorID      string
+	ReviewedCount int64
+	P50Seconds    float64
+	P90Seconds    float64
+	P99Seconds    float64
+}
+
+func (q *Queries) GetFirstReviewTimeByAuthor(ctx context.Context, arg GetFirstReviewTimeByAuthorParams) ([]GetFirstReviewTimeByAuthorRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFirstReviewTimeByAuthor, arg.WindowStart, arg.WindowEnd)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFirstReviewTimeByAuthorRow
+	for rows.Next() {
+		var i GetFirstReviewTimeByAuthorRow
+		if err := rows.Scan(
+			&i.AuthorID,
+			&i.ReviewedCount,
+			&i.P50Seconds,
+			&i.P90Seconds,
+			&i.P99Seconds,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFirstReviewTimeByTeam = `-- name: GetFirstReviewTimeByTeam :many
+SELECT a.team_name,
+       COUNT(*) AS reviewed_count,
+       percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM p.first_reviewed_at - p.created_at))::float8 AS p50_seconds,
+       percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM p.first_reviewed_at - p.created_at))::float8 AS p90_seconds,
+       percentile_cont(0.99) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM p.first_reviewed_at - p.created_at))::float8 AS p99_seconds
+FROM pull_requests p
+JOIN users a ON a.user_id = p.author_id
+WHERE p.created_at IS NOT NULL
+  AND p.first_reviewed_at IS NOT NULL
+  AND ($1::timestamptz IS NULL OR p.first_reviewed_at >= $1)
+  AND ($2::timestamptz IS NULL OR p.first_reviewed_at < $2)
+GROUP BY a.team_name
+ORDER BY a.team_name
+`
+
+type GetFirstReviewTimeByTeamParams struct {
+	WindowStart sql.NullTime
+	WindowEnd   sql.NullTime
+}
+
+type GetFirstReviewTimeByTeamRow struct {
+	TeamName      sql.NullString
+	ReviewedCount int64
+	P50Seconds    float64
+	P90Seconds    float64
+	P99Seconds    float64
+}
+
+func (q *Queries) GetFirstReviewTimeByTeam(ctx context.Context, arg GetFirstReviewTimeByTeamParams) ([]GetFirstReviewTimeByTeamRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFirstReviewTimeByTeam, arg.WindowStart, arg.WindowEnd)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFirstReviewTimeByTeamRow
+	for rows.Next() {
+		var i GetFirstReviewTimeByTeamRow
+		if err := rows.Scan(
+			&i.TeamName,
+			&i.ReviewedCount,
+			&i.P50Seconds,
+			&i.P90Seconds,
+			&i.P99Seconds,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMergeTimeByAuthor = `-- name: GetMergeTimeByAuthor :many
+SELECT p.author_id,
+       COUNT(*) AS merged_count,
+       percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM p.merged_at - p.created_at))::float8 AS p50_seconds,
+       percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM p.merged_at - p.created_at))::float8 AS p90_seconds,
+       percentile_cont(0.99) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM p.merged_at - p.created_at))::float8 AS p99_seconds
+FROM pull_requests p
+JOIN users a ON a.user_id = p.author_id
+WHERE p.status = 'MERGED'
+  AND p.created_at IS NOT NULL
+  AND p.merged_at IS NOT NULL
+  AND ($1::timestamptz IS NULL OR p.merged_at >= $1)
+  AND ($2::timestamptz IS NULL OR p.merged_at < $2)
+GROUP BY p.author_id
+ORDER BY p.author_id
+`
+
+type GetMergeTimeByAuthorParams struct {
+	WindowStart sql.NullTime
+	WindowEnd   sql.NullTime
+}
+
+type GetMergeTimeByAuthorRow struct {
+	AuthorID    string
+	MergedCount int64
+	P50Seconds  float64
+	P90Seconds  float64
+	P99Seconds  float64
+}
+
+func (q *Queries) GetMergeTimeByAuthor(ctx context.Context, arg GetMergeTimeByAuthorParams) ([]GetMergeTimeByAuthorRow, error) {
+	rows, err := q.db.QueryContext(ctx, getMergeTimeByAuthor, arg.WindowStart, arg.WindowEnd)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetMergeTimeByAuthorRow
+	for rows.Next() {
+		var i GetMergeTimeByAuthorRow
+		if err := rows.Scan(
+			&i.AuthorID,
+			&i.MergedCount,
+			&i.P50Seconds,
+			&i.P90Seconds,
+			&i.P99Seconds,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMergeTimeByTeam = `-- name: GetMergeTimeByTeam :many
+SELECT a.team_name,
+       COUNT(*) AS merged_count,
+       percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM p.merged_at - p.created_at))::float8 AS p50_seconds,
+       percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM p.merged_at - p.created_at))::float8 AS p90_seconds,
+       percentile_cont(0.99) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM p.merged_at - p.created_at))::float8 AS p99_seconds
+FROM pull_requests p
+JOIN users a ON a.user_id = p.author_id
+WHERE p.status = 'MERGED'
+  AND p.created_at IS NOT NULL
+  AND p.merged_at IS NOT NULL
+  AND ($1::timestamptz IS NULL OR p.merged_at >= $1)
+  AND ($2::timestamptz IS NULL OR p.merged_at < $2)
+GROUP BY a.team_name
+ORDER BY a.team_name
+`
+
+type GetMergeTimeByTeamParams struct {
+	WindowStart sql.NullTime
+	WindowEnd   sql.NullTime
+}
+
+type GetMergeTimeByTeamRow struct {
+	TeamName    sql.NullString
+	MergedCount int64
+	P50Seconds  float64
+	P90Seconds  float64
+	P99Seconds  float64
+}
+
+func (q *Queries) GetMergeTimeByTeam(ctx context.Context, arg GetMergeTimeByTeamParams) ([]GetMergeTimeByTeamRow, error) {
+	rows, err := q.db.QueryContext(ctx, getMergeTimeByTeam, arg.WindowStart, arg.WindowEnd)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetMergeTimeByTeamRow
+	for rows.Next() {
+		var i GetMergeTimeByTeamRow
+		if err := rows.Scan(
+			&i.TeamName,
+			&i.MergedCount,
+			&i.P50Seconds,
+			&i.P90Seconds,
+			&i.P99Seconds,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getPRStats = `-- name: GetPRStats :many
 SELECT status, COUNT(*) AS count
 FROM pull_requests
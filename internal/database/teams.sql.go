@@ -64,3 +64,30 @@ func (q *Queries) GetTeamMembers(ctx context.Context, teamName sql.NullString) (
 	}
 	return items, nil
 }
+
+const listTeams = `-- name: ListTeams :many
+SELECT t.team_name FROM teams t ORDER BY t.team_name
+`
+
+func (q *Queries) ListTeams(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listTeams)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var team_name string
+		if err := rows.Scan(&team_name); err != nil {
+			return nil, err
+		}
+		items = append(items, team_name)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -8,8 +8,45 @@ package database
 import (
 	"context"
 	"database/sql"
+
+	"github.com/lib/pq"
 )
 
+const deactivateUsers = `-- name: DeactivateUsers :many
+UPDATE users
+SET is_active = FALSE
+WHERE user_id = ANY($1::text[])
+RETURNING user_id, username, team_name, is_active
+`
+
+func (q *Queries) DeactivateUsers(ctx context.Context, userIds []string) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, deactivateUsers, pq.Array(userIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Username,
+			&i.TeamName,
+			&i.IsActive,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserById = `-- name: GetUserById :one
 SELECT u.user_id, u.username, u.team_name, u.is_active
 FROM users u
@@ -28,6 +65,40 @@ func (q *Queries) GetUserById(ctx context.Context, userID string) (User, error)
 	return i, err
 }
 
+const listUsers = `-- name: ListUsers :many
+SELECT u.user_id, u.username, u.team_name, u.is_active
+FROM users u
+ORDER BY u.user_id
+`
+
+func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, listUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Username,
+			&i.TeamName,
+			&i.IsActive,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const setUserActive = `-- name: SetUserActive :one
 UPDATE users
 SET is_active = $2
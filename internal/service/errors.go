@@ -0,0 +1,51 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound is returned by repositories when a row doesn't exist
+var ErrNotFound = errors.New("not found")
+
+// ErrAlreadyExists is returned by repositories when an insert conflicts with an existing row
+var ErrAlreadyExists = errors.New("already exists")
+
+// Domain errors returned by ReviewService
+// They are wrapped in *Error together with the reason reported to the caller
+var (
+	ErrPRNotFound     = errors.New("PR not found")
+	ErrPRExists       = errors.New("PR id already exists")
+	ErrPRMerged       = errors.New("PR is merged")
+	ErrPRClosed       = errors.New("PR is closed")
+	ErrNotApproved    = errors.New("PR is not approved")
+	ErrNotAssigned    = errors.New("reviewer is not assigned to this PR")
+	ErrNoCandidate    = errors.New("no reviewer candidate")
+	ErrAuthorNotFound = errors.New("author not found")
+	ErrAuthorNoTeam   = errors.New("author has no team")
+	ErrTeamExists     = errors.New("team_name already exists")
+	ErrTeamNotFound   = errors.New("team not found")
+	ErrUserNotFound   = errors.New("user not found")
+	ErrForbidden      = errors.New("forbidden")
+	ErrInvalidInput   = errors.New("invalid input")
+)
+
+// Error is a rejected operation
+// Kind is one of the domain errors above and Message is the human readable reason
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// newError wraps kind with a formatted reason
+func newError(kind error, format string, args ...interface{}) error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"time"
+)
+
+// PRStatus is the lifecycle state of a pull request
+type PRStatus string
+
+const (
+	PRStatusOpen   PRStatus = "OPEN"
+	PRStatusMerged PRStatus = "MERGED"
+	PRStatusClosed PRStatus = "CLOSED"
+)
+
+// ReviewState is a reviewer's verdict on a pull request
+type ReviewState string
+
+const (
+	ReviewStatePending          ReviewState = "PENDING"
+	ReviewStateApproved         ReviewState = "APPROVED"
+	ReviewStateChangesRequested ReviewState = "CHANGES_REQUESTED"
+)
+
+// ReviewerStrategy selects how reviewers are picked from team candidates
+type ReviewerStrategy string
+
+const (
+	StrategyRandom      ReviewerStrategy = "random"
+	StrategyLeastLoaded ReviewerStrategy = "least_loaded"
+	StrategyRoundRobin  ReviewerStrategy = "round_robin"
+)
+
+// User is a team member that can author and review pull requests
+type User struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	TeamName string `json:"team_name"` // Empty for users without a team
+	IsActive bool   `json:"is_active"`
+}
+
+// TeamMember is a user listed inside a team
+type TeamMember struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	IsActive bool   `json:"is_active"`
+}
+
+// Team is a team with its members
+type Team struct {
+	TeamName string       `json:"team_name"`
+	Members  []TeamMember `json:"members"`
+}
+
+// PullRequest is a pull request with its assigned reviewers
+type PullRequest struct {
+	PullRequestID     string     `json:"pull_request_id"`
+	PullRequestName   string     `json:"pull_request_name"`
+	AuthorID          string     `json:"author_id"`
+	Status            PRStatus   `json:"status"`
+	AssignedReviewers []string   `json:"assigned_reviewers"`
+	CreatedAt         *time.Time `json:"createdAt"`
+	MergedAt          *time.Time `json:"mergedAt"`
+	ClosedAt          *time.Time `json:"closedAt"`
+	ProjectPath       *string    `json:"project_path"`
+}
+
+// Review is a reviewer's verdict on a pull request
+type Review struct {
+	ReviewerID string      `json:"reviewer_id"`
+	State      ReviewState `json:"state"`
+	ReviewedAt *time.Time  `json:"reviewedAt"`
+}
+
+// AssignedReviewer is a reviewer of a PR together with the user data
+// needed to decide whether they can stay assigned
+type AssignedReviewer struct {
+	UserID     string
+	TeamName   string // Empty for users without a team
+	IsActive   bool
+	State      ReviewState
+	ReviewedAt *time.Time
+}
+
+// TeamSettings is the reviewer assignment configuration of a team
+type TeamSettings struct {
+	TeamName          string           `json:"team_name"`          // Name of the team
+	MinReviewers      int              `json:"min_reviewers"`      // Minimum reviewers a new PR must get
+	MaxReviewers      int              `json:"max_reviewers"`      // Maximum reviewers assigned to a new PR
+	Strategy          ReviewerStrategy `json:"strategy"`           // Reviewer selection strategy
+	RequiredApprovals int              `json:"required_approvals"` // Approvals required before merge
+}
+
+// ReviewerCandidate is an active team member that can be assigned as a reviewer
+type ReviewerCandidate struct {
+	UserID      string // ID of the candidate
+	OpenReviews int64  // Number of OPEN pull requests the candidate currently reviews
+}
+
+// Assignment is an OPEN PR reviewed by a user
+type Assignment struct {
+	PullRequestID  string
+	UserID         string // Assigned reviewer
+	AuthorTeamName string // Team of the PR author, empty when the author has no team
+}
+
+// AuditEvent is an entry of the audit log
+// Before and After are JSON snapshots of the entity
+type AuditEvent struct {
+	Actor       string
+	ActorUserID string // Empty when the actor is not bound to a user
+	Action      string
+	EntityType  string
+	EntityID    string
+	Before      []byte
+	After       []byte
+}
+
+// OutboxEvent is a webhook event waiting for delivery to subscribers
+type OutboxEvent struct {
+	EventID   string
+	EventType string
+	Payload   []byte
+}
+
+// Reassignment describes a reviewer moved from one user to another
+type Reassignment struct {
+	PullRequestID string `json:"pull_request_id"` // ID of the PR
+	OldReviewerID string `json:"old_reviewer_id"` // Removed reviewer
+	NewReviewerID string `json:"new_reviewer_id"` // Replacement reviewer
+}
+
+// ShortPR describes an OPEN PR that lost a reviewer without getting a replacement
+type ShortPR struct {
+	PullRequestID     string   `json:"pull_request_id"`     // ID of the PR
+	RemovedReviewerID string   `json:"removed_reviewer_id"` // Deactivated reviewer
+	AssignedReviewers []string `json:"assigned_reviewers"`  // Reviewers left on the PR
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"errors"
+)
+
+// OrgSnapshot is every team with its members, the format of ImportOrg and ExportOrg
+// Users without a team are not part of it
+type OrgSnapshot struct {
+	Teams []Team `json:"teams"`
+}
+
+// ImportDiff lists the changes of an import, the ones it would make in a dry run
+// A user can be listed in several categories, e.g. moved and deactivated
+type ImportDiff struct {
+	DryRun       bool         `json:"dry_run"`
+	CreatedTeams []string     `json:"created_teams"`
+	Created      []User       `json:"created"`     // Users that didn't exist
+	Moved        []UserMove   `json:"moved"`       // Users moved from another team
+	Renamed      []UserRename `json:"renamed"`     // Users with a new username
+	Activated    []User       `json:"activated"`   // Inactive users marked active
+	Deactivated  []User       `json:"deactivated"` // Users marked inactive or missing from the snapshot
+	Unchanged    int          `json:"unchanged"`   // Users the import leaves as they are
+
+	// OPEN PR reviews of deactivated users, moved like DeactivateTeamUsers does
+	Reassignments    []Reassignment `json:"reassignments"`
+	ShortOfReviewers []ShortPR      `json:"short_of_reviewers"`
+}
+
+// UserMove is a user moved to another team
+type UserMove struct {
+	UserID   string `json:"user_id"`
+	FromTeam string `json:"from_team"`
+	ToTeam   string `json:"to_team"`
+}
+
+// UserRename is a username change
+type UserRename struct {
+	UserID      string `json:"user_id"`
+	OldUsername string `json:"old_username"`
+	NewUsername string `json:"new_username"`
+}
+
+func newImportDiff(dryRun bool) ImportDiff {
+	return ImportDiff{
+		DryRun:       dryRun,
+		CreatedTeams: []string{},
+		Created:      []User{},
+		Moved:        []UserMove{},
+		Renamed:      []UserRename{},
+		Activated:    []User{},
+		Deactivated:  []User{},
+
+		Reassignments:    []Reassignment{},
+		ShortOfReviewers: []ShortPR{},
+	}
+}
+
+// errDryRun rolls back the transaction of a dry run import
+var errDryRun = errors.New("dry run")
+
+// recordUser adds the change from before to after to the diff, existed is false
+// for new users. It reports whether the user has to be written
+func (d *ImportDiff) recordUser(before User, existed bool, after User) bool {
+	if !existed {
+		d.Created = append(d.Created, after)
+		return true
+	}
+	if before == after {
+		d.Unchanged++
+		return false
+	}
+
+	if before.TeamName != after.TeamName {
+		d.Moved = append(d.Moved, UserMove{UserID: after.UserID, FromTeam: before.TeamName, ToTeam: after.TeamName})
+	}
+	if before.Username != after.Username {
+		d.Renamed = append(d.Renamed, UserRename{UserID: after.UserID, OldUsername: before.Username, NewUsername: after.Username})
+	}
+	switch {
+	case !before.IsActive && after.IsActive:
+		d.Activated = append(d.Activated, after)
+	case before.IsActive && !after.IsActive:
+		d.Deactivated = append(d.Deactivated, after)
+	}
+	return true
+}
+
+// ExportOrg returns every team with its members, ordered by team name and user_id
+func (s *ReviewService) ExportOrg(ctx context.Context) (OrgSnapshot, error) {
+	snapshot := OrgSnapshot{Teams: []Team{}}
+	err := s.repo.InTx(ctx, func(repo Repository) error {
+		teamNames, err := repo.ListTeams(ctx)
+		if err != nil {
+			return err
+		}
+		users, err := repo.ListUsers(ctx)
+		if err != nil {
+			return err
+		}
+
+		members := map[string][]TeamMember{}
+		for _, user := range users {
+			members[user.TeamName] = append(members[user.TeamName], TeamMember{
+				UserID:   user.UserID,
+				Username: user.Username,
+				IsActive: user.IsActive,
+			})
+		}
+
+		snapshot.Teams = make([]Team, len(teamNames))
+		for i, teamName := range teamNames {
+			snapshot.Teams[i] = Team{TeamName: teamName, Members: members[teamName]}
+			if snapshot.Teams[i].Members == nil {
+				snapshot.Teams[i].Members = []TeamMember{}
+			}
+		}
+		return nil
+	})
+	return snapshot, err
+}
+
+// ImportOrg makes teams and users match the snapshot in a single transaction
+// Missing teams and users are created, listed users get the team, username and
+// active flag of the snapshot and users with a team that are missing from the
+// snapshot are deactivated. Nothing is deleted, PRs and the audit log refer to it.
+// Like DeactivateTeamUsers, OPEN PR reviews of deactivated users move to active teammates.
+// With dryRun the import runs in a transaction that is rolled back, so the diff
+// shows what it would change. Reviewers picked at random may differ in the real run
+func (s *ReviewService) ImportOrg(ctx context.Context, actor Actor, snapshot OrgSnapshot, dryRun bool) (ImportDiff, error) {
+	if err := validateSnapshot(snapshot); err != nil {
+		return ImportDiff{}, err
+	}
+
+	var diff ImportDiff
+	err := s.repo.InTx(ctx, func(repo Repository) error {
+		diff = newImportDiff(dryRun)
+
+		teamNames, err := repo.ListTeams(ctx)
+		if err != nil {
+			return err
+		}
+		existingTeams := map[string]bool{}
+		for _, teamName := range teamNames {
+			existingTeams[teamName] = true
+		}
+
+		users, err := repo.ListUsers(ctx)
+		if err != nil {
+			return err
+		}
+		existingUsers := map[string]User{}
+		for _, user := range users {
+			existingUsers[user.UserID] = user
+		}
+
+		// apply writes a user and records it in the audit log, before is nil for new users
+		apply := func(before interface{}, after User) error {
+			if err := repo.UpsertUser(ctx, after); err != nil {
+				return err
+			}
+			return RecordAudit(ctx, repo, actor, AuditActionUserImport, AuditEntityUser, after.UserID, before, after)
+		}
+
+		listed := map[string]bool{}
+		for _, team := range snapshot.Teams {
+			if !existingTeams[team.TeamName] {
+				diff.CreatedTeams = append(diff.CreatedTeams, team.TeamName)
+				if err := repo.CreateTeam(ctx, team.TeamName); err != nil {
+					return err
+				}
+				if err := RecordAudit(ctx, repo, actor, AuditActionTeamCreate, AuditEntityTeam, team.TeamName, nil, team); err != nil {
+					return err
+				}
+			}
+
+			for _, member := range team.Members {
+				listed[member.UserID] = true
+				after := User{UserID: member.UserID, Username: member.Username, TeamName: team.TeamName, IsActive: member.IsActive}
+
+				before, ok := existingUsers[member.UserID]
+				if !diff.recordUser(before, ok, after) {
+					continue
+				}
+				if !ok {
+					if err := apply(nil, after); err != nil {
+						return err
+					}
+					continue
+				}
+				if err := apply(before, after); err != nil {
+					return err
+				}
+			}
+		}
+
+		// Team members left out of the snapshot stay in their team but can't review any more
+		for _, user := range users {
+			if listed[user.UserID] || user.TeamName == "" {
+				continue
+			}
+			if !user.IsActive {
+				diff.Unchanged++
+				continue
+			}
+			after := user
+			after.IsActive = false
+			diff.Deactivated = append(diff.Deactivated, after)
+			if err := apply(user, after); err != nil {
+				return err
+			}
+		}
+
+		// Deactivated users can't review, move their OPEN PR reviews to active teammates
+		if len(diff.Deactivated) > 0 {
+			userIDs := make([]string, len(diff.Deactivated))
+			for i, user := range diff.Deactivated {
+				userIDs[i] = user.UserID
+			}
+			diff.Reassignments, diff.ShortOfReviewers, err = s.reassignOpenReviews(ctx, repo, actor, userIDs)
+			if err != nil {
+				return err
+			}
+		}
+
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err == errDryRun {
+		err = nil
+	}
+	return diff, err
+}
+
+// validateSnapshot rejects snapshots with empty or duplicate names
+func validateSnapshot(snapshot OrgSnapshot) error {
+	teams := map[string]bool{}
+	users := map[string]string{} // user_id -> team_name
+	for _, team := range snapshot.Teams {
+		if team.TeamName == "" {
+			return newError(ErrInvalidInput, "team_name cannot be empty")
+		}
+		if teams[team.TeamName] {
+			return newError(ErrInvalidInput, "team %s is listed twice", team.TeamName)
+		}
+		teams[team.TeamName] = true
+
+		for _, member := range team.Members {
+			if member.UserID == "" {
+				return newError(ErrInvalidInput, "user_id cannot be empty in team %s", team.TeamName)
+			}
+			if member.Username == "" {
+				return newError(ErrInvalidInput, "username of %s cannot be empty", member.UserID)
+			}
+			if other, ok := users[member.UserID]; ok {
+				return newError(ErrInvalidInput, "user %s is listed in teams %s and %s", member.UserID, other, team.TeamName)
+			}
+			users[member.UserID] = team.TeamName
+		}
+	}
+	return nil
+}
@@ -0,0 +1,500 @@
+package service
+
+import (
+	"context"
+	"slices"
+)
+
+// NewPR is a pull request to open
+type NewPR struct {
+	PullRequestID   string
+	PullRequestName string
+	AuthorID        string
+	ProjectPath     string // Code host project of the PR, empty for PRs created through the API
+}
+
+// ReopenResult is the outcome of ReopenPR
+type ReopenResult struct {
+	PR               PullRequest `json:"pr"`                // Reopened PR
+	RemovedReviewers []string    `json:"removed_reviewers"` // Reviewers unassigned as no longer eligible
+	AddedReviewers   []string    `json:"added_reviewers"`   // Reviewers assigned to replace them
+}
+
+// ReassignResult is the outcome of ReassignReviewer
+type ReassignResult struct {
+	PR         PullRequest // PR with the updated reviewer list
+	ReplacedBy string      // ID of the new reviewer
+}
+
+// GetPR returns a pull request with its reviewers
+func (s *ReviewService) GetPR(ctx context.Context, prID string) (PullRequest, error) {
+	pr, err := s.repo.GetPR(ctx, prID)
+	if err == ErrNotFound {
+		return pr, newError(ErrPRNotFound, "PR not found")
+	}
+	return pr, err
+}
+
+// getPRForAction locks a PR and checks that the actor may act on behalf of its author
+// Concurrent actions on the same PR wait for the transaction to end, so the
+// status and reviewers it returns stay current until then.
+// verb names the action in the ErrForbidden message
+func getPRForAction(ctx context.Context, repo Repository, actor Actor, prID, verb string) (PullRequest, error) {
+	pr, err := repo.LockPR(ctx, prID)
+	if err == ErrNotFound {
+		return pr, newError(ErrPRNotFound, "PR not found")
+	}
+	if err != nil {
+		return pr, err
+	}
+
+	if !actor.CanActAs(pr.AuthorID) {
+		return pr, newError(ErrForbidden, "only admins or the PR author can %s", verb)
+	}
+	return pr, nil
+}
+
+// getAuthorTeam returns the team of a PR author
+func getAuthorTeam(ctx context.Context, repo Repository, authorID string) (string, error) {
+	author, err := repo.GetUser(ctx, authorID)
+	if err == ErrNotFound {
+		return "", newError(ErrAuthorNotFound, "author not found")
+	}
+	if err != nil {
+		return "", err
+	}
+	if author.TeamName == "" {
+		return "", newError(ErrAuthorNoTeam, "author has no team")
+	}
+	return author.TeamName, nil
+}
+
+// CreatePR opens a new pull request and assigns reviewers from the author's team
+// using the reviewer selection policy configured for that team
+func (s *ReviewService) CreatePR(ctx context.Context, actor Actor, in NewPR) (PullRequest, error) {
+	// Non-admin actors can only open PRs on behalf of their own user
+	if !actor.CanActAs(in.AuthorID) {
+		return PullRequest{}, newError(ErrForbidden, "cannot create PR for another author")
+	}
+
+	var created PullRequest
+	err := s.repo.InTx(ctx, func(repo Repository) error {
+		// Check if PR with the same ID already exists
+		if _, err := repo.GetPR(ctx, in.PullRequestID); err == nil {
+			return newError(ErrPRExists, "PR id already exists")
+		} else if err != ErrNotFound {
+			return err
+		}
+
+		// Verify that the author exists and belongs to a team
+		teamName, err := getAuthorTeam(ctx, repo, in.AuthorID)
+		if err != nil {
+			return err
+		}
+
+		// Find active reviewers in the same team, excluding the author
+		candidates, err := repo.GetActiveReviewersForTeam(ctx, teamName, in.AuthorID)
+		if err != nil {
+			return err
+		}
+
+		// Select up to max_reviewers from available candidates using the team's strategy
+		policy, err := s.teamPolicy(ctx, repo, teamName)
+		if err != nil {
+			return err
+		}
+		reviewers := policy.selector().Select(candidates, policy.MaxReviewers)
+		if len(reviewers) < policy.MinReviewers {
+			return newError(ErrNoCandidate, "not enough active reviewers in team")
+		}
+
+		// Create the pull request and assign the selected reviewers
+		// A concurrent request with the same id may have created it after the check above
+		err = repo.CreatePR(ctx, PullRequest{
+			PullRequestID:   in.PullRequestID,
+			PullRequestName: in.PullRequestName,
+			AuthorID:        in.AuthorID,
+			ProjectPath:     optionalString(in.ProjectPath),
+		})
+		if err == ErrAlreadyExists {
+			return newError(ErrPRExists, "PR id already exists")
+		}
+		if err != nil {
+			return err
+		}
+		for _, rID := range reviewers {
+			if err := repo.AddReviewer(ctx, in.PullRequestID, rID); err != nil {
+				return err
+			}
+		}
+
+		// Remember the last assigned reviewer for round-robin teams
+		if err := policy.recordAssignment(ctx, repo, reviewers); err != nil {
+			return err
+		}
+
+		// Record the new PR in the audit log and notify webhook subscribers
+		created, err = repo.GetPR(ctx, in.PullRequestID)
+		if err != nil {
+			return err
+		}
+		if err := RecordAudit(ctx, repo, actor, AuditActionPRCreate, AuditEntityPullRequest, in.PullRequestID, nil, created); err != nil {
+			return err
+		}
+		return enqueueEvent(ctx, repo, EventPRCreated, PREventData{PR: created})
+	})
+	return created, err
+}
+
+// MergePR sets the PR status to MERGED and records the merge timestamp
+// Merging is idempotent: an already merged PR is returned unchanged.
+// checkApprovals enforces the author's team approval rule, it is skipped
+// when the merge already happened in an external code host
+func (s *ReviewService) MergePR(ctx context.Context, actor Actor, prID string, checkApprovals bool) (PullRequest, error) {
+	var merged PullRequest
+	err := s.repo.InTx(ctx, func(repo Repository) error {
+		// Check if PR exists and the actor may merge it
+		before, err := getPRForAction(ctx, repo, actor, prID, "merge")
+		if err != nil {
+			return err
+		}
+
+		// Closed PRs have to be reopened before they can be merged
+		switch before.Status {
+		case PRStatusClosed:
+			return newError(ErrPRClosed, "cannot merge closed PR")
+		case PRStatusMerged:
+			merged = before
+			return nil
+		}
+
+		// Enforce the author's team approval rule
+		if checkApprovals {
+			approved, required, err := s.hasRequiredApprovals(ctx, repo, before)
+			if err != nil {
+				return err
+			}
+			if !approved {
+				return newError(ErrNotApproved, "PR requires %d approvals before merge", required)
+			}
+		}
+
+		merged, err = repo.SetPRMerged(ctx, prID)
+		if err != nil {
+			return err
+		}
+
+		if err := RecordAudit(ctx, repo, actor, AuditActionPRMerge, AuditEntityPullRequest, prID, before, merged); err != nil {
+			return err
+		}
+		return enqueueEvent(ctx, repo, EventPRMerged, PREventData{PR: merged})
+	})
+	return merged, err
+}
+
+// hasRequiredApprovals checks the PR against the required_approvals setting
+// of the author's team. Authors without a team have no approval rule
+// Returns: whether the rule is satisfied and the number of approvals required
+func (s *ReviewService) hasRequiredApprovals(ctx context.Context, repo Repository, pr PullRequest) (bool, int, error) {
+	author, err := repo.GetUser(ctx, pr.AuthorID)
+	if err != nil {
+		return false, 0, err
+	}
+	if author.TeamName == "" {
+		return true, 0, nil
+	}
+
+	policy, err := s.teamPolicy(ctx, repo, author.TeamName)
+	if err != nil {
+		return false, 0, err
+	}
+	if policy.RequiredApprovals == 0 {
+		return true, 0, nil
+	}
+
+	approvals, err := repo.CountApprovals(ctx, pr.PullRequestID)
+	if err != nil {
+		return false, 0, err
+	}
+	return approvals >= policy.RequiredApprovals, policy.RequiredApprovals, nil
+}
+
+// ClosePR closes a pull request without merging
+// Closing is idempotent: an already closed PR is returned unchanged
+func (s *ReviewService) ClosePR(ctx context.Context, actor Actor, prID string) (PullRequest, error) {
+	var closed PullRequest
+	err := s.repo.InTx(ctx, func(repo Repository) error {
+		// Check if PR exists and the actor may close it
+		before, err := getPRForAction(ctx, repo, actor, prID, "close")
+		if err != nil {
+			return err
+		}
+
+		// Merged PRs are final
+		switch before.Status {
+		case PRStatusMerged:
+			return newError(ErrPRMerged, "cannot close merged PR")
+		case PRStatusClosed:
+			closed = before
+			return nil
+		}
+
+		closed, err = repo.SetPRClosed(ctx, prID)
+		if err != nil {
+			return err
+		}
+		return RecordAudit(ctx, repo, actor, AuditActionPRClose, AuditEntityPullRequest, prID, before, closed)
+	})
+	return closed, err
+}
+
+// ReopenPR reopens a closed pull request
+// Reviewers that became inactive or left the author's team are unassigned,
+// and the PR is topped up to the team's max_reviewers from active teammates.
+// Reopening is idempotent: an already open PR is returned unchanged
+func (s *ReviewService) ReopenPR(ctx context.Context, actor Actor, prID string) (ReopenResult, error) {
+	var result ReopenResult
+	err := s.repo.InTx(ctx, func(repo Repository) error {
+		result = ReopenResult{
+			RemovedReviewers: []string{},
+			AddedReviewers:   []string{},
+		}
+
+		// Check if PR exists and the actor may reopen it
+		before, err := getPRForAction(ctx, repo, actor, prID, "reopen")
+		if err != nil {
+			return err
+		}
+
+		// Merged PRs are final
+		switch before.Status {
+		case PRStatusMerged:
+			return newError(ErrPRMerged, "cannot reopen merged PR")
+		case PRStatusOpen:
+			result.PR = before
+			return nil
+		}
+
+		if _, err := repo.SetPRReopened(ctx, prID); err != nil {
+			return err
+		}
+
+		author, err := repo.GetUser(ctx, before.AuthorID)
+		if err != nil {
+			return err
+		}
+
+		// Unassign reviewers who are no longer active members of the author's team
+		current, err := repo.GetAssignedReviewers(ctx, prID)
+		if err != nil {
+			return err
+		}
+		kept := 0
+		for _, reviewer := range current {
+			if reviewer.IsActive && author.TeamName != "" && reviewer.TeamName == author.TeamName {
+				kept++
+				continue
+			}
+			if err := repo.DeleteReviewer(ctx, prID, reviewer.UserID); err != nil {
+				return err
+			}
+			result.RemovedReviewers = append(result.RemovedReviewers, reviewer.UserID)
+		}
+
+		// Top up reviewers from the author's team
+		if author.TeamName != "" {
+			policy, err := s.teamPolicy(ctx, repo, author.TeamName)
+			if err != nil {
+				return err
+			}
+
+			if missing := policy.MaxReviewers - kept; missing > 0 {
+				// Exclude the author and the current reviewers
+				candidates, err := repo.GetEligibleReassignReviewers(ctx, author.TeamName, before.AuthorID, prID)
+				if err != nil {
+					return err
+				}
+
+				result.AddedReviewers = policy.selector().Select(candidates, missing)
+				for _, rID := range result.AddedReviewers {
+					if err := repo.AddReviewer(ctx, prID, rID); err != nil {
+						return err
+					}
+				}
+				if err := policy.recordAssignment(ctx, repo, result.AddedReviewers); err != nil {
+					return err
+				}
+			}
+		}
+
+		// Record the reopen, including reviewer changes, in the audit log
+		result.PR, err = repo.GetPR(ctx, prID)
+		if err != nil {
+			return err
+		}
+		return RecordAudit(ctx, repo, actor, AuditActionPRReopen, AuditEntityPullRequest, prID, before, result.PR)
+	})
+	return result, err
+}
+
+// RenamePR changes the name of a pull request
+// Renaming to the current name is a no-op
+func (s *ReviewService) RenamePR(ctx context.Context, actor Actor, prID, prName string) (PullRequest, error) {
+	var renamed PullRequest
+	err := s.repo.InTx(ctx, func(repo Repository) error {
+		// Check if PR exists and the actor may rename it
+		before, err := getPRForAction(ctx, repo, actor, prID, "rename")
+		if err != nil {
+			return err
+		}
+
+		if before.PullRequestName == prName {
+			renamed = before
+			return nil
+		}
+
+		renamed, err = repo.RenamePR(ctx, prID, prName)
+		if err != nil {
+			return err
+		}
+		return RecordAudit(ctx, repo, actor, AuditActionPRRename, AuditEntityPullRequest, prID, before, renamed)
+	})
+	return renamed, err
+}
+
+// ReassignReviewer replaces an assigned reviewer with a teammate of the PR author
+// chosen by the reviewer selection policy configured for that team
+func (s *ReviewService) ReassignReviewer(ctx context.Context, actor Actor, prID, oldReviewerID string) (ReassignResult, error) {
+	var result ReassignResult
+	err := s.repo.InTx(ctx, func(repo Repository) error {
+		// Check if PR exists and the actor may reassign its reviewers
+		before, err := getPRForAction(ctx, repo, actor, prID, "reassign")
+		if err != nil {
+			return err
+		}
+
+		// Reviewers are frozen once the PR is merged or closed
+		switch before.Status {
+		case PRStatusMerged:
+			return newError(ErrPRMerged, "cannot reassign on merged PR")
+		case PRStatusClosed:
+			return newError(ErrPRClosed, "cannot reassign on closed PR")
+		}
+
+		// Verify that the old reviewer is actually assigned to this PR
+		if !slices.Contains(before.AssignedReviewers, oldReviewerID) {
+			return newError(ErrNotAssigned, "reviewer is not assigned to this PR")
+		}
+
+		// Replacements come from the author's team
+		teamName, err := getAuthorTeam(ctx, repo, before.AuthorID)
+		if err != nil {
+			return err
+		}
+
+		// Find eligible replacements, excluding the old reviewer and current reviewers
+		candidates, err := repo.GetEligibleReassignReviewers(ctx, teamName, oldReviewerID, prID)
+		if err != nil {
+			return err
+		}
+		if len(candidates) == 0 {
+			return newError(ErrNoCandidate, "no active replacement candidate in team")
+		}
+
+		// Select one new reviewer using the team's strategy
+		policy, err := s.teamPolicy(ctx, repo, teamName)
+		if err != nil {
+			return err
+		}
+		result.ReplacedBy = policy.selector().Select(candidates, 1)[0]
+
+		// Swap the reviewers
+		if err := repo.DeleteReviewer(ctx, prID, oldReviewerID); err != nil {
+			return err
+		}
+		if err := repo.AddReviewer(ctx, prID, result.ReplacedBy); err != nil {
+			return err
+		}
+
+		// Remember the new reviewer for round-robin teams
+		if err := policy.recordAssignment(ctx, repo, []string{result.ReplacedBy}); err != nil {
+			return err
+		}
+
+		// Record the reassignment in the audit log and notify webhook subscribers
+		result.PR, err = repo.GetPR(ctx, prID)
+		if err != nil {
+			return err
+		}
+		if err := RecordAudit(ctx, repo, actor, AuditActionPRReassign, AuditEntityPullRequest, prID, before, result.PR); err != nil {
+			return err
+		}
+		return enqueueEvent(ctx, repo, EventPRReassigned, PRReassignedEventData{
+			PR:            result.PR,
+			OldReviewerID: oldReviewerID,
+			NewReviewerID: &result.ReplacedBy,
+		})
+	})
+	return result, err
+}
+
+// ReviewPR records a reviewer's verdict on an OPEN pull request
+func (s *ReviewService) ReviewPR(ctx context.Context, actor Actor, prID, reviewerID string, state ReviewState) (PullRequest, Review, error) {
+	// Reviewers submit their own verdicts, admins may record them for anyone
+	if !actor.CanActAs(reviewerID) {
+		return PullRequest{}, Review{}, newError(ErrForbidden, "cannot review on behalf of another user")
+	}
+
+	var pr PullRequest
+	var review Review
+	err := s.repo.InTx(ctx, func(repo Repository) error {
+		current, err := repo.LockPR(ctx, prID)
+		if err == ErrNotFound {
+			return newError(ErrPRNotFound, "PR not found")
+		}
+		if err != nil {
+			return err
+		}
+
+		// Verdicts can only change while the PR is open
+		switch current.Status {
+		case PRStatusMerged:
+			return newError(ErrPRMerged, "cannot review merged PR")
+		case PRStatusClosed:
+			return newError(ErrPRClosed, "cannot review closed PR")
+		}
+
+		// Find the reviewer's previous verdict
+		var before *Review
+		reviewers, err := repo.GetAssignedReviewers(ctx, prID)
+		if err != nil {
+			return err
+		}
+		for _, reviewer := range reviewers {
+			if reviewer.UserID == reviewerID {
+				before = &Review{ReviewerID: reviewer.UserID, State: reviewer.State, ReviewedAt: reviewer.ReviewedAt}
+			}
+		}
+
+		// Record the verdict, no row means the user is not assigned
+		review, err = repo.SetReviewState(ctx, prID, reviewerID, state)
+		if err == ErrNotFound {
+			return newError(ErrNotAssigned, "reviewer is not assigned to this PR")
+		}
+		if err != nil {
+			return err
+		}
+
+		pr = current
+		return RecordAudit(ctx, repo, actor, AuditActionPRReview, AuditEntityPullRequest, prID, before, review)
+	})
+	return pr, review, err
+}
+
+// optionalString converts an empty value to nil
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+)
+
+// Repository is the storage used by ReviewService
+// Lookups of missing rows return ErrNotFound
+type Repository interface {
+	// InTx runs fn with a repository bound to a transaction
+	// The transaction is committed when fn returns nil and rolled back otherwise.
+	// fn may run again when the storage aborts the transaction because of a
+	// conflict with a concurrent one, so it must not have effects outside of it
+	InTx(ctx context.Context, fn func(Repository) error) error
+
+	// Teams
+	TeamExists(ctx context.Context, teamName string) (bool, error)
+	CreateTeam(ctx context.Context, teamName string) error
+	ListTeams(ctx context.Context) ([]string, error)                        // Ordered by name
+	GetTeamPolicy(ctx context.Context, teamName string) (TeamPolicy, error) // ErrNotFound for teams that were never configured
+	UpsertTeamSettings(ctx context.Context, settings TeamSettings) (TeamSettings, error)
+	SetRoundRobinCursor(ctx context.Context, teamName, userID string) error
+
+	// Users
+	GetUser(ctx context.Context, userID string) (User, error)
+	ListUsers(ctx context.Context) ([]User, error) // Ordered by user_id
+	UpsertUser(ctx context.Context, user User) error
+	SetUserActive(ctx context.Context, userID string, isActive bool) (User, error)
+	DeactivateUsers(ctx context.Context, userIDs []string) ([]User, error)
+
+	// Pull requests, returned together with their assigned reviewers
+	GetPR(ctx context.Context, prID string) (PullRequest, error)
+	LockPR(ctx context.Context, prID string) (PullRequest, error) // GetPR that blocks concurrent changes to the PR until the transaction ends
+	CreatePR(ctx context.Context, pr PullRequest) error           // ErrAlreadyExists when the id is taken
+	SetPRMerged(ctx context.Context, prID string) (PullRequest, error)
+	SetPRClosed(ctx context.Context, prID string) (PullRequest, error)
+	SetPRReopened(ctx context.Context, prID string) (PullRequest, error)
+	RenamePR(ctx context.Context, prID, prName string) (PullRequest, error)
+
+	// Reviewers
+	AddReviewer(ctx context.Context, prID, userID string) error
+	DeleteReviewer(ctx context.Context, prID, userID string) error
+	GetAssignedReviewers(ctx context.Context, prID string) ([]AssignedReviewer, error)
+	SetReviewState(ctx context.Context, prID, userID string, state ReviewState) (Review, error) // ErrNotFound when the user is not assigned
+	CountApprovals(ctx context.Context, prID string) (int, error)
+	GetActiveReviewersForTeam(ctx context.Context, teamName, excludeUserID string) ([]ReviewerCandidate, error)
+	GetEligibleReassignReviewers(ctx context.Context, teamName, excludeUserID, prID string) ([]ReviewerCandidate, error)
+	GetOpenAssignmentsForReviewers(ctx context.Context, userIDs []string) ([]Assignment, error)
+
+	// Audit log and webhook outbox, written in the transaction of the change
+	InsertAuditEvent(ctx context.Context, event AuditEvent) error
+	EnqueueOutboxEvent(ctx context.Context, event OutboxEvent) error
+}
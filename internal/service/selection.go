@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+)
+
+const (
+	DefaultMinReviewers = 0  // Minimum reviewers for teams without settings
+	DefaultMaxReviewers = 2  // Maximum reviewers for teams without settings
+	MaxReviewersLimit   = 10 // Upper bound accepted for max_reviewers
+)
+
+// reviewerSelector picks up to count reviewers from the candidate list
+type reviewerSelector interface {
+	Select(candidates []ReviewerCandidate, count int) []string
+}
+
+// randomSelector ignores reviewer load and picks candidates uniformly
+type randomSelector struct{}
+
+func (randomSelector) Select(candidates []ReviewerCandidate, count int) []string {
+	ids := make([]string, len(candidates))
+	for i, c := range candidates {
+		ids[i] = c.UserID
+	}
+	if count <= 0 {
+		return []string{}
+	}
+	return chooseRandomReviewers(ids, count)
+}
+
+// leastLoadedSelector prefers candidates with the fewest OPEN reviews
+type leastLoadedSelector struct{}
+
+func (leastLoadedSelector) Select(candidates []ReviewerCandidate, count int) []string {
+	if len(candidates) == 0 || count <= 0 {
+		return []string{}
+	}
+
+	// Shuffle first so that the stable sort below breaks ties randomly
+	shuffled := make([]ReviewerCandidate, len(candidates))
+	copy(shuffled, candidates)
+	rand.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	sort.SliceStable(shuffled, func(i, j int) bool {
+		return shuffled[i].OpenReviews < shuffled[j].OpenReviews
+	})
+
+	if count > len(shuffled) {
+		count = len(shuffled)
+	}
+	reviewers := make([]string, count)
+	for i := range reviewers {
+		reviewers[i] = shuffled[i].UserID
+	}
+	return reviewers
+}
+
+// roundRobinSelector walks team members in user_id order,
+// starting right after the last reviewer assigned in the team
+type roundRobinSelector struct {
+	After string // user_id of the last assigned reviewer, empty to start from the beginning
+}
+
+func (s roundRobinSelector) Select(candidates []ReviewerCandidate, count int) []string {
+	if len(candidates) == 0 || count <= 0 {
+		return []string{}
+	}
+
+	ids := make([]string, len(candidates))
+	for i, c := range candidates {
+		ids[i] = c.UserID
+	}
+	sort.Strings(ids)
+
+	// Find the first candidate after the cursor, wrapping around to the start
+	start := sort.SearchStrings(ids, s.After)
+	if start < len(ids) && ids[start] == s.After {
+		start++
+	}
+
+	if count > len(ids) {
+		count = len(ids)
+	}
+	reviewers := make([]string, count)
+	for i := range reviewers {
+		reviewers[i] = ids[(start+i)%len(ids)]
+	}
+	return reviewers
+}
+
+// chooseRandomReviewers randomly selects reviewers from the candidate list
+// count: number of reviewers to select
+// Returns: slice of selected reviewer IDs, candidates is left untouched
+func chooseRandomReviewers(candidates []string, count int) []string {
+	n := len(candidates)
+	if n == 0 {
+		return []string{} // No candidates available
+	}
+	shuffled := make([]string, n)
+	copy(shuffled, candidates)
+	if n <= count {
+		return shuffled // Not enough candidates, return all available
+	}
+
+	// Shuffle the copy randomly
+	rand.Shuffle(n, func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	// Return the first 'count' elements after shuffling
+	return shuffled[:count]
+}
+
+// ParseReviewerStrategy validates a strategy name coming from config or a request
+func ParseReviewerStrategy(value string) (ReviewerStrategy, error) {
+	switch strategy := ReviewerStrategy(value); strategy {
+	case StrategyRandom, StrategyLeastLoaded, StrategyRoundRobin:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("unknown reviewer strategy %q", value)
+	}
+}
+
+// ParseReviewState validates a review state coming from a request
+func ParseReviewState(value string) (ReviewState, error) {
+	switch state := ReviewState(value); state {
+	case ReviewStatePending, ReviewStateApproved, ReviewStateChangesRequested:
+		return state, nil
+	default:
+		return "", fmt.Errorf("unknown review state %q", value)
+	}
+}
+
+// TeamPolicy is the effective reviewer assignment configuration of a team
+type TeamPolicy struct {
+	TeamName          string
+	MinReviewers      int
+	MaxReviewers      int
+	Strategy          ReviewerStrategy
+	RoundRobinCursor  string
+	RequiredApprovals int // Approvals needed before a PR can be merged, 0 disables the rule
+}
+
+// Settings returns the user visible part of the policy
+func (p TeamPolicy) Settings() TeamSettings {
+	return TeamSettings{
+		TeamName:          p.TeamName,
+		MinReviewers:      p.MinReviewers,
+		MaxReviewers:      p.MaxReviewers,
+		Strategy:          p.Strategy,
+		RequiredApprovals: p.RequiredApprovals,
+	}
+}
+
+// selector returns the reviewer selector implementing the team's strategy
+func (p TeamPolicy) selector() reviewerSelector {
+	switch p.Strategy {
+	case StrategyRandom:
+		return randomSelector{}
+	case StrategyRoundRobin:
+		return roundRobinSelector{After: p.RoundRobinCursor}
+	default:
+		return leastLoadedSelector{}
+	}
+}
+
+// teamPolicy loads team settings, falling back to service defaults
+// when the team has never been configured
+func (s *ReviewService) teamPolicy(ctx context.Context, repo Repository, teamName string) (TeamPolicy, error) {
+	policy, err := repo.GetTeamPolicy(ctx, teamName)
+	if err == ErrNotFound {
+		return TeamPolicy{
+			TeamName:     teamName,
+			MinReviewers: DefaultMinReviewers,
+			MaxReviewers: DefaultMaxReviewers,
+			Strategy:     s.defaultStrategy,
+		}, nil
+	}
+	return policy, err
+}
+
+// recordAssignment persists the round-robin cursor after reviewers were chosen
+// It is a no-op for strategies that don't keep state
+func (p TeamPolicy) recordAssignment(ctx context.Context, repo Repository, reviewers []string) error {
+	if p.Strategy != StrategyRoundRobin || len(reviewers) == 0 {
+		return nil
+	}
+	return repo.SetRoundRobinCursor(ctx, p.TeamName, reviewers[len(reviewers)-1])
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// candidates builds a candidate list ordered by user_id from open review counts
+func candidates(loads map[string]int64) []ReviewerCandidate {
+	list := []ReviewerCandidate{}
+	for id, load := range loads {
+		list = append(list, ReviewerCandidate{UserID: id, OpenReviews: load})
+	}
+	slices.SortFunc(list, func(a, b ReviewerCandidate) int { return strings.Compare(a.UserID, b.UserID) })
+	return list
+}
+
+func TestLeastLoadedSelector(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates map[string]int64
+		count      int
+		want       []string
+	}{
+		{"fewest open reviews win", map[string]int64{"u1": 3, "u2": 0, "u3": 1}, 2, []string{"u2", "u3"}},
+		{"busiest last", map[string]int64{"u1": 3, "u2": 0, "u3": 1}, 3, []string{"u2", "u3", "u1"}},
+		{"fewer candidates than wanted", map[string]int64{"u1": 2, "u2": 1}, 5, []string{"u2", "u1"}},
+		{"no candidates", map[string]int64{}, 2, []string{}},
+		{"none wanted", map[string]int64{"u1": 0}, 0, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (leastLoadedSelector{}).Select(candidates(tt.candidates), tt.count); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Select = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Equally loaded candidates are picked at random, busier ones never
+func TestLeastLoadedSelectorBreaksTiesRandomly(t *testing.T) {
+	list := candidates(map[string]int64{"u1": 1, "u2": 1, "u3": 1, "u4": 4})
+	picked := map[string]int{}
+	for i := 0; i < 300; i++ {
+		for _, id := range (leastLoadedSelector{}).Select(list, 1) {
+			picked[id]++
+		}
+	}
+	for _, id := range []string{"u1", "u2", "u3"} {
+		if picked[id] == 0 {
+			t.Errorf("%s never picked among equally loaded candidates: %v", id, picked)
+		}
+	}
+	if picked["u4"] != 0 {
+		t.Errorf("busier candidate picked: %v", picked)
+	}
+}
+
+func TestRoundRobinSelector(t *testing.T) {
+	team := candidates(map[string]int64{"u4": 0, "u2": 0, "u1": 0, "u3": 0})
+
+	tests := []struct {
+		name  string
+		after string
+		count int
+		want  []string
+	}{
+		{"starts at the beginning", "", 2, []string{"u1", "u2"}},
+		{"continues after the cursor", "u2", 2, []string{"u3", "u4"}},
+		{"wraps around", "u3", 2, []string{"u4", "u1"}},
+		{"wraps after the last member", "u4", 1, []string{"u1"}},
+		{"cursor left the team", "u25", 2, []string{"u3", "u4"}},
+		{"cursor after every member", "u9", 2, []string{"u1", "u2"}},
+		{"each member once", "u1", 10, []string{"u2", "u3", "u4", "u1"}},
+		{"none wanted", "u1", 0, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (roundRobinSelector{After: tt.after}).Select(team, tt.count); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Select = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChooseRandomReviewersKeepsInput(t *testing.T) {
+	ids := []string{"u1", "u2", "u3", "u4", "u5"}
+	for _, count := range []int{2, 5, 9} {
+		got := chooseRandomReviewers(ids, count)
+		if want := min(count, len(ids)); len(got) != want {
+			t.Errorf("count %d: got %v, want %d reviewers", count, got, want)
+		}
+		if !slices.Equal(ids, []string{"u1", "u2", "u3", "u4", "u5"}) {
+			t.Fatalf("count %d: candidates reordered to %v", count, ids)
+		}
+	}
+}
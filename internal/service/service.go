@@ -0,0 +1,148 @@
+// Package service implements the reviewer assignment rules: teams, users and
+// the pull request lifecycle. It is independent of HTTP and of the storage,
+// which is reached through the Repository interface
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// Audited entity types
+const (
+	AuditEntityTeam        = "team"
+	AuditEntityUser        = "user"
+	AuditEntityPullRequest = "pull_request"
+)
+
+// Audited actions
+const (
+	AuditActionTeamCreate         = "team.create"
+	AuditActionTeamSettingsUpdate = "team.settings_update"
+	AuditActionUserSetActive      = "user.set_active"
+	AuditActionUserDeactivate     = "user.deactivate"
+	AuditActionUserImport         = "user.import"
+	AuditActionPRCreate           = "pr.create"
+	AuditActionPRMerge            = "pr.merge"
+	AuditActionPRReassign         = "pr.reassign"
+	AuditActionPRClose            = "pr.close"
+	AuditActionPRReopen           = "pr.reopen"
+	AuditActionPRReview           = "pr.review"
+	AuditActionPRRename           = "pr.rename"
+)
+
+// Webhook event types
+const (
+	EventPRCreated    = "pr.created"
+	EventPRMerged     = "pr.merged"
+	EventPRReassigned = "pr.reassigned"
+)
+
+// SystemActor is recorded when a change is made without an authenticated caller
+const SystemActor = "system"
+
+// Actor is the caller on whose behalf an operation runs
+type Actor struct {
+	Name   string // Recorded in the audit log, SystemActor when empty
+	UserID string // User the actor is bound to, may be empty for admins
+	Admin  bool
+}
+
+// CanActAs reports whether the actor may act on behalf of userID
+// Admins may act as anyone, other actors only as their own user
+func (a Actor) CanActAs(userID string) bool {
+	return a.Admin || (a.UserID != "" && a.UserID == userID)
+}
+
+// Event is the JSON body posted to webhook subscribers
+type Event struct {
+	ID         string      `json:"id"`         // Event ID, the same for every subscriber and retry
+	Type       string      `json:"type"`       // Event type
+	OccurredAt time.Time   `json:"occurredAt"` // When the change was committed
+	Data       interface{} `json:"data"`       // Event specific payload
+}
+
+// PREventData is the payload of pr.created and pr.merged events
+type PREventData struct {
+	PR PullRequest `json:"pr"`
+}
+
+// PRReassignedEventData is the payload of pr.reassigned events
+type PRReassignedEventData struct {
+	PR            PullRequest `json:"pr"`
+	OldReviewerID string      `json:"old_reviewer_id"` // Reviewer that was removed
+	NewReviewerID *string     `json:"new_reviewer_id"` // Replacement, null when no candidate was found
+}
+
+// ReviewService runs team, user and pull request operations
+// Every change is written together with its audit event and webhook events
+// in a single repository transaction
+type ReviewService struct {
+	repo            Repository
+	defaultStrategy ReviewerStrategy // Strategy of teams without settings
+}
+
+// New creates a ReviewService on top of repo
+func New(repo Repository, defaultStrategy ReviewerStrategy) *ReviewService {
+	return &ReviewService{repo: repo, defaultStrategy: defaultStrategy}
+}
+
+// RecordAudit writes an audit event through repo, which must be bound to the
+// transaction of the change so that the event and the change commit together.
+// before and after are JSON snapshots of the entity, nil is stored as JSON null.
+// The API records changes it makes outside of the review service with it too
+func RecordAudit(ctx context.Context, repo Repository, actor Actor, action, entityType, entityID string, before, after interface{}) error {
+	beforeJSON, err := json.Marshal(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := json.Marshal(after)
+	if err != nil {
+		return err
+	}
+
+	name := actor.Name
+	if name == "" {
+		name = SystemActor
+	}
+
+	return repo.InsertAuditEvent(ctx, AuditEvent{
+		Actor:       name,
+		ActorUserID: actor.UserID,
+		Action:      action,
+		EntityType:  entityType,
+		EntityID:    entityID,
+		Before:      beforeJSON,
+		After:       afterJSON,
+	})
+}
+
+// enqueueEvent stores an event in the outbox for every matching subscription
+// repo must be bound to the transaction of the change, so an event is delivered
+// if and only if the change is committed
+func enqueueEvent(ctx context.Context, repo Repository, eventType string, data interface{}) error {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return err
+	}
+	eventID := hex.EncodeToString(buf)
+
+	payload, err := json.Marshal(Event{
+		ID:         eventID,
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	})
+	if err != nil {
+		return err
+	}
+
+	return repo.EnqueueOutboxEvent(ctx, OutboxEvent{
+		EventID:   eventID,
+		EventType: eventType,
+		Payload:   payload,
+	})
+}
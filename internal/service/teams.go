@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"slices"
+)
+
+// TeamSettingsUpdate is a partial update of team settings
+// Nil fields keep their current values
+type TeamSettingsUpdate struct {
+	TeamName          string
+	MinReviewers      *int
+	MaxReviewers      *int
+	Strategy          *ReviewerStrategy
+	RequiredApprovals *int
+}
+
+// DeactivationResult is the outcome of DeactivateTeamUsers
+type DeactivationResult struct {
+	TeamName         string         `json:"team_name"`
+	Deactivated      []User         `json:"deactivated"`        // Users marked inactive
+	Reassignments    []Reassignment `json:"reassignments"`      // Reviews moved to a teammate
+	ShortOfReviewers []ShortPR      `json:"short_of_reviewers"` // Reviews left without a replacement
+}
+
+// AddTeam creates a team and adds all its members to it
+// Members that already exist are moved to the new team and returned as moved
+func (s *ReviewService) AddTeam(ctx context.Context, actor Actor, team Team) (Team, []UserMove, error) {
+	diff := newImportDiff(false)
+	err := s.repo.InTx(ctx, func(repo Repository) error {
+		diff = newImportDiff(false)
+
+		// Check if a team with the same name already exists
+		exists, err := repo.TeamExists(ctx, team.TeamName)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return newError(ErrTeamExists, "team_name already exists")
+		}
+
+		if err := repo.CreateTeam(ctx, team.TeamName); err != nil {
+			return err
+		}
+
+		// Add each member to the team, noting the ones taken from another team
+		for _, member := range team.Members {
+			after := User{UserID: member.UserID, Username: member.Username, TeamName: team.TeamName, IsActive: member.IsActive}
+			before, err := repo.GetUser(ctx, member.UserID)
+			if err != nil && err != ErrNotFound {
+				return err
+			}
+			diff.recordUser(before, err == nil, after)
+			if err := repo.UpsertUser(ctx, after); err != nil {
+				return err
+			}
+		}
+
+		// Record the new team in the audit log
+		return RecordAudit(ctx, repo, actor, AuditActionTeamCreate, AuditEntityTeam, team.TeamName, nil, team)
+	})
+	return team, diff.Moved, err
+}
+
+// requireTeam returns ErrTeamNotFound when the team doesn't exist
+func requireTeam(ctx context.Context, repo Repository, teamName string) error {
+	exists, err := repo.TeamExists(ctx, teamName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return newError(ErrTeamNotFound, "team not found")
+	}
+	return nil
+}
+
+// GetTeamSettings returns the effective settings of a team
+// Teams that were never configured report the service defaults
+func (s *ReviewService) GetTeamSettings(ctx context.Context, teamName string) (TeamSettings, error) {
+	if err := requireTeam(ctx, s.repo, teamName); err != nil {
+		return TeamSettings{}, err
+	}
+
+	policy, err := s.teamPolicy(ctx, s.repo, teamName)
+	if err != nil {
+		return TeamSettings{}, err
+	}
+	return policy.Settings(), nil
+}
+
+// SetTeamSettings applies a partial settings update and returns the stored settings
+func (s *ReviewService) SetTeamSettings(ctx context.Context, actor Actor, update TeamSettingsUpdate) (TeamSettings, error) {
+	var after TeamSettings
+	err := s.repo.InTx(ctx, func(repo Repository) error {
+		if err := requireTeam(ctx, repo, update.TeamName); err != nil {
+			return err
+		}
+
+		// Start from the current effective settings
+		policy, err := s.teamPolicy(ctx, repo, update.TeamName)
+		if err != nil {
+			return err
+		}
+		before := policy.Settings()
+
+		// Apply requested changes
+		settings := before
+		if update.MinReviewers != nil {
+			settings.MinReviewers = *update.MinReviewers
+		}
+		if update.MaxReviewers != nil {
+			settings.MaxReviewers = *update.MaxReviewers
+		}
+		if update.RequiredApprovals != nil {
+			settings.RequiredApprovals = *update.RequiredApprovals
+		}
+		if update.Strategy != nil {
+			settings.Strategy = *update.Strategy
+		}
+
+		// Validate reviewer count limits
+		if settings.MinReviewers < 0 {
+			return newError(ErrInvalidInput, "min_reviewers cannot be negative")
+		}
+		if settings.MaxReviewers > MaxReviewersLimit {
+			return newError(ErrInvalidInput, "max_reviewers cannot exceed %d", MaxReviewersLimit)
+		}
+		if settings.MinReviewers > settings.MaxReviewers {
+			return newError(ErrInvalidInput, "min_reviewers cannot exceed max_reviewers")
+		}
+		if settings.RequiredApprovals < 0 || settings.RequiredApprovals > MaxReviewersLimit {
+			return newError(ErrInvalidInput, "required_approvals must be between 0 and %d", MaxReviewersLimit)
+		}
+
+		after, err = repo.UpsertTeamSettings(ctx, settings)
+		if err != nil {
+			return err
+		}
+
+		// Record the change in the audit log
+		return RecordAudit(ctx, repo, actor, AuditActionTeamSettingsUpdate, AuditEntityTeam, after.TeamName, before, after)
+	})
+	return after, err
+}
+
+// DeactivateTeamUsers deactivates several team members at once
+// In a single transaction it marks the users inactive and moves each of their
+// OPEN PR assignments to an eligible active teammate of the PR author
+func (s *ReviewService) DeactivateTeamUsers(ctx context.Context, actor Actor, teamName string, userIDs []string) (DeactivationResult, error) {
+	var result DeactivationResult
+	err := s.repo.InTx(ctx, func(repo Repository) error {
+		result = DeactivationResult{TeamName: teamName}
+
+		if err := requireTeam(ctx, repo, teamName); err != nil {
+			return err
+		}
+
+		// Verify that every user exists and belongs to the team
+		usersBefore := map[string]User{}
+		for _, userID := range userIDs {
+			user, err := repo.GetUser(ctx, userID)
+			if err == ErrNotFound || (err == nil && user.TeamName != teamName) {
+				return newError(ErrUserNotFound, "user %s not found in team", userID)
+			}
+			if err != nil {
+				return err
+			}
+			usersBefore[userID] = user
+		}
+
+		// Deactivate users first so they are never picked as replacements
+		deactivated, err := repo.DeactivateUsers(ctx, userIDs)
+		if err != nil {
+			return err
+		}
+		for _, user := range deactivated {
+			if err := RecordAudit(ctx, repo, actor, AuditActionUserDeactivate, AuditEntityUser, user.UserID, usersBefore[user.UserID], user); err != nil {
+				return err
+			}
+		}
+		result.Deactivated = deactivated
+
+		// Move their OPEN PR reviews to active teammates
+		result.Reassignments, result.ShortOfReviewers, err = s.reassignOpenReviews(ctx, repo, actor, userIDs)
+		return err
+	})
+	return result, err
+}
+
+// reassignOpenReviews moves the OPEN PR reviews of userIDs, who must already be
+// inactive, to an eligible active teammate of each PR author. PRs without a free
+// teammate lose the reviewer and are returned as short of reviewers
+func (s *ReviewService) reassignOpenReviews(ctx context.Context, repo Repository, actor Actor, userIDs []string) ([]Reassignment, []ShortPR, error) {
+	reassignments := []Reassignment{}
+	short := []ShortPR{}
+
+	// Find every OPEN PR the deactivated users review
+	assignments, err := repo.GetOpenAssignmentsForReviewers(ctx, userIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	policies := map[string]TeamPolicy{}
+	for _, a := range assignments {
+		before, err := repo.LockPR(ctx, a.PullRequestID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// A concurrent reassignment may have replaced the reviewer before the lock
+		if !slices.Contains(before.AssignedReviewers, a.UserID) {
+			continue
+		}
+
+		// Remove the deactivated reviewer
+		if err := repo.DeleteReviewer(ctx, a.PullRequestID, a.UserID); err != nil {
+			return nil, nil, err
+		}
+
+		// Pick a replacement from the author's team, same rules as reassignment
+		var newReviewer string
+		if a.AuthorTeamName != "" {
+			candidates, err := repo.GetEligibleReassignReviewers(ctx, a.AuthorTeamName, a.UserID, a.PullRequestID)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			policy, ok := policies[a.AuthorTeamName]
+			if !ok {
+				policy, err = s.teamPolicy(ctx, repo, a.AuthorTeamName)
+				if err != nil {
+					return nil, nil, err
+				}
+			}
+
+			if selected := policy.selector().Select(candidates, 1); len(selected) > 0 {
+				newReviewer = selected[0]
+				if err := repo.AddReviewer(ctx, a.PullRequestID, newReviewer); err != nil {
+					return nil, nil, err
+				}
+				if err := policy.recordAssignment(ctx, repo, selected); err != nil {
+					return nil, nil, err
+				}
+				policy.RoundRobinCursor = newReviewer
+			}
+			policies[a.AuthorTeamName] = policy
+		}
+
+		// Record the reviewer change in the audit log
+		after, err := repo.GetPR(ctx, a.PullRequestID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := RecordAudit(ctx, repo, actor, AuditActionPRReassign, AuditEntityPullRequest, a.PullRequestID, before, after); err != nil {
+			return nil, nil, err
+		}
+
+		// Notify webhook subscribers, new_reviewer_id is null when nobody was free
+		event := PRReassignedEventData{PR: after, OldReviewerID: a.UserID}
+		if newReviewer != "" {
+			event.NewReviewerID = &newReviewer
+		}
+		if err := enqueueEvent(ctx, repo, EventPRReassigned, event); err != nil {
+			return nil, nil, err
+		}
+
+		if newReviewer != "" {
+			reassignments = append(reassignments, Reassignment{
+				PullRequestID: a.PullRequestID,
+				OldReviewerID: a.UserID,
+				NewReviewerID: newReviewer,
+			})
+			continue
+		}
+
+		// No free candidate, report the PR as short of reviewers
+		short = append(short, ShortPR{
+			PullRequestID:     a.PullRequestID,
+			RemovedReviewerID: a.UserID,
+			AssignedReviewers: after.AssignedReviewers,
+		})
+	}
+	return reassignments, short, nil
+}
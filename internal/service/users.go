@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+)
+
+// SetUserActive sets a user's active status
+// Inactive users are never picked as reviewers
+func (s *ReviewService) SetUserActive(ctx context.Context, actor Actor, userID string, isActive bool) (User, error) {
+	var user User
+	err := s.repo.InTx(ctx, func(repo Repository) error {
+		before, err := repo.GetUser(ctx, userID)
+		if err == ErrNotFound {
+			return newError(ErrUserNotFound, "user not found")
+		}
+		if err != nil {
+			return err
+		}
+
+		user, err = repo.SetUserActive(ctx, userID, isActive)
+		if err != nil {
+			return err
+		}
+
+		// Record the change in the audit log
+		return RecordAudit(ctx, repo, actor, AuditActionUserSetActive, AuditEntityUser, user.UserID, before, user)
+	})
+	return user, err
+}
@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"GODanilich/avito_backend/internal/database"
+	"context"
+	"database/sql"
+)
+
+func (q *queries) GetActiveAPIToken(ctx context.Context, tokenHash string) (database.ApiToken, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	for _, token := range data.apiTokens {
+		if token.TokenHash == tokenHash && !token.RevokedAt.Valid {
+			return token, nil
+		}
+	}
+	return database.ApiToken{}, sql.ErrNoRows
+}
+
+// CreateAPIToken returns sql.ErrNoRows when the name is taken, like ON CONFLICT (name) DO NOTHING
+func (q *queries) CreateAPIToken(ctx context.Context, arg database.CreateAPITokenParams) (database.ApiToken, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	if _, ok := data.apiTokens[arg.Name]; ok {
+		return database.ApiToken{}, sql.ErrNoRows
+	}
+	token := database.ApiToken{
+		Name:      arg.Name,
+		TokenHash: arg.TokenHash,
+		Role:      arg.Role,
+		UserID:    arg.UserID,
+		CreatedAt: now(),
+	}
+	if err := putAPIToken(data, token); err != nil {
+		return database.ApiToken{}, err
+	}
+	return token, nil
+}
+
+// EnsureAPIToken creates the token or replaces its hash, role and user and un-revokes it
+func (q *queries) EnsureAPIToken(ctx context.Context, arg database.EnsureAPITokenParams) error {
+	data, unlock := q.lock()
+	defer unlock()
+
+	token, ok := data.apiTokens[arg.Name]
+	if !ok {
+		token = database.ApiToken{Name: arg.Name, CreatedAt: now()}
+	}
+	token.TokenHash = arg.TokenHash
+	token.Role = arg.Role
+	token.UserID = arg.UserID
+	token.RevokedAt = sql.NullTime{}
+	return putAPIToken(data, token)
+}
+
+func (q *queries) RevokeAPIToken(ctx context.Context, name string) (database.ApiToken, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	token, ok := data.apiTokens[name]
+	if !ok || token.RevokedAt.Valid {
+		return database.ApiToken{}, sql.ErrNoRows
+	}
+	token.RevokedAt = sql.NullTime{Time: now(), Valid: true}
+	data.apiTokens[name] = token
+	return token, nil
+}
+
+// putAPIToken stores a row after checking the table constraints
+func putAPIToken(data *state, token database.ApiToken) error {
+	for name, other := range data.apiTokens {
+		if name != token.Name && other.TokenHash == token.TokenHash {
+			return uniqueViolation("api_tokens", "api_tokens_token_hash_key")
+		}
+	}
+	if token.UserID.Valid {
+		if _, ok := data.users[token.UserID.String]; !ok {
+			return foreignKeyViolation("api_tokens", "api_tokens_user_id_fkey")
+		}
+	}
+	if token.Role != database.TokenRoleAdmin && !token.UserID.Valid {
+		return checkViolation("api_tokens", "api_tokens_check")
+	}
+	data.apiTokens[token.Name] = token
+	return nil
+}
@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"GODanilich/avito_backend/internal/database"
+	"context"
+	"encoding/json"
+	"sort"
+)
+
+func (q *queries) InsertAuditEvent(ctx context.Context, arg database.InsertAuditEventParams) error {
+	data, unlock := q.lock()
+	defer unlock()
+
+	q.store.seq.auditEvents++
+	id := q.store.seq.auditEvents
+	data.auditEvents[id] = database.AuditEvent{
+		ID:          id,
+		OccurredAt:  now(),
+		Actor:       arg.Actor,
+		ActorUserID: arg.ActorUserID,
+		Action:      arg.Action,
+		EntityType:  arg.EntityType,
+		EntityID:    arg.EntityID,
+		Before:      jsonValue(arg.Before),
+		After:       jsonValue(arg.After),
+	}
+	return nil
+}
+
+func (q *queries) ListAuditEvents(ctx context.Context, arg database.ListAuditEventsParams) ([]database.AuditEvent, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	items := []database.AuditEvent{}
+	for _, event := range data.auditEvents {
+		if arg.EntityType.Valid && event.EntityType != arg.EntityType.String {
+			continue
+		}
+		if arg.EntityID.Valid && event.EntityID != arg.EntityID.String {
+			continue
+		}
+		if arg.Action.Valid && event.Action != arg.Action.String {
+			continue
+		}
+		if arg.Actor.Valid && event.Actor != arg.Actor.String {
+			continue
+		}
+		if arg.OccurredFrom.Valid && event.OccurredAt.Before(arg.OccurredFrom.Time) {
+			continue
+		}
+		if arg.OccurredTo.Valid && !event.OccurredAt.Before(arg.OccurredTo.Time) {
+			continue
+		}
+		if arg.CursorID.Valid && event.ID >= arg.CursorID.Int64 {
+			continue
+		}
+		items = append(items, event)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID > items[j].ID })
+	return limit(items, arg.PageLimit), nil
+}
+
+// jsonValue copies a jsonb value, a missing value is stored as JSON null
+func jsonValue(value json.RawMessage) json.RawMessage {
+	if len(value) == 0 {
+		return json.RawMessage("null")
+	}
+	return append(json.RawMessage{}, value...)
+}
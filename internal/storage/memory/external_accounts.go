@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"GODanilich/avito_backend/internal/database"
+	"context"
+	"database/sql"
+	"sort"
+)
+
+func (q *queries) GetExternalAccountUser(ctx context.Context, arg database.GetExternalAccountUserParams) (string, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	account, ok := data.externalAccounts[externalAccountKey{arg.Provider, arg.Login}]
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return account.UserID, nil
+}
+
+func (q *queries) ListExternalAccounts(ctx context.Context, provider sql.NullString) ([]database.ExternalAccount, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	items := []database.ExternalAccount{}
+	for _, account := range data.externalAccounts {
+		if provider.Valid && account.Provider != provider.String {
+			continue
+		}
+		items = append(items, account)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Provider != items[j].Provider {
+			return items[i].Provider < items[j].Provider
+		}
+		return items[i].Login < items[j].Login
+	})
+	return items, nil
+}
+
+// UpsertExternalAccount maps the login to a user, replacing an existing mapping
+func (q *queries) UpsertExternalAccount(ctx context.Context, arg database.UpsertExternalAccountParams) (database.ExternalAccount, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	if _, ok := data.users[arg.UserID]; !ok {
+		return database.ExternalAccount{}, foreignKeyViolation("external_accounts", "external_accounts_user_id_fkey")
+	}
+
+	key := externalAccountKey{arg.Provider, arg.Login}
+	account, ok := data.externalAccounts[key]
+	if !ok {
+		account = database.ExternalAccount{Provider: arg.Provider, Login: arg.Login, CreatedAt: now()}
+	}
+	account.UserID = arg.UserID
+	data.externalAccounts[key] = account
+	return account, nil
+}
+
+func (q *queries) DeleteExternalAccount(ctx context.Context, arg database.DeleteExternalAccountParams) (database.ExternalAccount, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	key := externalAccountKey{arg.Provider, arg.Login}
+	account, ok := data.externalAccounts[key]
+	if !ok {
+		return database.ExternalAccount{}, sql.ErrNoRows
+	}
+	delete(data.externalAccounts, key)
+	return account, nil
+}
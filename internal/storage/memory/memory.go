@@ -0,0 +1,222 @@
+// Package memory implements storage.Store without a database, for tests and demos
+//
+// Every sqlc query is mirrored in Go with the same filtering, ordering and
+// conflict handling as its SQL, and constraint violations are reported as the
+// *pq.Error PostgreSQL would return. Nothing is persisted across restarts
+package memory
+
+import (
+	"GODanilich/avito_backend/internal/database"
+	"GODanilich/avito_backend/internal/storage"
+	"context"
+	"database/sql"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// state holds the tables
+// Rows are stored by value and never modified in place, so copying the maps
+// is enough to take a snapshot
+type state struct {
+	teams                map[string]database.Team
+	users                map[string]database.User
+	pullRequests         map[string]database.PullRequest
+	reviewers            map[string]map[string]database.PullRequestReviewer // By pull_request_id, then user_id
+	teamSettings         map[string]database.TeamSetting
+	apiTokens            map[string]database.ApiToken
+	auditEvents          map[int64]database.AuditEvent
+	webhookSubscriptions map[int64]database.WebhookSubscription
+	outbox               map[int64]database.Outbox
+	externalAccounts     map[externalAccountKey]database.ExternalAccount
+}
+
+type externalAccountKey struct {
+	provider string
+	login    string
+}
+
+func newState() *state {
+	return &state{
+		teams:                map[string]database.Team{},
+		users:                map[string]database.User{},
+		pullRequests:         map[string]database.PullRequest{},
+		reviewers:            map[string]map[string]database.PullRequestReviewer{},
+		teamSettings:         map[string]database.TeamSetting{},
+		apiTokens:            map[string]database.ApiToken{},
+		auditEvents:          map[int64]database.AuditEvent{},
+		webhookSubscriptions: map[int64]database.WebhookSubscription{},
+		outbox:               map[int64]database.Outbox{},
+		externalAccounts:     map[externalAccountKey]database.ExternalAccount{},
+	}
+}
+
+// clone returns a snapshot that later changes to s don't affect
+func (s *state) clone() *state {
+	reviewers := make(map[string]map[string]database.PullRequestReviewer, len(s.reviewers))
+	for prID, rows := range s.reviewers {
+		reviewers[prID] = maps.Clone(rows)
+	}
+	return &state{
+		teams:                maps.Clone(s.teams),
+		users:                maps.Clone(s.users),
+		pullRequests:         maps.Clone(s.pullRequests),
+		reviewers:            reviewers,
+		teamSettings:         maps.Clone(s.teamSettings),
+		apiTokens:            maps.Clone(s.apiTokens),
+		auditEvents:          maps.Clone(s.auditEvents),
+		webhookSubscriptions: maps.Clone(s.webhookSubscriptions),
+		outbox:               maps.Clone(s.outbox),
+		externalAccounts:     maps.Clone(s.externalAccounts),
+	}
+}
+
+// sequences are the BIGSERIAL counters
+// Like in PostgreSQL they are not rolled back with the transaction
+type sequences struct {
+	auditEvents          int64
+	webhookSubscriptions int64
+	outbox               int64
+}
+
+// Store is an in-memory storage.Store
+// Transactions are serialized: a transaction holds the store lock until it
+// commits or rolls back, and queries outside a transaction wait for it
+type Store struct {
+	queries
+	mu   sync.Mutex
+	data *state
+	seq  sequences
+}
+
+var _ storage.Store = (*Store)(nil)
+
+// New creates an empty store
+func New() *Store {
+	s := &Store{data: newState()}
+	s.queries = queries{store: s}
+	return s
+}
+
+// BeginTx starts a transaction, it blocks while another one is running
+// Queries must not run on the Store itself until the transaction ends
+func (s *Store) BeginTx(ctx context.Context) (storage.Tx, error) {
+	s.mu.Lock()
+	return &Tx{
+		queries:  queries{store: s, inTx: true},
+		snapshot: s.data.clone(),
+	}, nil
+}
+
+// Ping always succeeds, there is nothing to connect to
+func (s *Store) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// AppliedSchemaVersion reports the current version, the tables are created in Go
+func (s *Store) AppliedSchemaVersion(ctx context.Context) (int64, error) {
+	return storage.SchemaVersion, nil
+}
+
+// Tx is an in-memory storage.Tx
+// Changes are applied to the store directly and reverted on rollback
+type Tx struct {
+	queries
+	snapshot *state // Tables as they were when the transaction began
+	done     bool
+}
+
+func (t *Tx) Commit() error {
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	t.store.mu.Unlock()
+	return nil
+}
+
+func (t *Tx) Rollback() error {
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	t.store.data = t.snapshot
+	t.store.mu.Unlock()
+	return nil
+}
+
+// queries implements database.Querier on the store tables
+type queries struct {
+	store *Store
+	inTx  bool // The store lock is held by the transaction
+}
+
+var _ database.Querier = (*queries)(nil)
+
+// lock acquires the store lock for a single query and returns the tables
+// together with the function releasing it
+// Queries in a transaction already hold the lock
+func (q *queries) lock() (*state, func()) {
+	if q.inTx {
+		return q.store.data, func() {}
+	}
+	q.store.mu.Lock()
+	return q.store.data, q.store.mu.Unlock
+}
+
+// now returns the current time with the microsecond precision of timestamptz
+func now() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+// uniqueViolation is the error PostgreSQL returns for a duplicate key
+func uniqueViolation(table, constraint string) error {
+	return &pq.Error{
+		Severity:   "ERROR",
+		Code:       "23505",
+		Message:    fmt.Sprintf("duplicate key value violates unique constraint %q", constraint),
+		Table:      table,
+		Constraint: constraint,
+	}
+}
+
+// foreignKeyViolation is the error PostgreSQL returns for a missing referenced row
+func foreignKeyViolation(table, constraint string) error {
+	return &pq.Error{
+		Severity:   "ERROR",
+		Code:       "23503",
+		Message:    fmt.Sprintf("insert or update on table %q violates foreign key constraint %q", table, constraint),
+		Table:      table,
+		Constraint: constraint,
+	}
+}
+
+// checkViolation is the error PostgreSQL returns when a CHECK constraint fails
+func checkViolation(table, constraint string) error {
+	return &pq.Error{
+		Severity:   "ERROR",
+		Code:       "23514",
+		Message:    fmt.Sprintf("new row for relation %q violates check constraint %q", table, constraint),
+		Table:      table,
+		Constraint: constraint,
+	}
+}
+
+// cloneStrings copies a text[] value so rows never share it with callers
+func cloneStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return append([]string{}, values...)
+}
+
+// limit applies the LIMIT of a query to rows
+func limit[T any](rows []T, n int32) []T {
+	if int(n) < len(rows) {
+		return rows[:max(n, 0)]
+	}
+	return rows
+}
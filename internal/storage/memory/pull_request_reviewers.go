@@ -0,0 +1,196 @@
+package memory
+
+import (
+	"GODanilich/avito_backend/internal/database"
+	"context"
+	"database/sql"
+	"slices"
+	"sort"
+)
+
+// AddReviewer assigns a reviewer, an existing assignment is left untouched
+// like ON CONFLICT DO NOTHING
+func (q *queries) AddReviewer(ctx context.Context, arg database.AddReviewerParams) error {
+	data, unlock := q.lock()
+	defer unlock()
+
+	if _, ok := data.pullRequests[arg.PullRequestID]; !ok {
+		return foreignKeyViolation("pull_request_reviewers", "pull_request_reviewers_pull_request_id_fkey")
+	}
+	if _, ok := data.users[arg.UserID]; !ok {
+		return foreignKeyViolation("pull_request_reviewers", "pull_request_reviewers_user_id_fkey")
+	}
+	if _, ok := data.reviewers[arg.PullRequestID][arg.UserID]; ok {
+		return nil
+	}
+
+	if data.reviewers[arg.PullRequestID] == nil {
+		data.reviewers[arg.PullRequestID] = map[string]database.PullRequestReviewer{}
+	}
+	data.reviewers[arg.PullRequestID][arg.UserID] = database.PullRequestReviewer{
+		PullRequestID: arg.PullRequestID,
+		UserID:        arg.UserID,
+		State:         database.ReviewStatePENDING,
+	}
+	return nil
+}
+
+func (q *queries) GetPRReviewers(ctx context.Context, pullRequestID string) ([]string, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	items := []string{}
+	for userID := range data.reviewers[pullRequestID] {
+		items = append(items, userID)
+	}
+	sort.Strings(items)
+	return items, nil
+}
+
+func (q *queries) GetPRReviewerDetails(ctx context.Context, pullRequestID string) ([]database.GetPRReviewerDetailsRow, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	items := []database.GetPRReviewerDetailsRow{}
+	for userID, reviewer := range data.reviewers[pullRequestID] {
+		user := data.users[userID]
+		items = append(items, database.GetPRReviewerDetailsRow{
+			UserID:     user.UserID,
+			Username:   user.Username,
+			TeamName:   user.TeamName,
+			IsActive:   user.IsActive,
+			State:      reviewer.State,
+			ReviewedAt: reviewer.ReviewedAt,
+		})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].UserID < items[j].UserID })
+	return items, nil
+}
+
+func (q *queries) DeleteReviewer(ctx context.Context, arg database.DeleteReviewerParams) error {
+	data, unlock := q.lock()
+	defer unlock()
+
+	delete(data.reviewers[arg.PullRequestID], arg.UserID)
+	return nil
+}
+
+func (q *queries) GetPRsForReviewer(ctx context.Context, arg database.GetPRsForReviewerParams) ([]database.GetPRsForReviewerRow, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	prs := []database.PullRequest{}
+	for prID, reviewers := range data.reviewers {
+		reviewer, ok := reviewers[arg.UserID]
+		if !ok || (arg.State.Valid && reviewer.State != arg.State.ReviewState) {
+			continue
+		}
+		prs = append(prs, data.pullRequests[prID])
+	}
+	// Newest first, ties broken by ID so the order is stable
+	sort.Slice(prs, func(i, j int) bool {
+		return createdBefore(prs[j], prs[i].CreatedAt, prs[i].PullRequestID)
+	})
+
+	items := make([]database.GetPRsForReviewerRow, len(prs))
+	for i, pr := range prs {
+		items[i] = database.GetPRsForReviewerRow{
+			PullRequestID:   pr.PullRequestID,
+			PullRequestName: pr.PullRequestName,
+			AuthorID:        pr.AuthorID,
+			Status:          pr.Status,
+			State:           data.reviewers[pr.PullRequestID][arg.UserID].State,
+		}
+	}
+	return items, nil
+}
+
+func (q *queries) IsReviewerAssigned(ctx context.Context, arg database.IsReviewerAssignedParams) (bool, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	_, ok := data.reviewers[arg.PullRequestID][arg.UserID]
+	return ok, nil
+}
+
+func (q *queries) SetReviewState(ctx context.Context, arg database.SetReviewStateParams) (database.PullRequestReviewer, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	reviewer, ok := data.reviewers[arg.PullRequestID][arg.UserID]
+	if !ok {
+		return database.PullRequestReviewer{}, sql.ErrNoRows
+	}
+	reviewer.State = arg.State
+	reviewer.ReviewedAt = sql.NullTime{Time: now(), Valid: true}
+	data.reviewers[arg.PullRequestID][arg.UserID] = reviewer
+	return reviewer, nil
+}
+
+func (q *queries) CountApprovals(ctx context.Context, pullRequestID string) (int64, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	var count int64
+	for _, reviewer := range data.reviewers[pullRequestID] {
+		if reviewer.State == database.ReviewStateAPPROVED {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (q *queries) GetOpenAssignmentsForReviewers(ctx context.Context, userIds []string) ([]database.GetOpenAssignmentsForReviewersRow, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	items := []database.GetOpenAssignmentsForReviewersRow{}
+	for prID, reviewers := range data.reviewers {
+		pr := data.pullRequests[prID]
+		if pr.Status != database.PrStatusOPEN {
+			continue
+		}
+		for userID := range reviewers {
+			if !slices.Contains(userIds, userID) {
+				continue
+			}
+			items = append(items, database.GetOpenAssignmentsForReviewersRow{
+				PullRequestID:  prID,
+				UserID:         userID,
+				AuthorID:       pr.AuthorID,
+				AuthorTeamName: data.users[pr.AuthorID].TeamName,
+			})
+		}
+	}
+	// ORDER BY p.created_at, r.pull_request_id, r.user_id
+	sort.Slice(items, func(i, j int) bool {
+		a, b := data.pullRequests[items[i].PullRequestID], data.pullRequests[items[j].PullRequestID]
+		if !a.CreatedAt.Time.Equal(b.CreatedAt.Time) {
+			return a.CreatedAt.Time.Before(b.CreatedAt.Time)
+		}
+		if items[i].PullRequestID != items[j].PullRequestID {
+			return items[i].PullRequestID < items[j].PullRequestID
+		}
+		return items[i].UserID < items[j].UserID
+	})
+	return items, nil
+}
+
+func (q *queries) GetReviewersForPRs(ctx context.Context, pullRequestIds []string) ([]database.GetReviewersForPRsRow, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	items := []database.GetReviewersForPRsRow{}
+	for _, prID := range pullRequestIds {
+		for userID := range data.reviewers[prID] {
+			items = append(items, database.GetReviewersForPRsRow{PullRequestID: prID, UserID: userID})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].PullRequestID != items[j].PullRequestID {
+			return items[i].PullRequestID < items[j].PullRequestID
+		}
+		return items[i].UserID < items[j].UserID
+	})
+	return slices.Compact(items), nil
+}
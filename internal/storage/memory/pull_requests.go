@@ -0,0 +1,251 @@
+package memory
+
+import (
+	"GODanilich/avito_backend/internal/database"
+	"context"
+	"database/sql"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+func (q *queries) CreatePR(ctx context.Context, arg database.CreatePRParams) error {
+	data, unlock := q.lock()
+	defer unlock()
+
+	if _, ok := data.pullRequests[arg.PullRequestID]; ok {
+		return uniqueViolation("pull_requests", "pull_requests_pkey")
+	}
+	if _, ok := data.users[arg.AuthorID]; !ok {
+		return foreignKeyViolation("pull_requests", "pull_requests_author_id_fkey")
+	}
+	data.pullRequests[arg.PullRequestID] = database.PullRequest{
+		PullRequestID:   arg.PullRequestID,
+		PullRequestName: arg.PullRequestName,
+		AuthorID:        arg.AuthorID,
+		Status:          database.PrStatusOPEN,
+		CreatedAt:       sql.NullTime{Time: now(), Valid: true},
+		ProjectPath:     arg.ProjectPath,
+	}
+	return nil
+}
+
+func (q *queries) GetPR(ctx context.Context, pullRequestID string) (database.PullRequest, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	pr, ok := data.pullRequests[pullRequestID]
+	if !ok {
+		return database.PullRequest{}, sql.ErrNoRows
+	}
+	return pr, nil
+}
+
+// GetPRForUpdate needs no row lock, a transaction already holds the store lock
+func (q *queries) GetPRForUpdate(ctx context.Context, pullRequestID string) (database.PullRequest, error) {
+	return q.GetPR(ctx, pullRequestID)
+}
+
+func (q *queries) SetPRMerged(ctx context.Context, pullRequestID string) (database.PullRequest, error) {
+	return q.updatePR(pullRequestID, func(pr *database.PullRequest) {
+		pr.Status = database.PrStatusMERGED
+		pr.MergedAt = sql.NullTime{Time: now(), Valid: true}
+	})
+}
+
+func (q *queries) SetPRClosed(ctx context.Context, pullRequestID string) (database.PullRequest, error) {
+	return q.updatePR(pullRequestID, func(pr *database.PullRequest) {
+		pr.Status = database.PrStatusCLOSED
+		pr.ClosedAt = sql.NullTime{Time: now(), Valid: true}
+	})
+}
+
+func (q *queries) SetPRFirstReviewed(ctx context.Context, pullRequestID string) error {
+	_, err := q.updatePR(pullRequestID, func(pr *database.PullRequest) {
+		if !pr.FirstReviewedAt.Valid {
+			pr.FirstReviewedAt = sql.NullTime{Time: now(), Valid: true}
+		}
+	})
+	if err == sql.ErrNoRows {
+		return nil // Like an UPDATE matching no rows
+	}
+	return err
+}
+
+func (q *queries) SetPRReopened(ctx context.Context, pullRequestID string) (database.PullRequest, error) {
+	return q.updatePR(pullRequestID, func(pr *database.PullRequest) {
+		pr.Status = database.PrStatusOPEN
+		pr.ClosedAt = sql.NullTime{}
+	})
+}
+
+func (q *queries) RenamePR(ctx context.Context, arg database.RenamePRParams) (database.PullRequest, error) {
+	return q.updatePR(arg.PullRequestID, func(pr *database.PullRequest) {
+		pr.PullRequestName = arg.PullRequestName
+	})
+}
+
+// updatePR applies update to a pull request and returns the new row
+func (q *queries) updatePR(pullRequestID string, update func(pr *database.PullRequest)) (database.PullRequest, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	pr, ok := data.pullRequests[pullRequestID]
+	if !ok {
+		return database.PullRequest{}, sql.ErrNoRows
+	}
+	update(&pr)
+	data.pullRequests[pullRequestID] = pr
+	return pr, nil
+}
+
+func (q *queries) GetActiveReviewersForTeam(ctx context.Context, arg database.GetActiveReviewersForTeamParams) ([]database.GetActiveReviewersForTeamRow, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	items := []database.GetActiveReviewersForTeamRow{}
+	for _, userID := range activeTeamMembers(data, arg.TeamName, arg.UserID) {
+		items = append(items, database.GetActiveReviewersForTeamRow{
+			UserID:      userID,
+			OpenReviews: openReviews(data, userID),
+		})
+	}
+	return items, nil
+}
+
+func (q *queries) IsMerged(ctx context.Context, pullRequestID string) (bool, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	pr, ok := data.pullRequests[pullRequestID]
+	return ok && pr.Status == database.PrStatusMERGED, nil
+}
+
+func (q *queries) GetEligibleReassignReviewers(ctx context.Context, arg database.GetEligibleReassignReviewersParams) ([]database.GetEligibleReassignReviewersRow, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	items := []database.GetEligibleReassignReviewersRow{}
+	for _, userID := range activeTeamMembers(data, arg.TeamName, arg.UserID) {
+		// Skip reviewers already assigned to the PR and its author
+		if _, ok := data.reviewers[arg.PullRequestID][userID]; ok {
+			continue
+		}
+		if pr, ok := data.pullRequests[arg.PullRequestID]; ok && pr.AuthorID == userID {
+			continue
+		}
+		items = append(items, database.GetEligibleReassignReviewersRow{
+			UserID:      userID,
+			OpenReviews: openReviews(data, userID),
+		})
+	}
+	return items, nil
+}
+
+func (q *queries) ListPRs(ctx context.Context, arg database.ListPRsParams) ([]database.PullRequest, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	items := []database.PullRequest{}
+	for _, pr := range data.pullRequests {
+		author, ok := data.users[pr.AuthorID]
+		if !ok {
+			continue
+		}
+		if arg.Status.Valid && pr.Status != arg.Status.PrStatus {
+			continue
+		}
+		if arg.AuthorID.Valid && pr.AuthorID != arg.AuthorID.String {
+			continue
+		}
+		if arg.TeamName.Valid && (!author.TeamName.Valid || author.TeamName.String != arg.TeamName.String) {
+			continue
+		}
+		if arg.ReviewerID.Valid {
+			if _, ok := data.reviewers[pr.PullRequestID][arg.ReviewerID.String]; !ok {
+				continue
+			}
+		}
+		if arg.CreatedFrom.Valid && (!pr.CreatedAt.Valid || pr.CreatedAt.Time.Before(arg.CreatedFrom.Time)) {
+			continue
+		}
+		if arg.CreatedTo.Valid && (!pr.CreatedAt.Valid || !pr.CreatedAt.Time.Before(arg.CreatedTo.Time)) {
+			continue
+		}
+		if arg.NameQuery.Valid && !matchILike(pr.PullRequestName, "%"+arg.NameQuery.String+"%") {
+			continue
+		}
+		// Keyset pagination on (created_at, pull_request_id)
+		if arg.CursorCreatedAt.Valid {
+			if !pr.CreatedAt.Valid || !createdBefore(pr, arg.CursorCreatedAt, arg.CursorID.String) {
+				continue
+			}
+		}
+		items = append(items, pr)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return createdBefore(items[j], items[i].CreatedAt, items[i].PullRequestID)
+	})
+	return limit(items, arg.PageLimit), nil
+}
+
+// createdBefore compares (created_at, pull_request_id) of pr with the given
+// pair like the SQL row comparison does
+func createdBefore(pr database.PullRequest, createdAt sql.NullTime, pullRequestID string) bool {
+	if !pr.CreatedAt.Time.Equal(createdAt.Time) {
+		return pr.CreatedAt.Time.Before(createdAt.Time)
+	}
+	return pr.PullRequestID < pullRequestID
+}
+
+// matchILike reports whether s matches the ILIKE pattern with the default \ escape
+// % matches any run of characters, _ a single one and \ makes the next one literal
+func matchILike(s, pattern string) bool {
+	var expr strings.Builder
+	expr.WriteString("(?is)^")
+	escaped := false
+	for _, c := range pattern {
+		switch {
+		case escaped:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '%':
+			expr.WriteString(".*")
+		case c == '_':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.MustCompile(expr.String()).MatchString(s)
+}
+
+// activeTeamMembers returns active members of a team except one user, ordered by user_id
+func activeTeamMembers(data *state, teamName sql.NullString, excludeUserID string) []string {
+	ids := []string{}
+	if !teamName.Valid {
+		return ids
+	}
+	for _, user := range data.users {
+		if user.TeamName.Valid && user.TeamName.String == teamName.String && user.IsActive && user.UserID != excludeUserID {
+			ids = append(ids, user.UserID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// openReviews counts the OPEN pull requests a user is assigned to
+func openReviews(data *state, userID string) int64 {
+	var count int64
+	for prID, reviewers := range data.reviewers {
+		if _, ok := reviewers[userID]; ok && data.pullRequests[prID].Status == database.PrStatusOPEN {
+			count++
+		}
+	}
+	return count
+}
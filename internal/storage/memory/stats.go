@@ -0,0 +1,261 @@
+package memory
+
+import (
+	"GODanilich/avito_backend/internal/database"
+	"context"
+	"database/sql"
+	"math"
+	"sort"
+	"time"
+)
+
+func (q *queries) GetPRStats(ctx context.Context) ([]database.GetPRStatsRow, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	counts := map[database.PrStatus]int64{}
+	for _, pr := range data.pullRequests {
+		counts[pr.Status]++
+	}
+	items := []database.GetPRStatsRow{}
+	for status, count := range counts {
+		items = append(items, database.GetPRStatsRow{Status: status, Count: count})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Status < items[j].Status })
+	return items, nil
+}
+
+func (q *queries) GetAssignmentStats(ctx context.Context) ([]database.GetAssignmentStatsRow, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	counts := map[string]int64{}
+	for _, reviewers := range data.reviewers {
+		for userID := range reviewers {
+			counts[userID]++
+		}
+	}
+	items := []database.GetAssignmentStatsRow{}
+	for userID, count := range counts {
+		items = append(items, database.GetAssignmentStatsRow{UserID: userID, Count: count})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].UserID < items[j].UserID })
+	return items, nil
+}
+
+func (q *queries) GetMergeTimeByTeam(ctx context.Context, arg database.GetMergeTimeByTeamParams) ([]database.GetMergeTimeByTeamRow, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	items := []database.GetMergeTimeByTeamRow{}
+	for _, g := range groupDurations(mergeTimes(data, arg.WindowStart, arg.WindowEnd), authorTeam(data)) {
+		p50, p90, p99 := percentiles(g.seconds)
+		items = append(items, database.GetMergeTimeByTeamRow{
+			TeamName:    g.key,
+			MergedCount: int64(len(g.seconds)),
+			P50Seconds:  p50,
+			P90Seconds:  p90,
+			P99Seconds:  p99,
+		})
+	}
+	return items, nil
+}
+
+func (q *queries) GetMergeTimeByAuthor(ctx context.Context, arg database.GetMergeTimeByAuthorParams) ([]database.GetMergeTimeByAuthorRow, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	items := []database.GetMergeTimeByAuthorRow{}
+	for _, g := range groupDurations(mergeTimes(data, arg.WindowStart, arg.WindowEnd), authorID) {
+		p50, p90, p99 := percentiles(g.seconds)
+		items = append(items, database.GetMergeTimeByAuthorRow{
+			AuthorID:    g.key.String,
+			MergedCount: int64(len(g.seconds)),
+			P50Seconds:  p50,
+			P90Seconds:  p90,
+			P99Seconds:  p99,
+		})
+	}
+	return items, nil
+}
+
+func (q *queries) GetFirstReviewTimeByTeam(ctx context.Context, arg database.GetFirstReviewTimeByTeamParams) ([]database.GetFirstReviewTimeByTeamRow, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	items := []database.GetFirstReviewTimeByTeamRow{}
+	for _, g := range groupDurations(firstReviewTimes(data, arg.WindowStart, arg.WindowEnd), authorTeam(data)) {
+		p50, p90, p99 := percentiles(g.seconds)
+		items = append(items, database.GetFirstReviewTimeByTeamRow{
+			TeamName:      g.key,
+			ReviewedCount: int64(len(g.seconds)),
+			P50Seconds:    p50,
+			P90Seconds:    p90,
+			P99Seconds:    p99,
+		})
+	}
+	return items, nil
+}
+
+func (q *queries) GetFirstReviewTimeByAuthor(ctx context.Context, arg database.GetFirstReviewTimeByAuthorParams) ([]database.GetFirstReviewTimeByAuthorRow, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	items := []database.GetFirstReviewTimeByAuthorRow{}
+	for _, g := range groupDurations(firstReviewTimes(data, arg.WindowStart, arg.WindowEnd), authorID) {
+		p50, p90, p99 := percentiles(g.seconds)
+		items = append(items, database.GetFirstReviewTimeByAuthorRow{
+			AuthorID:      g.key.String,
+			ReviewedCount: int64(len(g.seconds)),
+			P50Seconds:    p50,
+			P90Seconds:    p90,
+			P99Seconds:    p99,
+		})
+	}
+	return items, nil
+}
+
+func (q *queries) CountOpenPRs(ctx context.Context) (int64, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	var count int64
+	for _, pr := range data.pullRequests {
+		if pr.Status == database.PrStatusOPEN {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// CountUnderstaffedOpenPRs counts OPEN PRs with fewer reviewers than the
+// max_reviewers of the author's team
+func (q *queries) CountUnderstaffedOpenPRs(ctx context.Context, defaultMaxReviewers int32) (int64, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	var count int64
+	for _, pr := range data.pullRequests {
+		author, ok := data.users[pr.AuthorID]
+		if !ok || pr.Status != database.PrStatusOPEN {
+			continue
+		}
+		maxReviewers := defaultMaxReviewers
+		if settings, ok := data.teamSettings[author.TeamName.String]; ok && author.TeamName.Valid {
+			maxReviewers = settings.MaxReviewers
+		}
+		if len(data.reviewers[pr.PullRequestID]) < int(maxReviewers) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// prDuration is the time from a PR's creation to some later event
+type prDuration struct {
+	pr      database.PullRequest
+	seconds float64
+}
+
+// mergeTimes returns creation-to-merge durations of PRs merged in the window
+func mergeTimes(data *state, windowStart, windowEnd sql.NullTime) []prDuration {
+	items := []prDuration{}
+	for _, pr := range data.pullRequests {
+		if pr.Status != database.PrStatusMERGED || !pr.CreatedAt.Valid || !pr.MergedAt.Valid {
+			continue
+		}
+		if !inWindow(pr.MergedAt.Time, windowStart, windowEnd) {
+			continue
+		}
+		items = append(items, prDuration{pr: pr, seconds: pr.MergedAt.Time.Sub(pr.CreatedAt.Time).Seconds()})
+	}
+	return items
+}
+
+// firstReviewTimes returns creation-to-first-review durations of PRs whose
+// first verdict falls in the window
+func firstReviewTimes(data *state, windowStart, windowEnd sql.NullTime) []prDuration {
+	items := []prDuration{}
+	for _, pr := range data.pullRequests {
+		if !pr.CreatedAt.Valid || !pr.FirstReviewedAt.Valid || !inWindow(pr.FirstReviewedAt.Time, windowStart, windowEnd) {
+			continue
+		}
+		items = append(items, prDuration{pr: pr, seconds: pr.FirstReviewedAt.Time.Sub(pr.CreatedAt.Time).Seconds()})
+	}
+	return items
+}
+
+func inWindow(t time.Time, windowStart, windowEnd sql.NullTime) bool {
+	if windowStart.Valid && t.Before(windowStart.Time) {
+		return false
+	}
+	return !windowEnd.Valid || t.Before(windowEnd.Time)
+}
+
+// durationGroup is one GROUP BY bucket of durations
+type durationGroup struct {
+	key     sql.NullString
+	seconds []float64
+}
+
+// authorTeam groups by the team of the PR author
+// PRs whose author is missing are dropped, like the inner join on users
+func authorTeam(data *state) func(pr database.PullRequest) (sql.NullString, bool) {
+	return func(pr database.PullRequest) (sql.NullString, bool) {
+		author, ok := data.users[pr.AuthorID]
+		return author.TeamName, ok
+	}
+}
+
+// authorID groups by the PR author
+func authorID(pr database.PullRequest) (sql.NullString, bool) {
+	return sql.NullString{String: pr.AuthorID, Valid: true}, true
+}
+
+// groupDurations buckets durations by key, ordered by key with NULL last
+func groupDurations(items []prDuration, key func(pr database.PullRequest) (sql.NullString, bool)) []durationGroup {
+	groups := map[sql.NullString]*durationGroup{}
+	for _, item := range items {
+		k, ok := key(item.pr)
+		if !ok {
+			continue
+		}
+		if groups[k] == nil {
+			groups[k] = &durationGroup{key: k}
+		}
+		groups[k].seconds = append(groups[k].seconds, item.seconds)
+	}
+
+	result := make([]durationGroup, 0, len(groups))
+	for _, g := range groups {
+		result = append(result, *g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i].key, result[j].key
+		if a.Valid != b.Valid {
+			return a.Valid
+		}
+		return a.String < b.String
+	})
+	return result
+}
+
+// percentiles returns p50, p90 and p99 of values like percentile_cont
+func percentiles(values []float64) (float64, float64, float64) {
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	return percentileCont(sorted, 0.5), percentileCont(sorted, 0.9), percentileCont(sorted, 0.99)
+}
+
+// percentileCont interpolates linearly between the closest ranks of sorted values
+func percentileCont(sorted []float64, fraction float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	pos := fraction * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	if lower+1 >= len(sorted) {
+		return sorted[lower]
+	}
+	return sorted[lower] + (pos-float64(lower))*(sorted[lower+1]-sorted[lower])
+}
@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"GODanilich/avito_backend/internal/database"
+	"context"
+	"database/sql"
+)
+
+// Column defaults of team_settings
+const (
+	defaultMinReviewers = 0
+	defaultMaxReviewers = 2
+)
+
+func (q *queries) GetTeamSettings(ctx context.Context, teamName string) (database.TeamSetting, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	settings, ok := data.teamSettings[teamName]
+	if !ok {
+		return database.TeamSetting{}, sql.ErrNoRows
+	}
+	return settings, nil
+}
+
+func (q *queries) UpsertTeamSettings(ctx context.Context, arg database.UpsertTeamSettingsParams) (database.TeamSetting, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	// The round-robin cursor survives the update
+	settings := data.teamSettings[arg.TeamName]
+	settings.TeamName = arg.TeamName
+	settings.MinReviewers = arg.MinReviewers
+	settings.MaxReviewers = arg.MaxReviewers
+	settings.Strategy = arg.Strategy
+	settings.RequiredApprovals = arg.RequiredApprovals
+
+	if err := putTeamSettings(data, settings); err != nil {
+		return database.TeamSetting{}, err
+	}
+	return settings, nil
+}
+
+func (q *queries) SetRoundRobinCursor(ctx context.Context, arg database.SetRoundRobinCursorParams) error {
+	data, unlock := q.lock()
+	defer unlock()
+
+	settings, ok := data.teamSettings[arg.TeamName]
+	if !ok {
+		settings = database.TeamSetting{
+			TeamName:     arg.TeamName,
+			MinReviewers: defaultMinReviewers,
+			MaxReviewers: defaultMaxReviewers,
+			Strategy:     database.ReviewerStrategyRoundRobin,
+		}
+	}
+	settings.RoundRobinCursor = arg.RoundRobinCursor
+	return putTeamSettings(data, settings)
+}
+
+// putTeamSettings stores a row after checking the table constraints
+func putTeamSettings(data *state, settings database.TeamSetting) error {
+	if _, ok := data.teams[settings.TeamName]; !ok {
+		return foreignKeyViolation("team_settings", "team_settings_team_name_fkey")
+	}
+	if settings.MinReviewers < 0 {
+		return checkViolation("team_settings", "team_settings_min_reviewers_check")
+	}
+	if settings.MaxReviewers < settings.MinReviewers {
+		return checkViolation("team_settings", "team_settings_check")
+	}
+	if settings.RequiredApprovals < 0 {
+		return checkViolation("team_settings", "team_settings_required_approvals_check")
+	}
+	data.teamSettings[settings.TeamName] = settings
+	return nil
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"GODanilich/avito_backend/internal/database"
+	"context"
+	"database/sql"
+	"sort"
+)
+
+func (q *queries) GetTeamMembers(ctx context.Context, teamName sql.NullString) ([]database.User, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	items := []database.User{}
+	for _, user := range data.users {
+		if teamName.Valid && user.TeamName.Valid && user.TeamName.String == teamName.String {
+			items = append(items, user)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].UserID < items[j].UserID })
+	return items, nil
+}
+
+func (q *queries) CreateTeam(ctx context.Context, teamName string) error {
+	data, unlock := q.lock()
+	defer unlock()
+
+	if _, ok := data.teams[teamName]; ok {
+		return uniqueViolation("teams", "teams_pkey")
+	}
+	data.teams[teamName] = database.Team{TeamName: teamName}
+	return nil
+}
+
+func (q *queries) ListTeams(ctx context.Context) ([]string, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	items := make([]string, 0, len(data.teams))
+	for teamName := range data.teams {
+		items = append(items, teamName)
+	}
+	sort.Strings(items)
+	return items, nil
+}
+
+func (q *queries) GetTeam(ctx context.Context, teamName string) (string, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	team, ok := data.teams[teamName]
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return team.TeamName, nil
+}
@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"GODanilich/avito_backend/internal/database"
+	"context"
+	"database/sql"
+	"slices"
+	"sort"
+)
+
+// UpsertUser inserts the user or overwrites every column of the existing row,
+// like ON CONFLICT (user_id) DO UPDATE
+func (q *queries) UpsertUser(ctx context.Context, arg database.UpsertUserParams) error {
+	data, unlock := q.lock()
+	defer unlock()
+
+	if err := checkUserTeam(data, arg.TeamName); err != nil {
+		return err
+	}
+	data.users[arg.UserID] = database.User{
+		UserID:   arg.UserID,
+		Username: arg.Username,
+		TeamName: arg.TeamName,
+		IsActive: arg.IsActive,
+	}
+	return nil
+}
+
+func (q *queries) GetUserById(ctx context.Context, userID string) (database.User, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	user, ok := data.users[userID]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+func (q *queries) ListUsers(ctx context.Context) ([]database.User, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	items := make([]database.User, 0, len(data.users))
+	for _, user := range data.users {
+		items = append(items, user)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].UserID < items[j].UserID })
+	return items, nil
+}
+
+func (q *queries) SetUserActive(ctx context.Context, arg database.SetUserActiveParams) (database.User, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	user, ok := data.users[arg.UserID]
+	if !ok {
+		return database.User{}, sql.ErrNoRows
+	}
+	user.IsActive = arg.IsActive
+	data.users[arg.UserID] = user
+	return user, nil
+}
+
+func (q *queries) DeactivateUsers(ctx context.Context, userIds []string) ([]database.User, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	items := []database.User{}
+	for id, user := range data.users {
+		if !slices.Contains(userIds, id) {
+			continue
+		}
+		user.IsActive = false
+		data.users[id] = user
+		items = append(items, user)
+	}
+	// UPDATE ... RETURNING has no defined order, keep it stable
+	sort.Slice(items, func(i, j int) bool { return items[i].UserID < items[j].UserID })
+	return items, nil
+}
+
+// checkUserTeam enforces users.team_name REFERENCES teams
+func checkUserTeam(data *state, teamName sql.NullString) error {
+	if !teamName.Valid {
+		return nil
+	}
+	if _, ok := data.teams[teamName.String]; !ok {
+		return foreignKeyViolation("users", "users_team_name_fkey")
+	}
+	return nil
+}
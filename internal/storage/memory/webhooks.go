@@ -0,0 +1,228 @@
+package memory
+
+import (
+	"GODanilich/avito_backend/internal/database"
+	"context"
+	"database/sql"
+	"slices"
+	"sort"
+	"time"
+)
+
+func (q *queries) CreateWebhookSubscription(ctx context.Context, arg database.CreateWebhookSubscriptionParams) (database.WebhookSubscription, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	q.store.seq.webhookSubscriptions++
+	sub := database.WebhookSubscription{
+		ID:         q.store.seq.webhookSubscriptions,
+		Url:        arg.Url,
+		Secret:     arg.Secret,
+		EventTypes: cloneStrings(arg.EventTypes),
+		IsActive:   true,
+		CreatedAt:  now(),
+	}
+	data.webhookSubscriptions[sub.ID] = sub
+	return sub, nil
+}
+
+func (q *queries) GetWebhookSubscription(ctx context.Context, id int64) (database.WebhookSubscription, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	sub, ok := data.webhookSubscriptions[id]
+	if !ok {
+		return database.WebhookSubscription{}, sql.ErrNoRows
+	}
+	return sub, nil
+}
+
+func (q *queries) ListWebhookSubscriptions(ctx context.Context) ([]database.WebhookSubscription, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	items := []database.WebhookSubscription{}
+	for _, sub := range data.webhookSubscriptions {
+		items = append(items, sub)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items, nil
+}
+
+func (q *queries) UpdateWebhookSubscription(ctx context.Context, arg database.UpdateWebhookSubscriptionParams) (database.WebhookSubscription, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	sub, ok := data.webhookSubscriptions[arg.ID]
+	if !ok {
+		return database.WebhookSubscription{}, sql.ErrNoRows
+	}
+	sub.Url = arg.Url
+	sub.EventTypes = cloneStrings(arg.EventTypes)
+	sub.IsActive = arg.IsActive
+	data.webhookSubscriptions[arg.ID] = sub
+	return sub, nil
+}
+
+// DeleteWebhookSubscription removes the subscription together with its
+// deliveries, like ON DELETE CASCADE on outbox
+func (q *queries) DeleteWebhookSubscription(ctx context.Context, id int64) (database.WebhookSubscription, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	sub, ok := data.webhookSubscriptions[id]
+	if !ok {
+		return database.WebhookSubscription{}, sql.ErrNoRows
+	}
+	delete(data.webhookSubscriptions, id)
+	for outboxID, delivery := range data.outbox {
+		if delivery.SubscriptionID == id {
+			delete(data.outbox, outboxID)
+		}
+	}
+	return sub, nil
+}
+
+// EnqueueOutboxEvent adds a delivery for every active subscription
+// listening to the event type, an empty list means all types
+func (q *queries) EnqueueOutboxEvent(ctx context.Context, arg database.EnqueueOutboxEventParams) (int64, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	subs := []int64{}
+	for id, sub := range data.webhookSubscriptions {
+		if sub.IsActive && (len(sub.EventTypes) == 0 || slices.Contains(sub.EventTypes, arg.EventType)) {
+			subs = append(subs, id)
+		}
+	}
+	slices.Sort(subs)
+
+	createdAt := now()
+	for _, subID := range subs {
+		q.store.seq.outbox++
+		data.outbox[q.store.seq.outbox] = database.Outbox{
+			ID:             q.store.seq.outbox,
+			SubscriptionID: subID,
+			EventID:        arg.EventID,
+			EventType:      arg.EventType,
+			Payload:        jsonValue(arg.Payload),
+			Status:         database.OutboxStatusPENDING,
+			NextAttemptAt:  createdAt,
+			CreatedAt:      createdAt,
+		}
+	}
+	return int64(len(subs)), nil
+}
+
+// ClaimOutboxBatch hides the due deliveries for the lease and returns them
+func (q *queries) ClaimOutboxBatch(ctx context.Context, arg database.ClaimOutboxBatchParams) ([]database.ClaimOutboxBatchRow, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	claimedAt := now()
+	due := []database.Outbox{}
+	for _, delivery := range data.outbox {
+		// Deliveries of paused subscriptions wait until they are resumed
+		if !data.webhookSubscriptions[delivery.SubscriptionID].IsActive {
+			continue
+		}
+		if delivery.Status == database.OutboxStatusPENDING && !delivery.NextAttemptAt.After(claimedAt) {
+			due = append(due, delivery)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].NextAttemptAt.Equal(due[j].NextAttemptAt) {
+			return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+		}
+		return due[i].ID < due[j].ID
+	})
+	due = limit(due, arg.BatchSize)
+
+	items := make([]database.ClaimOutboxBatchRow, len(due))
+	for i, delivery := range due {
+		delivery.NextAttemptAt = claimedAt.Add(time.Duration(arg.LeaseSeconds) * time.Second)
+		data.outbox[delivery.ID] = delivery
+
+		sub := data.webhookSubscriptions[delivery.SubscriptionID]
+		items[i] = database.ClaimOutboxBatchRow{
+			ID:        delivery.ID,
+			EventID:   delivery.EventID,
+			EventType: delivery.EventType,
+			Payload:   delivery.Payload,
+			Attempts:  delivery.Attempts,
+			Url:       sub.Url,
+			Secret:    sub.Secret,
+		}
+	}
+	return items, nil
+}
+
+func (q *queries) MarkOutboxDelivered(ctx context.Context, id int64) error {
+	data, unlock := q.lock()
+	defer unlock()
+
+	delivery, ok := data.outbox[id]
+	if !ok {
+		return nil
+	}
+	delivery.Status = database.OutboxStatusDELIVERED
+	delivery.Attempts++
+	delivery.DeliveredAt = sql.NullTime{Time: now(), Valid: true}
+	delivery.LastError = sql.NullString{}
+	data.outbox[id] = delivery
+	return nil
+}
+
+func (q *queries) MarkOutboxFailed(ctx context.Context, arg database.MarkOutboxFailedParams) error {
+	data, unlock := q.lock()
+	defer unlock()
+
+	delivery, ok := data.outbox[arg.ID]
+	if !ok {
+		return nil
+	}
+	delivery.Status = arg.Status
+	delivery.Attempts++
+	delivery.NextAttemptAt = arg.NextAttemptAt
+	delivery.LastError = arg.LastError
+	data.outbox[arg.ID] = delivery
+	return nil
+}
+
+func (q *queries) ListOutboxDeliveries(ctx context.Context, arg database.ListOutboxDeliveriesParams) ([]database.Outbox, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	items := []database.Outbox{}
+	for _, delivery := range data.outbox {
+		if arg.SubscriptionID.Valid && delivery.SubscriptionID != arg.SubscriptionID.Int64 {
+			continue
+		}
+		if arg.Status.Valid && delivery.Status != arg.Status.OutboxStatus {
+			continue
+		}
+		if arg.CursorID.Valid && delivery.ID >= arg.CursorID.Int64 {
+			continue
+		}
+		items = append(items, delivery)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID > items[j].ID })
+	return limit(items, arg.PageLimit), nil
+}
+
+// RetryOutboxDelivery resets a DEAD delivery so it is sent again
+func (q *queries) RetryOutboxDelivery(ctx context.Context, id int64) (database.Outbox, error) {
+	data, unlock := q.lock()
+	defer unlock()
+
+	delivery, ok := data.outbox[id]
+	if !ok || delivery.Status != database.OutboxStatusDEAD {
+		return database.Outbox{}, sql.ErrNoRows
+	}
+	delivery.Status = database.OutboxStatusPENDING
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = now()
+	delivery.LastError = sql.NullString{}
+	data.outbox[id] = delivery
+	return delivery, nil
+}
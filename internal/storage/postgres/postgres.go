@@ -0,0 +1,79 @@
+// Package postgres implements storage.Store on top of the sqlc queries
+package postgres
+
+import (
+	"GODanilich/avito_backend/internal/database"
+	"GODanilich/avito_backend/internal/storage"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// Store is a storage.Store backed by PostgreSQL
+type Store struct {
+	*database.Queries
+	conn       *sql.DB
+	instrument func(database.DBTX) database.DBTX // Wraps connections and transactions, e.g. with metrics
+}
+
+var _ storage.Store = (*Store)(nil)
+
+// New creates a store on conn
+// instrument wraps conn and every transaction before queries run on them, it may be nil
+func New(conn *sql.DB, instrument func(database.DBTX) database.DBTX) *Store {
+	if instrument == nil {
+		instrument = func(db database.DBTX) database.DBTX { return db }
+	}
+	return &Store{
+		Queries:    database.New(instrument(conn)),
+		conn:       conn,
+		instrument: instrument,
+	}
+}
+
+// BeginTx starts a transaction, its queries go through the same instrumentation
+// It runs at the default READ COMMITTED level, callers lock the rows they change
+func (s *Store) BeginTx(ctx context.Context) (storage.Tx, error) {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Queries: database.New(s.instrument(tx)), tx: tx}, nil
+}
+
+func (s *Store) Ping(ctx context.Context) error {
+	return s.conn.PingContext(ctx)
+}
+
+// sqlStateUndefinedTable is reported when goose has never run on the database
+const sqlStateUndefinedTable = "42P01"
+
+// AppliedSchemaVersion reads the version table goose maintains
+// goose deletes the row of a migration when it is rolled back, so the
+// highest applied version is the current one
+func (s *Store) AppliedSchemaVersion(ctx context.Context) (int64, error) {
+	var version int64
+	err := s.conn.QueryRowContext(ctx,
+		"SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied").Scan(&version)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == sqlStateUndefinedTable {
+		return 0, nil
+	}
+	return version, err
+}
+
+// Tx is a storage.Tx wrapping a database transaction
+type Tx struct {
+	*database.Queries
+	tx *sql.Tx
+}
+
+func (t *Tx) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t *Tx) Rollback() error {
+	return t.tx.Rollback()
+}
@@ -0,0 +1,461 @@
+package storage
+
+import (
+	"GODanilich/avito_backend/internal/database"
+	"GODanilich/avito_backend/internal/service"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Repository is a service.Repository on top of a Store
+type Repository struct {
+	q     database.Querier
+	store Store // nil for repositories bound to a transaction
+}
+
+// NewRepository creates a repository running its queries on store
+func NewRepository(store Store) *Repository {
+	return &Repository{q: store, store: store}
+}
+
+// NewTxRepository creates a repository bound to tx, for callers that run
+// their own transaction. Its InTx runs in tx and never retries
+func NewTxRepository(tx Tx) *Repository {
+	return &Repository{q: tx}
+}
+
+// maxTxAttempts bounds how often InTx runs a transaction that PostgreSQL
+// aborted because of a deadlock with a concurrent one
+const maxTxAttempts = 3
+
+// SQLSTATE codes the repository handles
+const (
+	sqlStateUniqueViolation  = "23505"
+	sqlStateDeadlockDetected = "40P01"
+)
+
+// InTx runs fn in a transaction, repositories already bound to one reuse it
+// Transactions run at READ COMMITTED and order concurrent changes of a PR
+// with FOR UPDATE row locks, the ones PostgreSQL aborts as deadlocked are retried
+func (r *Repository) InTx(ctx context.Context, fn func(service.Repository) error) error {
+	if r.store == nil {
+		return fn(r)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := r.runTx(ctx, fn)
+		if attempt == maxTxAttempts || !isRetryable(err) {
+			return err
+		}
+
+		// Give the conflicting transaction a moment to finish
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt) * 10 * time.Millisecond):
+		}
+	}
+}
+
+func (r *Repository) runTx(ctx context.Context, fn func(service.Repository) error) error {
+	tx, err := r.store.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot begin tx: %w", err)
+	}
+	defer tx.Rollback() // Ensure rollback if fn fails
+
+	if err := fn(&Repository{q: tx}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// sqlState returns the SQLSTATE of a PostgreSQL error, empty for other errors
+func sqlState(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code)
+	}
+	return ""
+}
+
+// isRetryable reports whether running the transaction again may succeed
+func isRetryable(err error) bool {
+	return sqlState(err) == sqlStateDeadlockDetected
+}
+
+// notFound converts sql.ErrNoRows to service.ErrNotFound
+func notFound(err error) error {
+	if err == sql.ErrNoRows {
+		return service.ErrNotFound
+	}
+	return err
+}
+
+func (r *Repository) TeamExists(ctx context.Context, teamName string) (bool, error) {
+	_, err := r.q.GetTeam(ctx, teamName)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *Repository) CreateTeam(ctx context.Context, teamName string) error {
+	return r.q.CreateTeam(ctx, teamName)
+}
+
+func (r *Repository) ListTeams(ctx context.Context) ([]string, error) {
+	return r.q.ListTeams(ctx)
+}
+
+func (r *Repository) GetTeamPolicy(ctx context.Context, teamName string) (service.TeamPolicy, error) {
+	settings, err := r.q.GetTeamSettings(ctx, teamName)
+	if err != nil {
+		return service.TeamPolicy{}, notFound(err)
+	}
+	return service.TeamPolicy{
+		TeamName:          settings.TeamName,
+		MinReviewers:      int(settings.MinReviewers),
+		MaxReviewers:      int(settings.MaxReviewers),
+		Strategy:          service.ReviewerStrategy(settings.Strategy),
+		RoundRobinCursor:  settings.RoundRobinCursor.String,
+		RequiredApprovals: int(settings.RequiredApprovals),
+	}, nil
+}
+
+func (r *Repository) UpsertTeamSettings(ctx context.Context, settings service.TeamSettings) (service.TeamSettings, error) {
+	stored, err := r.q.UpsertTeamSettings(ctx, database.UpsertTeamSettingsParams{
+		TeamName:          settings.TeamName,
+		MinReviewers:      int32(settings.MinReviewers),
+		MaxReviewers:      int32(settings.MaxReviewers),
+		Strategy:          database.ReviewerStrategy(settings.Strategy),
+		RequiredApprovals: int32(settings.RequiredApprovals),
+	})
+	if err != nil {
+		return service.TeamSettings{}, err
+	}
+	return service.TeamSettings{
+		TeamName:          stored.TeamName,
+		MinReviewers:      int(stored.MinReviewers),
+		MaxReviewers:      int(stored.MaxReviewers),
+		Strategy:          service.ReviewerStrategy(stored.Strategy),
+		RequiredApprovals: int(stored.RequiredApprovals),
+	}, nil
+}
+
+func (r *Repository) SetRoundRobinCursor(ctx context.Context, teamName, userID string) error {
+	return r.q.SetRoundRobinCursor(ctx, database.SetRoundRobinCursorParams{
+		TeamName:         teamName,
+		RoundRobinCursor: nullString(userID),
+	})
+}
+
+func (r *Repository) GetUser(ctx context.Context, userID string) (service.User, error) {
+	user, err := r.q.GetUserById(ctx, userID)
+	if err != nil {
+		return service.User{}, notFound(err)
+	}
+	return toUser(user), nil
+}
+
+func (r *Repository) ListUsers(ctx context.Context) ([]service.User, error) {
+	rows, err := r.q.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	users := make([]service.User, len(rows))
+	for i, row := range rows {
+		users[i] = toUser(row)
+	}
+	return users, nil
+}
+
+func (r *Repository) UpsertUser(ctx context.Context, user service.User) error {
+	return r.q.UpsertUser(ctx, database.UpsertUserParams{
+		UserID:   user.UserID,
+		Username: user.Username,
+		TeamName: nullString(user.TeamName),
+		IsActive: user.IsActive,
+	})
+}
+
+func (r *Repository) SetUserActive(ctx context.Context, userID string, isActive bool) (service.User, error) {
+	user, err := r.q.SetUserActive(ctx, database.SetUserActiveParams{
+		UserID:   userID,
+		IsActive: isActive,
+	})
+	if err != nil {
+		return service.User{}, notFound(err)
+	}
+	return toUser(user), nil
+}
+
+func (r *Repository) DeactivateUsers(ctx context.Context, userIDs []string) ([]service.User, error) {
+	rows, err := r.q.DeactivateUsers(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	users := make([]service.User, len(rows))
+	for i, row := range rows {
+		users[i] = toUser(row)
+	}
+	return users, nil
+}
+
+func (r *Repository) GetPR(ctx context.Context, prID string) (service.PullRequest, error) {
+	pr, err := r.q.GetPR(ctx, prID)
+	if err != nil {
+		return service.PullRequest{}, notFound(err)
+	}
+	return r.withReviewers(ctx, pr)
+}
+
+func (r *Repository) LockPR(ctx context.Context, prID string) (service.PullRequest, error) {
+	pr, err := r.q.GetPRForUpdate(ctx, prID)
+	if err != nil {
+		return service.PullRequest{}, notFound(err)
+	}
+	return r.withReviewers(ctx, pr)
+}
+
+func (r *Repository) CreatePR(ctx context.Context, pr service.PullRequest) error {
+	err := r.q.CreatePR(ctx, database.CreatePRParams{
+		PullRequestID:   pr.PullRequestID,
+		PullRequestName: pr.PullRequestName,
+		AuthorID:        pr.AuthorID,
+		ProjectPath:     nullStringPtr(pr.ProjectPath),
+	})
+	if sqlState(err) == sqlStateUniqueViolation {
+		return service.ErrAlreadyExists
+	}
+	return err
+}
+
+func (r *Repository) SetPRMerged(ctx context.Context, prID string) (service.PullRequest, error) {
+	pr, err := r.q.SetPRMerged(ctx, prID)
+	if err != nil {
+		return service.PullRequest{}, notFound(err)
+	}
+	return r.withReviewers(ctx, pr)
+}
+
+func (r *Repository) SetPRClosed(ctx context.Context, prID string) (service.PullRequest, error) {
+	pr, err := r.q.SetPRClosed(ctx, prID)
+	if err != nil {
+		return service.PullRequest{}, notFound(err)
+	}
+	return r.withReviewers(ctx, pr)
+}
+
+func (r *Repository) SetPRReopened(ctx context.Context, prID string) (service.PullRequest, error) {
+	pr, err := r.q.SetPRReopened(ctx, prID)
+	if err != nil {
+		return service.PullRequest{}, notFound(err)
+	}
+	return r.withReviewers(ctx, pr)
+}
+
+func (r *Repository) RenamePR(ctx context.Context, prID, prName string) (service.PullRequest, error) {
+	pr, err := r.q.RenamePR(ctx, database.RenamePRParams{
+		PullRequestID:   prID,
+		PullRequestName: prName,
+	})
+	if err != nil {
+		return service.PullRequest{}, notFound(err)
+	}
+	return r.withReviewers(ctx, pr)
+}
+
+func (r *Repository) AddReviewer(ctx context.Context, prID, userID string) error {
+	return r.q.AddReviewer(ctx, database.AddReviewerParams{
+		PullRequestID: prID,
+		UserID:        userID,
+	})
+}
+
+func (r *Repository) DeleteReviewer(ctx context.Context, prID, userID string) error {
+	return r.q.DeleteReviewer(ctx, database.DeleteReviewerParams{
+		PullRequestID: prID,
+		UserID:        userID,
+	})
+}
+
+func (r *Repository) GetAssignedReviewers(ctx context.Context, prID string) ([]service.AssignedReviewer, error) {
+	rows, err := r.q.GetPRReviewerDetails(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+	reviewers := make([]service.AssignedReviewer, len(rows))
+	for i, row := range rows {
+		reviewers[i] = service.AssignedReviewer{
+			UserID:     row.UserID,
+			TeamName:   row.TeamName.String,
+			IsActive:   row.IsActive,
+			State:      service.ReviewState(row.State),
+			ReviewedAt: timePtr(row.ReviewedAt),
+		}
+	}
+	return reviewers, nil
+}
+
+func (r *Repository) SetReviewState(ctx context.Context, prID, userID string, state service.ReviewState) (service.Review, error) {
+	reviewer, err := r.q.SetReviewState(ctx, database.SetReviewStateParams{
+		PullRequestID: prID,
+		UserID:        userID,
+		State:         database.ReviewState(state),
+	})
+	if err != nil {
+		return service.Review{}, notFound(err)
+	}
+
+	// Time to first review is measured from the first verdict, which reviewer rows don't keep
+	if reviewer.State != database.ReviewStatePENDING {
+		if err := r.q.SetPRFirstReviewed(ctx, prID); err != nil {
+			return service.Review{}, err
+		}
+	}
+	return service.Review{
+		ReviewerID: reviewer.UserID,
+		State:      service.ReviewState(reviewer.State),
+		ReviewedAt: timePtr(reviewer.ReviewedAt),
+	}, nil
+}
+
+func (r *Repository) CountApprovals(ctx context.Context, prID string) (int, error) {
+	approvals, err := r.q.CountApprovals(ctx, prID)
+	return int(approvals), err
+}
+
+func (r *Repository) GetActiveReviewersForTeam(ctx context.Context, teamName, excludeUserID string) ([]service.ReviewerCandidate, error) {
+	rows, err := r.q.GetActiveReviewersForTeam(ctx, database.GetActiveReviewersForTeamParams{
+		TeamName: nullString(teamName),
+		UserID:   excludeUserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]service.ReviewerCandidate, len(rows))
+	for i, row := range rows {
+		candidates[i] = service.ReviewerCandidate{UserID: row.UserID, OpenReviews: row.OpenReviews}
+	}
+	return candidates, nil
+}
+
+func (r *Repository) GetEligibleReassignReviewers(ctx context.Context, teamName, excludeUserID, prID string) ([]service.ReviewerCandidate, error) {
+	rows, err := r.q.GetEligibleReassignReviewers(ctx, database.GetEligibleReassignReviewersParams{
+		TeamName:      nullString(teamName),
+		UserID:        excludeUserID,
+		PullRequestID: prID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]service.ReviewerCandidate, len(rows))
+	for i, row := range rows {
+		candidates[i] = service.ReviewerCandidate{UserID: row.UserID, OpenReviews: row.OpenReviews}
+	}
+	return candidates, nil
+}
+
+func (r *Repository) GetOpenAssignmentsForReviewers(ctx context.Context, userIDs []string) ([]service.Assignment, error) {
+	rows, err := r.q.GetOpenAssignmentsForReviewers(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	assignments := make([]service.Assignment, len(rows))
+	for i, row := range rows {
+		assignments[i] = service.Assignment{
+			PullRequestID:  row.PullRequestID,
+			UserID:         row.UserID,
+			AuthorTeamName: row.AuthorTeamName.String,
+		}
+	}
+	return assignments, nil
+}
+
+func (r *Repository) InsertAuditEvent(ctx context.Context, event service.AuditEvent) error {
+	return r.q.InsertAuditEvent(ctx, database.InsertAuditEventParams{
+		Actor:       event.Actor,
+		ActorUserID: nullString(event.ActorUserID),
+		Action:      event.Action,
+		EntityType:  event.EntityType,
+		EntityID:    event.EntityID,
+		Before:      event.Before,
+		After:       event.After,
+	})
+}
+
+func (r *Repository) EnqueueOutboxEvent(ctx context.Context, event service.OutboxEvent) error {
+	_, err := r.q.EnqueueOutboxEvent(ctx, database.EnqueueOutboxEventParams{
+		EventID:   event.EventID,
+		EventType: event.EventType,
+		Payload:   event.Payload,
+	})
+	return err
+}
+
+// withReviewers loads the reviewers of pr and converts it to the domain model
+func (r *Repository) withReviewers(ctx context.Context, pr database.PullRequest) (service.PullRequest, error) {
+	reviewers, err := r.q.GetPRReviewers(ctx, pr.PullRequestID)
+	if err != nil {
+		return service.PullRequest{}, err
+	}
+	return toPullRequest(pr, reviewers), nil
+}
+
+// toPullRequest converts a pull request row and its reviewer IDs to the domain model
+func toPullRequest(pr database.PullRequest, reviewers []string) service.PullRequest {
+	if reviewers == nil {
+		reviewers = []string{}
+	}
+	return service.PullRequest{
+		PullRequestID:     pr.PullRequestID,
+		PullRequestName:   pr.PullRequestName,
+		AuthorID:          pr.AuthorID,
+		Status:            service.PRStatus(pr.Status),
+		AssignedReviewers: reviewers,
+		CreatedAt:         timePtr(pr.CreatedAt),
+		MergedAt:          timePtr(pr.MergedAt),
+		ClosedAt:          timePtr(pr.ClosedAt),
+		ProjectPath:       stringPtr(pr.ProjectPath),
+	}
+}
+
+func toUser(user database.User) service.User {
+	return service.User{
+		UserID:   user.UserID,
+		Username: user.Username,
+		TeamName: user.TeamName.String,
+		IsActive: user.IsActive,
+	}
+}
+
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+func nullStringPtr(value *string) sql.NullString {
+	if value == nil {
+		return sql.NullString{}
+	}
+	return nullString(*value)
+}
+
+func stringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,182 @@
+package storage_test
+
+import (
+	"GODanilich/avito_backend/internal/service"
+	"GODanilich/avito_backend/internal/storage"
+	"GODanilich/avito_backend/internal/storage/memory"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+// conflictingStore fails the first commits with a deadlock,
+// like PostgreSQL does when it aborts one of two transactions waiting on each other
+type conflictingStore struct {
+	*memory.Store
+	failures int
+}
+
+func (s *conflictingStore) BeginTx(ctx context.Context) (storage.Tx, error) {
+	tx, err := s.Store.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conflictingTx{Tx: tx, store: s}, nil
+}
+
+type conflictingTx struct {
+	storage.Tx
+	store *conflictingStore
+}
+
+func (t *conflictingTx) Commit() error {
+	if t.store.failures > 0 {
+		t.store.failures--
+		t.Tx.Rollback()
+		return &pq.Error{Code: "40P01", Message: "deadlock detected"}
+	}
+	return t.Tx.Commit()
+}
+
+func TestInTxRetries(t *testing.T) {
+	fnErr := errors.New("rejected")
+
+	tests := []struct {
+		name     string
+		failures int   // Commits failing with a deadlock
+		fnErr    error // Error returned by the transaction body
+		runs     int   // Expected runs of the transaction body
+		wantErr  bool
+		created  bool // Whether the team exists afterwards
+	}{
+		{name: "commits at once", runs: 1, created: true},
+		{name: "retries deadlocks", failures: 2, runs: 3, created: true},
+		{name: "gives up after the last attempt", failures: 3, runs: 3, wantErr: true},
+		{name: "does not retry other errors", fnErr: fnErr, runs: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &conflictingStore{Store: memory.New(), failures: tt.failures}
+			repo := storage.NewRepository(store)
+
+			runs := 0
+			err := repo.InTx(context.Background(), func(tx service.Repository) error {
+				runs++
+				if err := tx.CreateTeam(context.Background(), "backend"); err != nil {
+					return err
+				}
+				return tt.fnErr
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("InTx error = %v, want error: %v", err, tt.wantErr)
+			}
+			if tt.fnErr != nil && !errors.Is(err, tt.fnErr) {
+				t.Errorf("InTx error = %v, want %v", err, tt.fnErr)
+			}
+			if runs != tt.runs {
+				t.Errorf("transaction ran %d times, want %d", runs, tt.runs)
+			}
+
+			// Rolled back attempts leave nothing behind
+			exists, err := repo.TeamExists(context.Background(), "backend")
+			if err != nil {
+				t.Fatalf("TeamExists: %v", err)
+			}
+			if exists != tt.created {
+				t.Errorf("team exists = %v, want %v", exists, tt.created)
+			}
+		})
+	}
+}
+
+// Results built inside a transaction must not keep entries of the attempts that were retried
+func TestServiceResultsAfterRetry(t *testing.T) {
+	ctx := context.Background()
+	admin := service.Actor{Admin: true}
+	store := &conflictingStore{Store: memory.New()}
+	repo := storage.NewRepository(store)
+	reviews := service.New(repo, service.StrategyRandom)
+
+	if err := repo.CreateTeam(ctx, "backend"); err != nil {
+		t.Fatalf("CreateTeam: %v", err)
+	}
+	for _, userID := range []string{"u1", "u2", "u3"} {
+		if err := repo.UpsertUser(ctx, service.User{UserID: userID, Username: userID, TeamName: "backend", IsActive: true}); err != nil {
+			t.Fatalf("UpsertUser: %v", err)
+		}
+	}
+	for _, prID := range []string{"pr-1", "pr-2"} {
+		if _, err := reviews.CreatePR(ctx, admin, service.NewPR{PullRequestID: prID, PullRequestName: prID, AuthorID: "u1"}); err != nil {
+			t.Fatalf("CreatePR: %v", err)
+		}
+	}
+
+	// u3 stops reviewing pr-1 while it is closed
+	if _, err := reviews.ClosePR(ctx, admin, "pr-1"); err != nil {
+		t.Fatalf("ClosePR: %v", err)
+	}
+	if _, err := reviews.SetUserActive(ctx, admin, "u3", false); err != nil {
+		t.Fatalf("SetUserActive: %v", err)
+	}
+
+	store.failures = 1
+	reopened, err := reviews.ReopenPR(ctx, admin, "pr-1")
+	if err != nil {
+		t.Fatalf("ReopenPR: %v", err)
+	}
+	if len(reopened.RemovedReviewers) != 1 {
+		t.Errorf("removed reviewers = %v, want [u3]", reopened.RemovedReviewers)
+	}
+
+	// u2 is the last free reviewer of both PRs, so neither gets a replacement
+	store.failures = 1
+	deactivated, err := reviews.DeactivateTeamUsers(ctx, admin, "backend", []string{"u2"})
+	if err != nil {
+		t.Fatalf("DeactivateTeamUsers: %v", err)
+	}
+	if len(deactivated.ShortOfReviewers) != 2 || len(deactivated.Reassignments) != 0 {
+		t.Errorf("short of reviewers = %v, reassignments = %v, want 2 and 0", deactivated.ShortOfReviewers, deactivated.Reassignments)
+	}
+}
+
+// unreachableStore can't start transactions
+type unreachableStore struct {
+	*memory.Store
+	err error
+}
+
+func (s *unreachableStore) BeginTx(ctx context.Context) (storage.Tx, error) {
+	return nil, s.err
+}
+
+func TestInTxKeepsBeginError(t *testing.T) {
+	cause := errors.New("connection refused")
+	repo := storage.NewRepository(&unreachableStore{Store: memory.New(), err: cause})
+
+	err := repo.InTx(context.Background(), func(service.Repository) error { return nil })
+	if !errors.Is(err, cause) {
+		t.Errorf("InTx error = %v, want it to wrap %v", err, cause)
+	}
+}
+
+func TestCreatePRReportsExistingID(t *testing.T) {
+	ctx := context.Background()
+	repo := storage.NewRepository(memory.New())
+	if err := repo.CreateTeam(ctx, "backend"); err != nil {
+		t.Fatalf("CreateTeam: %v", err)
+	}
+	if err := repo.UpsertUser(ctx, service.User{UserID: "u1", Username: "alice", TeamName: "backend", IsActive: true}); err != nil {
+		t.Fatalf("UpsertUser: %v", err)
+	}
+
+	pr := service.PullRequest{PullRequestID: "pr-1", PullRequestName: "x", AuthorID: "u1"}
+	if err := repo.CreatePR(ctx, pr); err != nil {
+		t.Fatalf("CreatePR: %v", err)
+	}
+	if err := repo.CreatePR(ctx, pr); err != service.ErrAlreadyExists {
+		t.Errorf("second CreatePR error = %v, want ErrAlreadyExists", err)
+	}
+}
@@ -0,0 +1,35 @@
+// Package storage defines the store the API runs its queries on and adapts it
+// to the service.Repository used by the review service
+package storage
+
+import (
+	"GODanilich/avito_backend/internal/database"
+	"context"
+)
+
+// SchemaVersion is the goose version of the latest migration in sql/schema,
+// the one the queries are written against. Bump it with every new migration
+const SchemaVersion int64 = 13
+
+// Store runs the sqlc queries, on PostgreSQL or in memory
+type Store interface {
+	database.Querier
+
+	// BeginTx starts a transaction
+	// Queries on the returned Tx see its own uncommitted changes
+	BeginTx(ctx context.Context) (Tx, error)
+
+	// Ping checks that the store accepts queries
+	Ping(ctx context.Context) error
+
+	// AppliedSchemaVersion returns the version of the latest applied migration, 0 when none is
+	AppliedSchemaVersion(ctx context.Context) (int64, error)
+}
+
+// Tx is a transaction started by Store.BeginTx
+// Rollback after Commit has no effect, so it can always be deferred
+type Tx interface {
+	database.Querier
+	Commit() error
+	Rollback() error
+}
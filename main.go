@@ -1,12 +1,20 @@
 package main
 
 import (
-	"GODanilich/avito_backend/internal/database"
+	"GODanilich/avito_backend/internal/service"
+	"GODanilich/avito_backend/internal/storage"
+	"GODanilich/avito_backend/internal/storage/memory"
+	"GODanilich/avito_backend/internal/storage/postgres"
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/cors"
@@ -17,41 +25,263 @@ import (
 
 // API config
 type apiConfig struct {
-	DB     *database.Queries
-	dbConn *sql.DB
+	DB                  storage.Store          // PostgreSQL, or memory with STORAGE=memory
+	reviews             *service.ReviewService // Team, user and PR lifecycle rules
+	metrics             *appMetrics
+	openAPI             *openAPIValidator        // openapi.yml contract checks, nil disables them
+	workers             map[string]*workerStatus // Background workers reported by the readiness probe
+	GitHubWebhookSecret string
+	GitLabWebhookToken  string
 }
 
+// version is the release the binary was built from, set with -ldflags "-X main.version=v1.2.3"
+var version = "dev"
+
+const usage = `Usage: main [command]
+
+Commands:
+  serve                     run the API server (default)
+  migrate up|down|status    apply, roll back the latest or list the embedded migrations
+  version                   print the binary and schema versions
+  team|user|pr|stats        admin commands, see below
+
+` + ctlUsage
+
 func main() {
 	// load environment variables from .env
 	godotenv.Load(".env")
 
-	// getting PORT from .env
-	portString := os.Getenv("PORT")
-	if portString == "" {
-		log.Fatal("PORT is not found in the environment")
+	// cancelled on SIGINT/SIGTERM, e.g. when Kubernetes stops the pod
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// without a command the server starts, as it did before commands existed
+	command, args := "serve", []string{}
+	if len(os.Args) > 1 {
+		command, args = os.Args[1], os.Args[2:]
 	}
 
+	switch command {
+	case "serve":
+		serve(ctx, stop)
+	case "migrate":
+		if err := checkMigrateArgs(args); err != nil {
+			log.Fatal(err)
+		}
+
+		conn, err := openDatabase(ctx)
+		if err != nil {
+			log.Fatal("Can`t connect to database:", err)
+		}
+		defer conn.Close()
+
+		if err := runMigrate(ctx, conn, args, os.Stdout); err != nil {
+			log.Fatal("Migration failed: ", err)
+		}
+	case "version":
+		fmt.Printf("avito_backend %s\nschema version %d\n", version, storage.SchemaVersion)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		if isCtlCommand(command) {
+			if err := runCtl(ctx, command, args, os.Stdout); err != nil {
+				fmt.Fprintln(os.Stderr, "Error:", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
+	}
+}
+
+// openDatabase connects to DB_URL with the pool settings from the environment
+// and waits until the database accepts connections
+func openDatabase(ctx context.Context) (*sql.DB, error) {
 	// getting DB_URL from .env
 	db_URL := os.Getenv("DB_URL")
 	if db_URL == "" {
-		log.Fatal("DB_URL is not found in the environment")
+		return nil, errors.New("DB_URL is not found in the environment")
+	}
+
+	// pool limits from the environment, the defaults suit a single replica
+	poolCFG, err := loadPoolConfig()
+	if err != nil {
+		return nil, fmt.Errorf("invalid DB pool config: %w", err)
 	}
 
-	// connecting to db
 	conn, err := sql.Open("postgres", db_URL)
 	if err != nil {
-		log.Fatal("Can`t connect to database:", err)
+		return nil, err
+	}
+	poolCFG.apply(conn)
+
+	// sql.Open does not connect, so waiting here until the database accepts connections
+	pingCtx, cancel := context.WithTimeout(ctx, poolCFG.ConnectTimeout)
+	defer cancel()
+	if err := waitForDatabase(pingCtx, conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// defaultReviewerStrategy returns the reviewer strategy for teams without settings,
+// REVIEWER_POLICY or load-aware by default
+func defaultReviewerStrategy() (service.ReviewerStrategy, error) {
+	value := os.Getenv("REVIEWER_POLICY")
+	if value == "" {
+		return service.StrategyLeastLoaded, nil
+	}
+	strategy, err := service.ParseReviewerStrategy(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid REVIEWER_POLICY: %w", err)
 	}
+	return strategy, nil
+}
 
-	defer conn.Close()
+// serve runs the API server until ctx is cancelled, then drains in-flight requests
+// stop restores the default signal handling, so a second signal kills the process
+func serve(ctx context.Context, stop context.CancelFunc) {
+	// getting PORT from .env
+	portString := os.Getenv("PORT")
+	if portString == "" {
+		log.Fatal("PORT is not found in the environment")
+	}
 
-	db := database.New(conn)
+	serverCFG, err := loadServerConfig()
+	if err != nil {
+		log.Fatal("Invalid server config:", err)
+	}
+
+	appMetrics := newAppMetrics()
+
+	// choosing the storage, the in-memory one needs no database and loses all data on exit
+	var db storage.Store
+	switch storageKind := os.Getenv("STORAGE"); storageKind {
+	case "memory":
+		log.Printf("STORAGE is memory, data is not persisted")
+		db = memory.New()
+	case "", "postgres":
+		// connecting to db
+		conn, err := openDatabase(ctx)
+		if err != nil {
+			log.Fatal("Can`t connect to database:", err)
+		}
+
+		defer conn.Close()
+
+		// every sqlc call goes through the instrumented connection
+		db = postgres.New(conn, appMetrics.instrumentDB)
+		appMetrics.registerPoolMetrics(conn)
+	default:
+		log.Fatalf("Unknown STORAGE %q, expected postgres or memory", storageKind)
+	}
+
+	// refusing to serve when queries expect tables the database doesn't have yet
+	if err := requireSchema(ctx, db); err != nil {
+		log.Fatal(err)
+	}
+
+	defaultStrategy, err := defaultReviewerStrategy()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dispatcher := newWebhookDispatcher(db, appMetrics)
 
 	apiCFG := apiConfig{
-		DB:     db,
-		dbConn: conn,
+		DB:                  db,
+		reviews:             service.New(storage.NewRepository(db), defaultStrategy),
+		metrics:             appMetrics,
+		workers:             map[string]*workerStatus{"webhook_dispatcher": dispatcher.status},
+		GitHubWebhookSecret: os.Getenv("GITHUB_WEBHOOK_SECRET"),
+		GitLabWebhookToken:  os.Getenv("GITLAB_WEBHOOK_TOKEN"),
+	}
+
+	// checking requests and responses against openapi.yml unless disabled
+	if os.Getenv("OPENAPI_VALIDATION") != "off" {
+		validator, err := newOpenAPIValidator(nil)
+		if err != nil {
+			log.Fatal("Can`t load OpenAPI spec:", err)
+		}
+		apiCFG.openAPI = validator
+	} else {
+		log.Printf("OPENAPI_VALIDATION is off, requests are not checked against openapi.yml")
+	}
+
+	// storing the bootstrap admin token, without it tokens have to be inserted manually
+	if adminToken := os.Getenv("ADMIN_TOKEN"); adminToken != "" {
+		if err := bootstrapAdminToken(ctx, db, adminToken); err != nil {
+			log.Fatal("Can`t store ADMIN_TOKEN:", err)
+		}
+	} else {
+		log.Printf("ADMIN_TOKEN is not set, only existing API tokens will be accepted")
+	}
+	if apiCFG.GitHubWebhookSecret == "" {
+		log.Printf("GITHUB_WEBHOOK_SECRET is not set, /webhooks/github is disabled")
+	}
+	if apiCFG.GitLabWebhookToken == "" {
+		log.Printf("GITLAB_WEBHOOK_TOKEN is not set, /webhooks/gitlab is disabled")
+	}
+
+	appMetrics.registerBusinessMetrics(&apiCFG)
+
+	// delivering outbox events to webhook subscribers in the background until shutdown
+	var workers sync.WaitGroup
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		dispatcher.Run(ctx)
+	}()
+
+	// configuring HTTP server
+	srv := &http.Server{
+		Handler:           apiCFG.routes(),
+		Addr:              ":" + portString,
+		ReadHeaderTimeout: serverCFG.ReadHeaderTimeout,
+		ReadTimeout:       serverCFG.ReadTimeout,
+		WriteTimeout:      serverCFG.WriteTimeout,
+		IdleTimeout:       serverCFG.IdleTimeout,
+	}
+
+	// starting the server
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("Server is starting on port %v", portString)
+		if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+		close(serverErr)
+	}()
+
+	select {
+	case err := <-serverErr:
+		log.Fatal(err)
+	case <-ctx.Done():
 	}
+	stop()
 
+	// draining in-flight requests, new connections are refused from now on
+	log.Printf("Shutting down, waiting up to %v for in-flight requests", serverCFG.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serverCFG.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server shutdown: %v", err)
+	}
+	workers.Wait()
+	log.Printf("Server stopped")
+}
+
+// routes builds the HTTP router with every API endpoint
+func (apiCFG *apiConfig) routes() http.Handler {
+	return apiCFG.routesWithAuth(apiCFG.authenticate)
+}
+
+// routesWithAuth builds the router with auth resolving the caller of every
+// protected route, the server always uses bearer token authentication
+func (apiCFG *apiConfig) routesWithAuth(auth func(http.Handler) http.Handler) http.Handler {
 	// routing conf
 	router := chi.NewRouter()
 
@@ -64,31 +294,75 @@ func main() {
 		MaxAge:           300,
 	}))
 
+	router.Use(apiCFG.metrics.middleware)
+
+	// Prometheus scrape endpoint
+	router.Handle("/metrics", apiCFG.metrics.handler())
+
 	v1Router := chi.NewRouter()
 
-	v1Router.Get("/health", apiCFG.handlerHealth)
-	v1Router.Post("/team/add", apiCFG.handlerAddTeam)
-	v1Router.Get("/team/get", apiCFG.handlerGetTeam)
-	v1Router.Post("/users/setIsActive", apiCFG.handlerSetIsActive)
-	v1Router.Post("/pullRequest/create", apiCFG.handlerCreatePR)
-	v1Router.Post("/pullRequest/merge", apiCFG.handlerMergePR)
-	v1Router.Post("/pullRequest/reassign", apiCFG.handlerReassignPR)
-	v1Router.Get("/users/getReview", apiCFG.handlerGetReview)
-	v1Router.Get("/stats/get", apiCFG.handlerGetStats)
+	if apiCFG.openAPI != nil {
+		v1Router.Use(apiCFG.openAPI.middleware)
+	}
 
-	router.Mount("/api/v1", v1Router)
+	// probes, liveness never touches the database
+	v1Router.Get("/health", apiCFG.handlerLiveness)
+	v1Router.Get("/health/live", apiCFG.handlerLiveness)
+	v1Router.Get("/health/ready", apiCFG.handlerReadiness)
 
-	// configuring HTTP server
-	srv := &http.Server{
-		Handler: router,
-		Addr:    ":" + portString,
+	// code host webhooks authenticate with a shared secret instead of a bearer token
+	if apiCFG.GitHubWebhookSecret != "" {
+		v1Router.Post("/webhooks/github", apiCFG.handlerGitHubWebhook)
 	}
-	log.Printf("Server is starting on port %v", portString)
-	// starting the server
-	err = srv.ListenAndServe()
-	if err != nil {
-		log.Fatal(err)
+	if apiCFG.GitLabWebhookToken != "" {
+		v1Router.Post("/webhooks/gitlab", apiCFG.handlerGitLabWebhook)
 	}
 
-	fmt.Println("PORT is:", portString)
+	// every other route requires a bearer token
+	v1Router.Group(func(r chi.Router) {
+		r.Use(auth)
+
+		r.Get("/team/get", apiCFG.handlerGetTeam)
+		r.Get("/team/settings/get", apiCFG.handlerGetTeamSettings)
+		r.Post("/pullRequest/create", apiCFG.handlerCreatePR)
+		r.Post("/pullRequest/merge", apiCFG.handlerMergePR)
+		r.Post("/pullRequest/reassign", apiCFG.handlerReassignPR)
+		r.Post("/pullRequest/close", apiCFG.handlerClosePR)
+		r.Post("/pullRequest/reopen", apiCFG.handlerReopenPR)
+		r.Post("/pullRequest/rename", apiCFG.handlerRenamePR)
+		r.Post("/pullRequest/review", apiCFG.handlerReviewPR)
+		r.Get("/pullRequest/list", apiCFG.handlerListPRs)
+		r.Get("/pullRequest/get", apiCFG.handlerGetPR)
+		r.Get("/users/getReview", apiCFG.handlerGetReview)
+		r.Get("/stats/get", apiCFG.handlerGetStats)
+
+		// team, user, token, webhook and account mapping management and the audit log are admin-only
+		r.Group(func(r chi.Router) {
+			r.Use(requireAdmin)
+
+			r.Post("/team/add", apiCFG.handlerAddTeam)
+			r.Post("/team/settings/set", apiCFG.handlerSetTeamSettings)
+			r.Post("/team/deactivateUsers", apiCFG.handlerDeactivateTeamUsers)
+			r.Post("/import", apiCFG.handlerImportOrg)
+			r.Get("/export", apiCFG.handlerExportOrg)
+			r.Post("/users/setIsActive", apiCFG.handlerSetIsActive)
+			r.Post("/auth/tokens/create", apiCFG.handlerCreateToken)
+			r.Post("/auth/tokens/revoke", apiCFG.handlerRevokeToken)
+			r.Get("/audit/list", apiCFG.handlerListAuditEvents)
+			r.Post("/webhooks/subscriptions/create", apiCFG.handlerCreateWebhook)
+			r.Get("/webhooks/subscriptions/list", apiCFG.handlerListWebhooks)
+			r.Get("/webhooks/subscriptions/get", apiCFG.handlerGetWebhook)
+			r.Post("/webhooks/subscriptions/update", apiCFG.handlerUpdateWebhook)
+			r.Post("/webhooks/subscriptions/delete", apiCFG.handlerDeleteWebhook)
+			r.Get("/webhooks/deliveries/list", apiCFG.handlerListWebhookDeliveries)
+			r.Post("/webhooks/deliveries/retry", apiCFG.handlerRetryWebhookDelivery)
+			r.Post("/externalAccounts/set", apiCFG.handlerSetExternalAccount)
+			r.Get("/externalAccounts/list", apiCFG.handlerListExternalAccounts)
+			r.Post("/externalAccounts/delete", apiCFG.handlerDeleteExternalAccount)
+		})
+	})
+
+	router.Mount("/api/v1", v1Router)
+
+	return router
 }
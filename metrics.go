@@ -0,0 +1,208 @@
+package main
+
+import (
+	"GODanilich/avito_backend/internal/database"
+	"GODanilich/avito_backend/internal/service"
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const metricsScrapeTimeout = 5 * time.Second // Upper bound for DB queries run while serving /metrics
+
+// latencyBuckets are latency buckets in seconds suitable for HTTP and DB calls
+var latencyBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// appMetrics holds the Prometheus collectors of the service
+type appMetrics struct {
+	registry     *prometheus.Registry
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	dbDuration   *prometheus.HistogramVec
+
+	webhookDeliveries *prometheus.CounterVec
+}
+
+func newAppMetrics() *appMetrics {
+	m := &appMetrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Total number of HTTP requests by method, route pattern and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by method and route pattern.",
+			Buckets: latencyBuckets,
+		}, []string{"method", "route"}),
+		dbDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Database call latency by sqlc query name.",
+			Buckets: latencyBuckets,
+		}, []string{"query"}),
+		webhookDeliveries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "webhook_delivery_attempts_total",
+			Help: "Webhook delivery attempts by result: delivered, retry or dead.",
+		}, []string{"result"}),
+	}
+	m.registry.MustRegister(m.httpRequests, m.httpDuration, m.dbDuration, m.webhookDeliveries)
+	return m
+}
+
+// handler serves the collected metrics
+func (m *appMetrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{ErrorLog: log.Default()})
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// middleware records request count and latency per chi route pattern
+// Using the pattern instead of the raw path keeps label cardinality bounded
+func (m *appMetrics) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		// The route pattern is only known after chi has routed the request
+		route := chi.RouteContext(r.Context()).RoutePattern()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		m.httpRequests.WithLabelValues(r.Method, route, strconv.Itoa(rec.status)).Inc()
+		m.httpDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// instrumentDB wraps a connection or transaction so that every sqlc call is timed
+func (m *appMetrics) instrumentDB(db database.DBTX) database.DBTX {
+	return &instrumentedDB{db: db, duration: m.dbDuration}
+}
+
+// instrumentedDB is a database.DBTX that observes call latency per query name
+// For QueryContext only the time until the first row is available is measured
+type instrumentedDB struct {
+	db       database.DBTX
+	duration *prometheus.HistogramVec
+}
+
+func (i *instrumentedDB) observe(query string, start time.Time) {
+	i.duration.WithLabelValues(queryName(query)).Observe(time.Since(start).Seconds())
+}
+
+func (i *instrumentedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	defer i.observe(query, time.Now())
+	return i.db.ExecContext(ctx, query, args...)
+}
+
+func (i *instrumentedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	defer i.observe(query, time.Now())
+	return i.db.PrepareContext(ctx, query)
+}
+
+func (i *instrumentedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	defer i.observe(query, time.Now())
+	return i.db.QueryContext(ctx, query, args...)
+}
+
+func (i *instrumentedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	defer i.observe(query, time.Now())
+	return i.db.QueryRowContext(ctx, query, args...)
+}
+
+// queryName extracts the sqlc query name from the "-- name: X :kind" header
+// that sqlc keeps at the start of every generated query
+func queryName(query string) string {
+	rest, ok := strings.CutPrefix(query, "-- name: ")
+	if !ok {
+		return "unknown"
+	}
+	name, _, _ := strings.Cut(rest, " ")
+	return name
+}
+
+// registerPoolMetrics exposes sql.DB connection pool statistics
+func (m *appMetrics) registerPoolMetrics(conn *sql.DB) {
+	gauge := func(name, help string, value func(sql.DBStats) float64) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: name, Help: help}, func() float64 { return value(conn.Stats()) })
+	}
+	counter := func(name, help string, value func(sql.DBStats) float64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{Name: name, Help: help}, func() float64 { return value(conn.Stats()) })
+	}
+
+	m.registry.MustRegister(
+		gauge("db_pool_max_open_connections", "Maximum number of open connections to the database.",
+			func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }),
+		gauge("db_pool_open_connections", "Number of established connections, both in use and idle.",
+			func(s sql.DBStats) float64 { return float64(s.OpenConnections) }),
+		gauge("db_pool_in_use_connections", "Number of connections currently in use.",
+			func(s sql.DBStats) float64 { return float64(s.InUse) }),
+		gauge("db_pool_idle_connections", "Number of idle connections.",
+			func(s sql.DBStats) float64 { return float64(s.Idle) }),
+		counter("db_pool_wait_count_total", "Total number of connections waited for.",
+			func(s sql.DBStats) float64 { return float64(s.WaitCount) }),
+		counter("db_pool_wait_duration_seconds_total", "Total time blocked waiting for a new connection.",
+			func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }),
+		counter("db_pool_max_idle_closed_total", "Total number of connections closed due to SetMaxIdleConns.",
+			func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }),
+		counter("db_pool_max_lifetime_closed_total", "Total number of connections closed due to SetConnMaxLifetime.",
+			func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }),
+	)
+}
+
+// registerBusinessMetrics exposes PR gauges that are computed on every scrape
+func (m *appMetrics) registerBusinessMetrics(api *apiConfig) {
+	m.registry.MustRegister(
+		newQueryGauge("pull_requests_open", "Number of pull requests in OPEN status.",
+			api.DB.CountOpenPRs),
+		newQueryGauge("pull_requests_understaffed", "Number of OPEN pull requests with fewer reviewers than the team's max_reviewers.",
+			func(ctx context.Context) (int64, error) {
+				return api.DB.CountUnderstaffedOpenPRs(ctx, service.DefaultMaxReviewers)
+			}),
+	)
+}
+
+// queryGauge is a gauge counted by a database query on every scrape
+// If the query fails the sample is omitted from that scrape instead of failing it
+type queryGauge struct {
+	desc  *prometheus.Desc
+	query func(ctx context.Context) (int64, error)
+}
+
+func newQueryGauge(name, help string, query func(ctx context.Context) (int64, error)) *queryGauge {
+	return &queryGauge{desc: prometheus.NewDesc(name, help, nil, nil), query: query}
+}
+
+func (g *queryGauge) Describe(ch chan<- *prometheus.Desc) {
+	ch <- g.desc
+}
+
+func (g *queryGauge) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), metricsScrapeTimeout)
+	defer cancel()
+	n, err := g.query(ctx)
+	if err != nil {
+		log.Printf("Failed to collect metric %s: %v", g.desc, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(g.desc, prometheus.GaugeValue, float64(n))
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"GODanilich/avito_backend/internal/storage/memory"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// failingCountStore is a memory store whose PR counts fail
+type failingCountStore struct {
+	*memory.Store
+}
+
+func (s *failingCountStore) CountOpenPRs(ctx context.Context) (int64, error) {
+	return 0, errors.New("connection refused")
+}
+
+func scrapeMetrics(t *testing.T, m *appMetrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /metrics: status = %d, body: %s", rec.Code, rec.Body)
+	}
+	return rec.Body.String()
+}
+
+func TestMetrics(t *testing.T) {
+	store := memory.New()
+	m := newAppMetrics()
+	api := &apiConfig{DB: store, metrics: m}
+	m.registerBusinessMetrics(api)
+	handler := api.routes()
+
+	doRequest(handler, http.MethodGet, "/api/v1/health", "", "")
+	doRequest(handler, http.MethodGet, "/api/v1/team/get?team_name=x", "", "")
+	m.webhookDeliveries.WithLabelValues("retry").Inc()
+
+	body := scrapeMetrics(t, m)
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="/api/v1/health",status="200"} 1`,
+		`http_requests_total{method="GET",route="/api/v1/team/get",status="401"} 1`,
+		`http_request_duration_seconds_bucket{method="GET",route="/api/v1/health",le="+Inf"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="/api/v1/health"} 1`,
+		`webhook_delivery_attempts_total{result="retry"} 1`,
+		"# TYPE pull_requests_open gauge\npull_requests_open 0",
+		"pull_requests_understaffed 0",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics lack %q:\n%s", want, body)
+		}
+	}
+}
+
+// A failing count query drops its sample, the rest of the scrape succeeds
+func TestMetricsSkipFailedQueries(t *testing.T) {
+	m := newAppMetrics()
+	m.registerBusinessMetrics(&apiConfig{DB: &failingCountStore{Store: memory.New()}, metrics: m})
+
+	body := scrapeMetrics(t, m)
+	if strings.Contains(body, "pull_requests_open ") {
+		t.Errorf("failed gauge exposed:\n%s", body)
+	}
+	if !strings.Contains(body, "pull_requests_understaffed 0") {
+		t.Errorf("other gauges missing:\n%s", body)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"GODanilich/avito_backend/internal/storage"
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/pressly/goose/v3"
+)
+
+// migrationFiles are the goose migrations the binary applies with `migrate up`
+//
+//go:embed sql/schema/*.sql
+var migrationFiles embed.FS
+
+// migrations returns the embedded migrations with the file names goose expects
+func migrations() fs.FS {
+	sub, err := fs.Sub(migrationFiles, "sql/schema")
+	if err != nil {
+		panic(err) // The directory is embedded, so it always exists
+	}
+	return sub
+}
+
+// requireSchema fails when the database lacks migrations the queries depend on
+// A newer schema is only logged, migrations are written to stay compatible with the previous release
+func requireSchema(ctx context.Context, db storage.Store) error {
+	applied, err := db.AppliedSchemaVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	switch {
+	case applied < storage.SchemaVersion:
+		return fmt.Errorf("database schema is at version %d, this binary needs %d: run `%s migrate up` first",
+			applied, storage.SchemaVersion, os.Args[0])
+	case applied > storage.SchemaVersion:
+		log.Printf("Database schema is at version %d, newer than %d this binary was built for", applied, storage.SchemaVersion)
+	}
+	return nil
+}
+
+// checkMigrateArgs rejects unknown migrate commands before connecting to the database
+func checkMigrateArgs(args []string) error {
+	if len(args) != 1 || !slices.Contains([]string{"up", "down", "status"}, args[0]) {
+		return fmt.Errorf("usage: migrate up|down|status, got %q", args)
+	}
+	return nil
+}
+
+// runMigrate runs `migrate up|down|status` against conn and writes the outcome to out
+func runMigrate(ctx context.Context, conn *sql.DB, args []string, out io.Writer) error {
+	if err := checkMigrateArgs(args); err != nil {
+		return err
+	}
+
+	provider, err := goose.NewProvider(goose.DialectPostgres, conn, migrations())
+	if err != nil {
+		return fmt.Errorf("load migrations: %w", err)
+	}
+
+	switch args[0] {
+	case "up":
+		// applying every pending migration, each in its own transaction
+		results, err := provider.Up(ctx)
+		for _, result := range results {
+			fmt.Fprintln(out, result)
+		}
+		if err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			fmt.Fprintln(out, "no pending migrations")
+		}
+	case "down":
+		// rolling back the latest applied migration only
+		result, err := provider.Down(ctx)
+		if result != nil {
+			fmt.Fprintln(out, result)
+		}
+		if err != nil {
+			return err
+		}
+	case "status":
+		statuses, err := provider.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			appliedAt := "-"
+			if status.State == goose.StateApplied {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(out, "%-8s %-25s %s\n", status.State, appliedAt, status.Source.Path)
+		}
+	}
+	return nil
+}
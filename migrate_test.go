@@ -0,0 +1,43 @@
+package main
+
+import (
+	"GODanilich/avito_backend/internal/storage"
+	"context"
+	"io"
+	"io/fs"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// storage.SchemaVersion has to follow the embedded migrations
+func TestSchemaVersionMatchesMigrations(t *testing.T) {
+	entries, err := fs.ReadDir(migrations(), ".")
+	if err != nil {
+		t.Fatalf("read embedded migrations: %v", err)
+	}
+	if len(entries) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+
+	var latest int64
+	for _, entry := range entries {
+		prefix, _, _ := strings.Cut(entry.Name(), "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			t.Fatalf("migration %s has no version prefix", entry.Name())
+		}
+		latest = max(latest, version)
+	}
+	if latest != storage.SchemaVersion {
+		t.Errorf("storage.SchemaVersion = %d, latest migration is %d", storage.SchemaVersion, latest)
+	}
+}
+
+func TestRunMigrateRejectsUnknownCommands(t *testing.T) {
+	for _, args := range [][]string{nil, {"sideways"}, {"up", "down"}} {
+		if err := runMigrate(context.Background(), nil, args, io.Discard); err == nil || !strings.Contains(err.Error(), "usage") {
+			t.Errorf("migrate %v: error = %v, want usage", args, err)
+		}
+	}
+}
@@ -1,28 +1,15 @@
 package main
 
-import "GODanilich/avito_backend/internal/database"
+import (
+	"GODanilich/avito_backend/internal/database"
+	"GODanilich/avito_backend/internal/service"
+	"database/sql"
+	"time"
+)
 
-type User struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	TeamName string `json:"team_name"`
-	IsActive bool   `json:"is_active"`
-}
-
-func dbUserToUser(dbUser database.User) User {
-	return User{
-		UserID:   dbUser.UserID,
-		Username: dbUser.Username,
-		TeamName: dbUser.TeamName.String,
-		IsActive: dbUser.IsActive,
-	}
-}
+type User = service.User
 
-type UserWithoutTeam struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	IsActive bool   `json:"is_active"`
-}
+type UserWithoutTeam = service.TeamMember
 
 func dbUserWithoutTeamToUser(dbUser database.User) UserWithoutTeam {
 	return UserWithoutTeam{
@@ -40,10 +27,11 @@ func dbUsersWithoutTeamToUsers(dbUsers []database.User) (users []UserWithoutTeam
 }
 
 type PRRow struct {
-	PullRequestID   string            `json:"pull_request_id"`
-	PullRequestName string            `json:"pull_request_name"`
-	AuthorID        string            `json:"author_id"`
-	Status          database.PrStatus `json:"status"`
+	PullRequestID   string               `json:"pull_request_id"`
+	PullRequestName string               `json:"pull_request_name"`
+	AuthorID        string               `json:"author_id"`
+	Status          database.PrStatus    `json:"status"`
+	ReviewState     database.ReviewState `json:"review_state"`
 }
 
 func dbPRRowToPRRow(dbPRRow database.GetPRsForReviewerRow) PRRow {
@@ -52,12 +40,83 @@ func dbPRRowToPRRow(dbPRRow database.GetPRsForReviewerRow) PRRow {
 		PullRequestName: dbPRRow.PullRequestName,
 		AuthorID:        dbPRRow.AuthorID,
 		Status:          dbPRRow.Status,
+		ReviewState:     dbPRRow.State,
 	}
 }
 
 func dbPRRowsToPRRows(dbPRRows []database.GetPRsForReviewerRow) (pRRows []PRRow) {
+	pRRows = make([]PRRow, 0, len(dbPRRows))
 	for _, dbPRRow := range dbPRRows {
 		pRRows = append(pRRows, dbPRRowToPRRow(dbPRRow))
 	}
 	return pRRows
 }
+
+// PullRequest is the full pull request representation from the OpenAPI spec
+type PullRequest = service.PullRequest
+
+func dbPRToPR(dbPR database.PullRequest, reviewers []string) PullRequest {
+	if reviewers == nil {
+		reviewers = []string{}
+	}
+	return PullRequest{
+		PullRequestID:     dbPR.PullRequestID,
+		PullRequestName:   dbPR.PullRequestName,
+		AuthorID:          dbPR.AuthorID,
+		Status:            service.PRStatus(dbPR.Status),
+		AssignedReviewers: reviewers,
+		CreatedAt:         nullTimeToPtr(dbPR.CreatedAt),
+		MergedAt:          nullTimeToPtr(dbPR.MergedAt),
+		ClosedAt:          nullTimeToPtr(dbPR.ClosedAt),
+		ProjectPath:       nullStringToPtr(dbPR.ProjectPath),
+	}
+}
+
+func nullTimeToPtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// Review is a reviewer's verdict on a pull request
+type Review = service.Review
+
+// ReviewerDetails is an assigned reviewer together with their user data and verdict
+type ReviewerDetails struct {
+	UserID      string               `json:"user_id"`
+	Username    string               `json:"username"`
+	TeamName    *string              `json:"team_name"`
+	IsActive    bool                 `json:"is_active"`
+	ReviewState database.ReviewState `json:"review_state"`
+	ReviewedAt  *time.Time           `json:"reviewedAt"`
+}
+
+func dbReviewerDetailsToReviewerDetails(dbRows []database.GetPRReviewerDetailsRow) []ReviewerDetails {
+	reviewers := make([]ReviewerDetails, len(dbRows))
+	for i, row := range dbRows {
+		reviewers[i] = ReviewerDetails{
+			UserID:      row.UserID,
+			Username:    row.Username,
+			TeamName:    nullStringToPtr(row.TeamName),
+			IsActive:    row.IsActive,
+			ReviewState: row.State,
+			ReviewedAt:  nullTimeToPtr(row.ReviewedAt),
+		}
+	}
+	return reviewers
+}
+
+// PullRequestDetails extends PullRequest with the author's team and reviewer details
+type PullRequestDetails struct {
+	PullRequest
+	AuthorTeam *string           `json:"author_team"`
+	Reviewers  []ReviewerDetails `json:"reviewers"`
+}
+
+func nullStringToPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+)
+
+// openAPISpec is the API contract served under /api/v1
+//
+//go:embed openapi.yml
+var openAPISpec []byte
+
+// openAPIValidator checks requests and responses of the v1 API against openapi.yml
+// Requests that don't match the spec are rejected before reaching the handlers,
+// responses that don't match it are reported to onResponseError
+type openAPIValidator struct {
+	router          routers.Router
+	onResponseError func(r *http.Request, err error) // The server logs mismatches, tests fail on them
+}
+
+// newOpenAPIValidator parses the embedded spec
+// A nil onResponseError logs response mismatches
+func newOpenAPIValidator(onResponseError func(r *http.Request, err error)) (*openAPIValidator, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(openAPISpec)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse openapi.yml: %w", err)
+	}
+	if err := doc.Validate(loader.Context); err != nil {
+		return nil, fmt.Errorf("invalid openapi.yml: %w", err)
+	}
+
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("cannot build openapi.yml router: %w", err)
+	}
+
+	if onResponseError == nil {
+		onResponseError = func(r *http.Request, err error) {
+			log.Printf("Response to %s %s doesn't match openapi.yml: %v", r.Method, r.URL.Path, err)
+		}
+	}
+	return &openAPIValidator{router: router, onResponseError: onResponseError}, nil
+}
+
+// openAPIOptions are shared by request and response validation
+var openAPIOptions = &openapi3filter.Options{
+	// Bearer tokens are checked by authenticate, the spec only documents them
+	AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	// Handlers tell omitted fields from defaults, so the body must reach them unchanged
+	SkipSettingDefaults: true,
+	// Every status a handler returns has to be documented
+	IncludeResponseStatus: true,
+}
+
+// middleware validates the request, then buffers and validates the response
+func (v *openAPIValidator) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, pathParams, err := v.router.FindRoute(r)
+		if err != nil {
+			// Unknown paths end up as 404 or 405, anything else is a route the spec misses
+			response := &bufferedResponse{header: w.Header()}
+			next.ServeHTTP(response, r)
+			if response.status != http.StatusNotFound && response.status != http.StatusMethodNotAllowed {
+				v.onResponseError(r, fmt.Errorf("route is not documented: %w", err))
+			}
+			response.flush(w)
+			return
+		}
+
+		// Handlers decode bodies as JSON unless the spec documents another media type
+		// (CSV imports), so clients that omit the Content-Type or send curl's form default keep working
+		if r.ContentLength != 0 && !documentsMediaType(route, r.Header.Get("Content-Type")) {
+			r = r.Clone(r.Context())
+			r.Header.Set("Content-Type", "application/json")
+		}
+
+		// Reject requests that don't match the spec
+		requestInput := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    openAPIOptions,
+		}
+		if err := openapi3filter.ValidateRequest(r.Context(), requestInput); err != nil {
+			respondWithError(w, codeBadRequest, openAPIRequestErrorMessage(err))
+			return
+		}
+
+		// Hold the response until it has been validated
+		response := &bufferedResponse{header: w.Header()}
+		next.ServeHTTP(response, r)
+
+		responseInput := &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: requestInput,
+			Status:                 response.status,
+			Header:                 response.header,
+			Options:                openAPIOptions,
+		}
+		responseInput.SetBodyBytes(response.body.Bytes())
+		if err := openapi3filter.ValidateResponse(r.Context(), responseInput); err != nil {
+			v.onResponseError(r, err)
+		}
+		response.flush(w)
+	})
+}
+
+// documentsMediaType reports whether the request body of the route may have contentType
+func documentsMediaType(route *routers.Route, contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	body := route.Operation.RequestBody
+	return body != nil && body.Value != nil && body.Value.Content.Get(mediaType) != nil
+}
+
+// openAPIRequestErrorMessage describes why a request doesn't match the spec
+// without the schema dump kin-openapi appends to its errors
+func openAPIRequestErrorMessage(err error) string {
+	var requestErr *openapi3filter.RequestError
+	if !errors.As(err, &requestErr) {
+		return err.Error()
+	}
+
+	var schemaErr *openapi3.SchemaError
+	if errors.As(requestErr.Err, &schemaErr) {
+		if path := schemaErr.JSONPointer(); len(path) > 0 {
+			return fmt.Sprintf("invalid request: %s: %s", strings.Join(path, "."), schemaErr.Reason)
+		}
+		return "invalid request: " + schemaErr.Reason
+	}
+
+	var parseErr *openapi3filter.ParseError
+	if errors.As(requestErr.Err, &parseErr) {
+		return "invalid request: " + parseErr.Error()
+	}
+	return "invalid request: " + requestErr.Error()
+}
+
+// bufferedResponse is an http.ResponseWriter that keeps the response in memory
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *bufferedResponse) Write(data []byte) (int, error) {
+	b.WriteHeader(http.StatusOK)
+	return b.body.Write(data)
+}
+
+// flush sends the buffered response to w, which shares the header map
+func (b *bufferedResponse) flush(w http.ResponseWriter) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	w.WriteHeader(b.status)
+	if _, err := io.Copy(w, &b.body); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestOpenAPIValidatorMiddleware(t *testing.T) {
+	var reported []error
+	validator, err := newOpenAPIValidator(func(r *http.Request, err error) {
+		reported = append(reported, err)
+	})
+	if err != nil {
+		t.Fatalf("load openapi.yml: %v", err)
+	}
+
+	// Handlers that break the contract in different ways
+	router := chi.NewRouter()
+	router.Use(validator.middleware)
+	router.Get("/api/v1/team/get", func(w http.ResponseWriter, r *http.Request) {
+		respondWithJSON(w, http.StatusOK, map[string]interface{}{"team_name": 1, "members": []string{}})
+	})
+	router.Post("/api/v1/team/add", func(w http.ResponseWriter, r *http.Request) {
+		respondWithJSON(w, http.StatusTeapot, map[string]interface{}{"error": map[string]string{"code": "BAD_REQUEST", "message": "undocumented status"}})
+	})
+	router.Get("/api/v1/team/list", func(w http.ResponseWriter, r *http.Request) {
+		respondWithJSON(w, http.StatusOK, map[string]interface{}{"teams": []string{}})
+	})
+	router.Get("/api/v1/health/live", func(w http.ResponseWriter, r *http.Request) {
+		respondWithJSON(w, http.StatusOK, healthResponse{Status: healthOK})
+	})
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		status   int
+		reported string // Substring of the reported mismatch, empty if none is expected
+	}{
+		{"response body off schema", http.MethodGet, "/api/v1/team/get?team_name=backend", "", http.StatusOK, "doesn't match schema"},
+		{"undocumented status", http.MethodPost, "/api/v1/team/add", `{"team_name":"backend","members":[]}`, http.StatusTeapot, "status is not supported"},
+		{"undocumented route", http.MethodGet, "/api/v1/team/list", "", http.StatusOK, "route is not documented"},
+		{"unknown route", http.MethodGet, "/api/v1/nope", "", http.StatusNotFound, ""},
+		{"conforming response", http.MethodGet, "/api/v1/health/live", "", http.StatusOK, ""},
+		{"missing query parameter", http.MethodGet, "/api/v1/team/get", "", http.StatusBadRequest, ""},
+		{"body off schema", http.MethodPost, "/api/v1/team/add", `{"team_name":"backend"}`, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reported = nil
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d, body: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status == http.StatusBadRequest {
+				if got := responseErrorCode(decodeBody(t, rec)); got != "BAD_REQUEST" {
+					t.Errorf("error code = %q, want BAD_REQUEST", got)
+				}
+			}
+
+			switch {
+			case tt.reported == "" && len(reported) > 0:
+				t.Errorf("unexpected mismatch: %v", reported)
+			case tt.reported != "" && (len(reported) != 1 || !strings.Contains(reported[0].Error(), tt.reported)):
+				t.Errorf("reported %v, want one mismatch containing %q", reported, tt.reported)
+			}
+		})
+	}
+}
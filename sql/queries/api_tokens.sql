@@ -0,0 +1,24 @@
+-- name: GetActiveAPIToken :one
+SELECT name, token_hash, role, user_id, created_at, revoked_at
+FROM api_tokens
+WHERE token_hash = $1
+  AND revoked_at IS NULL;
+
+-- name: CreateAPIToken :one
+INSERT INTO api_tokens (name, token_hash, role, user_id)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (name) DO NOTHING
+RETURNING name, token_hash, role, user_id, created_at, revoked_at;
+
+-- name: EnsureAPIToken :exec
+INSERT INTO api_tokens (name, token_hash, role, user_id)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (name) DO UPDATE
+SET token_hash = EXCLUDED.token_hash, role = EXCLUDED.role, user_id = EXCLUDED.user_id, revoked_at = NULL;
+
+-- name: RevokeAPIToken :one
+UPDATE api_tokens
+SET revoked_at = now()
+WHERE name = $1
+  AND revoked_at IS NULL
+RETURNING name, token_hash, role, user_id, created_at, revoked_at;
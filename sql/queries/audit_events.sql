@@ -0,0 +1,16 @@
+-- name: InsertAuditEvent :exec
+INSERT INTO audit_events (actor, actor_user_id, action, entity_type, entity_id, before, after)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: ListAuditEvents :many
+SELECT id, occurred_at, actor, actor_user_id, action, entity_type, entity_id, before, after
+FROM audit_events
+WHERE (sqlc.narg('entity_type')::text IS NULL OR entity_type = sqlc.narg('entity_type'))
+  AND (sqlc.narg('entity_id')::text IS NULL OR entity_id = sqlc.narg('entity_id'))
+  AND (sqlc.narg('action')::text IS NULL OR action = sqlc.narg('action'))
+  AND (sqlc.narg('actor')::text IS NULL OR actor = sqlc.narg('actor'))
+  AND (sqlc.narg('occurred_from')::timestamptz IS NULL OR occurred_at >= sqlc.narg('occurred_from'))
+  AND (sqlc.narg('occurred_to')::timestamptz IS NULL OR occurred_at < sqlc.narg('occurred_to'))
+  AND (sqlc.narg('cursor_id')::bigint IS NULL OR id < sqlc.narg('cursor_id'))
+ORDER BY id DESC
+LIMIT sqlc.arg('page_limit');
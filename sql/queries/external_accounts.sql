@@ -0,0 +1,22 @@
+-- name: GetExternalAccountUser :one
+SELECT user_id
+FROM external_accounts
+WHERE provider = $1 AND login = $2;
+
+-- name: ListExternalAccounts :many
+SELECT provider, login, user_id, created_at
+FROM external_accounts
+WHERE sqlc.narg('provider')::text IS NULL OR provider = sqlc.narg('provider')
+ORDER BY provider, login;
+
+-- name: UpsertExternalAccount :one
+INSERT INTO external_accounts (provider, login, user_id)
+VALUES ($1, $2, $3)
+ON CONFLICT (provider, login) DO UPDATE
+SET user_id = EXCLUDED.user_id
+RETURNING provider, login, user_id, created_at;
+
+-- name: DeleteExternalAccount :one
+DELETE FROM external_accounts
+WHERE provider = $1 AND login = $2
+RETURNING provider, login, user_id, created_at;
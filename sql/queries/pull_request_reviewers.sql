@@ -10,18 +10,53 @@ WHERE r.pull_request_id = $1
 ORDER BY u.user_id;
 
 
+-- name: GetPRReviewerDetails :many
+SELECT u.user_id, u.username, u.team_name, u.is_active, r.state, r.reviewed_at
+FROM users u
+JOIN pull_request_reviewers r ON u.user_id = r.user_id
+WHERE r.pull_request_id = $1
+ORDER BY u.user_id;
+
+
 -- name: DeleteReviewer :exec
 DELETE FROM pull_request_reviewers
 WHERE pull_request_id = $1 AND user_id = $2;
 
 -- name: GetPRsForReviewer :many
-SELECT p.pull_request_id, p.pull_request_name, p.author_id, p.status
+SELECT p.pull_request_id, p.pull_request_name, p.author_id, p.status, r.state
 FROM pull_requests p
 JOIN pull_request_reviewers r ON p.pull_request_id = r.pull_request_id
 WHERE r.user_id = $1
+  AND (sqlc.narg('state')::review_state IS NULL OR r.state = sqlc.narg('state'))
 ORDER BY p.created_at DESC;
 
 -- name: IsReviewerAssigned :one
 SELECT COUNT(*) > 0
 FROM pull_request_reviewers
-WHERE pull_request_id = $1 AND user_id = $2;
\ No newline at end of file
+WHERE pull_request_id = $1 AND user_id = $2;
+
+-- name: SetReviewState :one
+UPDATE pull_request_reviewers
+SET state = $3, reviewed_at = now()
+WHERE pull_request_id = $1 AND user_id = $2
+RETURNING pull_request_id, user_id, state, reviewed_at;
+
+-- name: CountApprovals :one
+SELECT COUNT(*)
+FROM pull_request_reviewers
+WHERE pull_request_id = $1 AND state = 'APPROVED';
+
+-- name: GetOpenAssignmentsForReviewers :many
+SELECT r.pull_request_id, r.user_id, p.author_id, a.team_name AS author_team_name
+FROM pull_request_reviewers r
+JOIN pull_requests p ON p.pull_request_id = r.pull_request_id
+JOIN users a ON a.user_id = p.author_id
+WHERE r.user_id = ANY(sqlc.arg(user_ids)::text[])
+  AND p.status = 'OPEN'
+ORDER BY p.created_at, r.pull_request_id, r.user_id;
+
+-- name: GetReviewersForPRs :many
+SELECT pull_request_id, user_id
+FROM pull_request_reviewers
+WHERE pull_request_id = ANY(sqlc.arg(pull_request_ids)::text[])
+ORDER BY pull_request_id, user_id;
\ No newline at end of file
@@ -1,25 +1,55 @@
 -- name: CreatePR :exec
-INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, status, created_at)
-VALUES ($1,$2,$3,'OPEN', NOW());
+INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, status, created_at, project_path)
+VALUES ($1,$2,$3,'OPEN', NOW(), $4);
 
 -- name: GetPR :one
-SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at
+SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at, closed_at, project_path, first_reviewed_at
 FROM pull_requests
 WHERE pull_request_id = $1;
 
+-- name: GetPRForUpdate :one
+-- Locks the PR row until the end of the transaction, so concurrent changes to
+-- the same PR and its reviewers run one after another
+SELECT pull_request_id, pull_request_name, author_id, status, created_at, merged_at, closed_at, project_path, first_reviewed_at
+FROM pull_requests
+WHERE pull_request_id = $1
+FOR UPDATE;
+
 
 -- name: SetPRMerged :one
 UPDATE pull_requests
 SET status='MERGED', merged_at = now()
 WHERE pull_request_id = $1
-RETURNING pull_request_id, pull_request_name, author_id, status, created_at, merged_at;
+RETURNING pull_request_id, pull_request_name, author_id, status, created_at, merged_at, closed_at, project_path, first_reviewed_at;
+
+-- name: SetPRClosed :one
+UPDATE pull_requests
+SET status='CLOSED', closed_at = now()
+WHERE pull_request_id = $1
+RETURNING pull_request_id, pull_request_name, author_id, status, created_at, merged_at, closed_at, project_path, first_reviewed_at;
+
+-- name: SetPRFirstReviewed :exec
+-- Set once, later verdicts and reassignments don't move it
+UPDATE pull_requests
+SET first_reviewed_at = now()
+WHERE pull_request_id = $1 AND first_reviewed_at IS NULL;
+
+-- name: SetPRReopened :one
+UPDATE pull_requests
+SET status='OPEN', closed_at = NULL
+WHERE pull_request_id = $1
+RETURNING pull_request_id, pull_request_name, author_id, status, created_at, merged_at, closed_at, project_path, first_reviewed_at;
 
 -- name: GetActiveReviewersForTeam :many
-SELECT user_id
-FROM users
-WHERE team_name = $1
-AND is_active = TRUE
-AND user_id <> $2;
+SELECT u.user_id, COUNT(p.pull_request_id) AS open_reviews
+FROM users u
+LEFT JOIN pull_request_reviewers r ON r.user_id = u.user_id
+LEFT JOIN pull_requests p ON p.pull_request_id = r.pull_request_id AND p.status = 'OPEN'
+WHERE u.team_name = $1
+  AND u.is_active = TRUE
+  AND u.user_id <> $2
+GROUP BY u.user_id
+ORDER BY u.user_id;
 
 -- name: IsMerged :one
 SELECT COUNT(*) > 0
@@ -27,8 +57,10 @@ FROM pull_requests
 WHERE pull_request_id = $1 AND status ='MERGED';
 
 -- name: GetEligibleReassignReviewers :many
-SELECT u.user_id
+SELECT u.user_id, COUNT(p.pull_request_id) AS open_reviews
 FROM users u
+LEFT JOIN pull_request_reviewers r ON r.user_id = u.user_id
+LEFT JOIN pull_requests p ON p.pull_request_id = r.pull_request_id AND p.status = 'OPEN'
 WHERE u.team_name = $1
   AND u.is_active = TRUE
   AND u.user_id <> $2
@@ -36,4 +68,36 @@ WHERE u.team_name = $1
       SELECT prr.user_id
       FROM pull_request_reviewers prr
       WHERE prr.pull_request_id = $3
-  );
\ No newline at end of file
+  )
+  AND u.user_id NOT IN (
+      SELECT pr.author_id
+      FROM pull_requests pr
+      WHERE pr.pull_request_id = $3
+  )
+GROUP BY u.user_id
+ORDER BY u.user_id;
+
+-- name: ListPRs :many
+SELECT p.pull_request_id, p.pull_request_name, p.author_id, p.status, p.created_at, p.merged_at, p.closed_at, p.project_path, p.first_reviewed_at
+FROM pull_requests p
+JOIN users a ON a.user_id = p.author_id
+WHERE (sqlc.narg('status')::pr_status IS NULL OR p.status = sqlc.narg('status'))
+  AND (sqlc.narg('author_id')::text IS NULL OR p.author_id = sqlc.narg('author_id'))
+  AND (sqlc.narg('team_name')::text IS NULL OR a.team_name = sqlc.narg('team_name'))
+  AND (sqlc.narg('reviewer_id')::text IS NULL OR EXISTS (
+      SELECT 1 FROM pull_request_reviewers r
+      WHERE r.pull_request_id = p.pull_request_id AND r.user_id = sqlc.narg('reviewer_id')
+  ))
+  AND (sqlc.narg('created_from')::timestamptz IS NULL OR p.created_at >= sqlc.narg('created_from'))
+  AND (sqlc.narg('created_to')::timestamptz IS NULL OR p.created_at < sqlc.narg('created_to'))
+  AND (sqlc.narg('name_query')::text IS NULL OR p.pull_request_name ILIKE '%' || sqlc.narg('name_query') || '%')
+  AND (sqlc.narg('cursor_created_at')::timestamptz IS NULL
+       OR (p.created_at, p.pull_request_id) < (sqlc.narg('cursor_created_at'), sqlc.narg('cursor_id')::text))
+ORDER BY p.created_at DESC, p.pull_request_id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: RenamePR :one
+UPDATE pull_requests
+SET pull_request_name = $2
+WHERE pull_request_id = $1
+RETURNING pull_request_id, pull_request_name, author_id, status, created_at, merged_at, closed_at, project_path, first_reviewed_at;
@@ -7,3 +7,80 @@ GROUP BY status;
 SELECT user_id, COUNT(*) AS count
 FROM pull_request_reviewers
 GROUP BY user_id;
+
+
+-- name: GetMergeTimeByTeam :many
+SELECT a.team_name,
+       COUNT(*) AS merged_count,
+       percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM p.merged_at - p.created_at))::float8 AS p50_seconds,
+       percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM p.merged_at - p.created_at))::float8 AS p90_seconds,
+       percentile_cont(0.99) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM p.merged_at - p.created_at))::float8 AS p99_seconds
+FROM pull_requests p
+JOIN users a ON a.user_id = p.author_id
+WHERE p.status = 'MERGED'
+  AND p.created_at IS NOT NULL
+  AND p.merged_at IS NOT NULL
+  AND (sqlc.narg('window_start')::timestamptz IS NULL OR p.merged_at >= sqlc.narg('window_start'))
+  AND (sqlc.narg('window_end')::timestamptz IS NULL OR p.merged_at < sqlc.narg('window_end'))
+GROUP BY a.team_name
+ORDER BY a.team_name;
+
+-- name: GetMergeTimeByAuthor :many
+SELECT p.author_id,
+       COUNT(*) AS merged_count,
+       percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM p.merged_at - p.created_at))::float8 AS p50_seconds,
+       percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM p.merged_at - p.created_at))::float8 AS p90_seconds,
+       percentile_cont(0.99) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM p.merged_at - p.created_at))::float8 AS p99_seconds
+FROM pull_requests p
+JOIN users a ON a.user_id = p.author_id
+WHERE p.status = 'MERGED'
+  AND p.created_at IS NOT NULL
+  AND p.merged_at IS NOT NULL
+  AND (sqlc.narg('window_start')::timestamptz IS NULL OR p.merged_at >= sqlc.narg('window_start'))
+  AND (sqlc.narg('window_end')::timestamptz IS NULL OR p.merged_at < sqlc.narg('window_end'))
+GROUP BY p.author_id
+ORDER BY p.author_id;
+
+-- name: GetFirstReviewTimeByTeam :many
+SELECT a.team_name,
+       COUNT(*) AS reviewed_count,
+       percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM p.first_reviewed_at - p.created_at))::float8 AS p50_seconds,
+       percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM p.first_reviewed_at - p.created_at))::float8 AS p90_seconds,
+       percentile_cont(0.99) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM p.first_reviewed_at - p.created_at))::float8 AS p99_seconds
+FROM pull_requests p
+JOIN users a ON a.user_id = p.author_id
+WHERE p.created_at IS NOT NULL
+  AND p.first_reviewed_at IS NOT NULL
+  AND (sqlc.narg('window_start')::timestamptz IS NULL OR p.first_reviewed_at >= sqlc.narg('window_start'))
+  AND (sqlc.narg('window_end')::timestamptz IS NULL OR p.first_reviewed_at < sqlc.narg('window_end'))
+GROUP BY a.team_name
+ORDER BY a.team_name;
+
+-- name: GetFirstReviewTimeByAuthor :many
+SELECT p.author_id,
+       COUNT(*) AS reviewed_count,
+       percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM p.first_reviewed_at - p.created_at))::float8 AS p50_seconds,
+       percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM p.first_reviewed_at - p.created_at))::float8 AS p90_seconds,
+       percentile_cont(0.99) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM p.first_reviewed_at - p.created_at))::float8 AS p99_seconds
+FROM pull_requests p
+JOIN users a ON a.user_id = p.author_id
+WHERE p.created_at IS NOT NULL
+  AND p.first_reviewed_at IS NOT NULL
+  AND (sqlc.narg('window_start')::timestamptz IS NULL OR p.first_reviewed_at >= sqlc.narg('window_start'))
+  AND (sqlc.narg('window_end')::timestamptz IS NULL OR p.first_reviewed_at < sqlc.narg('window_end'))
+GROUP BY p.author_id
+ORDER BY p.author_id;
+
+-- name: CountOpenPRs :one
+SELECT COUNT(*)
+FROM pull_requests
+WHERE status = 'OPEN';
+
+-- name: CountUnderstaffedOpenPRs :one
+SELECT COUNT(*)
+FROM pull_requests p
+JOIN users a ON a.user_id = p.author_id
+LEFT JOIN team_settings ts ON ts.team_name = a.team_name
+WHERE p.status = 'OPEN'
+  AND (SELECT COUNT(*) FROM pull_request_reviewers r WHERE r.pull_request_id = p.pull_request_id)
+      < COALESCE(ts.max_reviewers, sqlc.arg(default_max_reviewers)::int);
@@ -0,0 +1,18 @@
+-- name: GetTeamSettings :one
+SELECT team_name, min_reviewers, max_reviewers, strategy, round_robin_cursor, required_approvals
+FROM team_settings
+WHERE team_name = $1;
+
+-- name: UpsertTeamSettings :one
+INSERT INTO team_settings (team_name, min_reviewers, max_reviewers, strategy, required_approvals)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (team_name) DO UPDATE
+SET min_reviewers = EXCLUDED.min_reviewers, max_reviewers = EXCLUDED.max_reviewers,
+    strategy = EXCLUDED.strategy, required_approvals = EXCLUDED.required_approvals
+RETURNING team_name, min_reviewers, max_reviewers, strategy, round_robin_cursor, required_approvals;
+
+-- name: SetRoundRobinCursor :exec
+INSERT INTO team_settings (team_name, strategy, round_robin_cursor)
+VALUES ($1, 'round_robin', $2)
+ON CONFLICT (team_name) DO UPDATE
+SET round_robin_cursor = EXCLUDED.round_robin_cursor;
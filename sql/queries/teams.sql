@@ -10,4 +10,8 @@ INSERT INTO teams (team_name) VALUES ($1);
 
 
 -- name: GetTeam :one
-SELECT t.team_name FROM teams t WHERE t.team_name = $1;
\ No newline at end of file
+SELECT t.team_name FROM teams t WHERE t.team_name = $1;
+
+
+-- name: ListTeams :many
+SELECT t.team_name FROM teams t ORDER BY t.team_name;
\ No newline at end of file
@@ -9,8 +9,19 @@ SELECT u.user_id, u.username, u.team_name, u.is_active
 FROM users u
 WHERE u.user_id = $1;
 
+-- name: ListUsers :many
+SELECT u.user_id, u.username, u.team_name, u.is_active
+FROM users u
+ORDER BY u.user_id;
+
 -- name: SetUserActive :one
 UPDATE users
 SET is_active = $2
 WHERE user_id = $1
+RETURNING user_id, username, team_name, is_active;
+
+-- name: DeactivateUsers :many
+UPDATE users
+SET is_active = FALSE
+WHERE user_id = ANY(sqlc.arg(user_ids)::text[])
 RETURNING user_id, username, team_name, is_active;
\ No newline at end of file
@@ -0,0 +1,73 @@
+-- name: CreateWebhookSubscription :one
+INSERT INTO webhook_subscriptions (url, secret, event_types)
+VALUES ($1, $2, $3)
+RETURNING id, url, secret, event_types, is_active, created_at;
+
+-- name: GetWebhookSubscription :one
+SELECT id, url, secret, event_types, is_active, created_at
+FROM webhook_subscriptions
+WHERE id = $1;
+
+-- name: ListWebhookSubscriptions :many
+SELECT id, url, secret, event_types, is_active, created_at
+FROM webhook_subscriptions
+ORDER BY id;
+
+-- name: UpdateWebhookSubscription :one
+UPDATE webhook_subscriptions
+SET url = $2, event_types = $3, is_active = $4
+WHERE id = $1
+RETURNING id, url, secret, event_types, is_active, created_at;
+
+-- name: DeleteWebhookSubscription :one
+DELETE FROM webhook_subscriptions
+WHERE id = $1
+RETURNING id, url, secret, event_types, is_active, created_at;
+
+-- name: EnqueueOutboxEvent :execrows
+INSERT INTO outbox (subscription_id, event_id, event_type, payload)
+SELECT s.id, sqlc.arg(event_id)::text, sqlc.arg(event_type)::text, sqlc.arg(payload)::jsonb
+FROM webhook_subscriptions s
+WHERE s.is_active = TRUE
+  AND (cardinality(s.event_types) = 0 OR sqlc.arg(event_type)::text = ANY(s.event_types));
+
+-- name: ClaimOutboxBatch :many
+UPDATE outbox o
+SET next_attempt_at = now() + sqlc.arg(lease_seconds)::int * INTERVAL '1 second'
+FROM webhook_subscriptions s
+WHERE s.id = o.subscription_id
+  AND o.id IN (
+      SELECT d.id FROM outbox d
+      JOIN webhook_subscriptions ds ON ds.id = d.subscription_id
+      WHERE d.status = 'PENDING' AND d.next_attempt_at <= now() AND ds.is_active = TRUE
+      ORDER BY d.next_attempt_at, d.id
+      LIMIT sqlc.arg(batch_size)
+      FOR UPDATE OF d SKIP LOCKED
+  )
+RETURNING o.id, o.event_id, o.event_type, o.payload, o.attempts, s.url, s.secret;
+
+-- name: MarkOutboxDelivered :exec
+UPDATE outbox
+SET status = 'DELIVERED', attempts = attempts + 1, delivered_at = now(), last_error = NULL
+WHERE id = $1;
+
+-- name: MarkOutboxFailed :exec
+UPDATE outbox
+SET status = $2, attempts = attempts + 1, next_attempt_at = $3, last_error = $4
+WHERE id = $1;
+
+-- name: ListOutboxDeliveries :many
+SELECT id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_error, created_at, delivered_at
+FROM outbox
+WHERE (sqlc.narg('subscription_id')::bigint IS NULL OR subscription_id = sqlc.narg('subscription_id'))
+  AND (sqlc.narg('status')::outbox_status IS NULL OR status = sqlc.narg('status'))
+  AND (sqlc.narg('cursor_id')::bigint IS NULL OR id < sqlc.narg('cursor_id'))
+ORDER BY id DESC
+LIMIT sqlc.arg('page_limit');
+
+-- name: RetryOutboxDelivery :one
+UPDATE outbox
+SET status = 'PENDING', attempts = 0, next_attempt_at = now(), last_error = NULL
+WHERE id = $1
+  AND status = 'DEAD'
+RETURNING id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_error, created_at, delivered_at;
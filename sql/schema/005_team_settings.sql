@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TYPE reviewer_strategy AS ENUM ('random', 'least_loaded', 'round_robin');
+
+CREATE TABLE team_settings (
+team_name TEXT PRIMARY KEY REFERENCES teams(team_name) ON DELETE CASCADE,
+min_reviewers INTEGER NOT NULL DEFAULT 0 CHECK (min_reviewers >= 0),
+max_reviewers INTEGER NOT NULL DEFAULT 2 CHECK (max_reviewers >= min_reviewers),
+strategy reviewer_strategy NOT NULL DEFAULT 'least_loaded',
+round_robin_cursor TEXT
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS team_settings;
+DROP TYPE IF EXISTS reviewer_strategy;
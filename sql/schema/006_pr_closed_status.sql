@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TYPE pr_status ADD VALUE IF NOT EXISTS 'CLOSED';
+
+ALTER TABLE pull_requests ADD COLUMN closed_at TIMESTAMP WITH TIME ZONE;
+
+-- +goose Down
+UPDATE pull_requests SET status = 'OPEN' WHERE status = 'CLOSED';
+
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS closed_at;
+
+ALTER TYPE pr_status RENAME TO pr_status_old;
+CREATE TYPE pr_status AS ENUM ('OPEN', 'MERGED');
+ALTER TABLE pull_requests ALTER COLUMN status DROP DEFAULT;
+ALTER TABLE pull_requests ALTER COLUMN status TYPE pr_status USING status::text::pr_status;
+ALTER TABLE pull_requests ALTER COLUMN status SET DEFAULT 'OPEN';
+DROP TYPE pr_status_old;
@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TYPE review_state AS ENUM ('PENDING', 'APPROVED', 'CHANGES_REQUESTED');
+
+ALTER TABLE pull_request_reviewers
+ADD COLUMN state review_state NOT NULL DEFAULT 'PENDING',
+ADD COLUMN reviewed_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE team_settings
+ADD COLUMN required_approvals INTEGER NOT NULL DEFAULT 0 CHECK (required_approvals >= 0);
+
+-- +goose Down
+ALTER TABLE team_settings DROP COLUMN IF EXISTS required_approvals;
+
+ALTER TABLE pull_request_reviewers
+DROP COLUMN IF EXISTS reviewed_at,
+DROP COLUMN IF EXISTS state;
+
+DROP TYPE IF EXISTS review_state;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TYPE token_role AS ENUM ('admin', 'user');
+
+CREATE TABLE api_tokens (
+name TEXT PRIMARY KEY,
+token_hash TEXT NOT NULL UNIQUE,
+role token_role NOT NULL,
+user_id TEXT REFERENCES users(user_id) ON DELETE CASCADE,
+created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+revoked_at TIMESTAMP WITH TIME ZONE,
+CHECK (role = 'admin' OR user_id IS NOT NULL)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS api_tokens;
+DROP TYPE IF EXISTS token_role;
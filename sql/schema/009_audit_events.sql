@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE audit_events (
+id BIGSERIAL PRIMARY KEY,
+occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+actor TEXT NOT NULL,
+actor_user_id TEXT,
+action TEXT NOT NULL,
+entity_type TEXT NOT NULL,
+entity_id TEXT NOT NULL,
+before JSONB NOT NULL DEFAULT 'null',
+after JSONB NOT NULL DEFAULT 'null'
+);
+
+CREATE INDEX audit_events_entity_idx ON audit_events (entity_type, entity_id, id DESC);
+CREATE INDEX audit_events_occurred_at_idx ON audit_events (occurred_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS audit_events;
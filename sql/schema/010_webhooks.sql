@@ -0,0 +1,33 @@
+-- +goose Up
+CREATE TABLE webhook_subscriptions (
+id BIGSERIAL PRIMARY KEY,
+url TEXT NOT NULL,
+secret TEXT NOT NULL,
+event_types TEXT[] NOT NULL DEFAULT '{}',
+is_active BOOLEAN NOT NULL DEFAULT TRUE,
+created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE TYPE outbox_status AS ENUM ('PENDING', 'DELIVERED', 'DEAD');
+
+CREATE TABLE outbox (
+id BIGSERIAL PRIMARY KEY,
+subscription_id BIGINT NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+event_id TEXT NOT NULL,
+event_type TEXT NOT NULL,
+payload JSONB NOT NULL,
+status outbox_status NOT NULL DEFAULT 'PENDING',
+attempts INTEGER NOT NULL DEFAULT 0,
+next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+last_error TEXT,
+created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+delivered_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX outbox_due_idx ON outbox (next_attempt_at, id) WHERE status = 'PENDING';
+CREATE INDEX outbox_subscription_idx ON outbox (subscription_id, id DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS outbox;
+DROP TYPE IF EXISTS outbox_status;
+DROP TABLE IF EXISTS webhook_subscriptions;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE external_accounts (
+provider TEXT NOT NULL,
+login TEXT NOT NULL,
+user_id TEXT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+PRIMARY KEY (provider, login)
+);
+
+CREATE INDEX idx_external_accounts_user ON external_accounts(user_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_external_accounts_user;
+DROP TABLE IF EXISTS external_accounts;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE pull_requests ADD COLUMN project_path TEXT;
+
+-- +goose Down
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS project_path;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- Set by the first verdict and never changed, reviewer rows keep only the latest
+-- verdict and are deleted on reassignment
+ALTER TABLE pull_requests ADD COLUMN first_reviewed_at TIMESTAMP WITH TIME ZONE;
+
+-- Earliest verdict still on record, the best estimate for PRs reviewed before
+UPDATE pull_requests p
+SET first_reviewed_at = f.first_reviewed_at
+FROM (
+    SELECT pull_request_id, MIN(reviewed_at) AS first_reviewed_at
+    FROM pull_request_reviewers
+    WHERE state <> 'PENDING' AND reviewed_at IS NOT NULL
+    GROUP BY pull_request_id
+) f
+WHERE f.pull_request_id = p.pull_request_id;
+
+-- +goose Down
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS first_reviewed_at;
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"GODanilich/avito_backend/internal/database"
+	"GODanilich/avito_backend/internal/service"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// webhookEventTypes lists the event types a subscription can filter on
+// Events are enqueued by the review service together with the change
+var webhookEventTypes = []string{service.EventPRCreated, service.EventPRMerged, service.EventPRReassigned}
+
+const (
+	webhookDispatchInterval = time.Second      // How often the dispatcher polls the outbox
+	webhookBatchSize        = 20               // Deliveries claimed per poll
+	webhookLeaseSeconds     = 60               // How long a claimed delivery is hidden from other dispatchers
+	webhookRequestTimeout   = 10 * time.Second // Timeout of a single delivery attempt
+	webhookMaxAttempts      = 8                // Attempts before a delivery is moved to DEAD
+	webhookBaseBackoff      = 5 * time.Second  // Delay after the first failed attempt
+	webhookMaxBackoff       = time.Hour        // Upper bound for the retry delay
+	webhookSignatureHeader  = "X-Signature-256"
+	webhookDispatcherStale  = time.Minute // Readiness reports the dispatcher as stuck after this long without a finished poll
+)
+
+// randomHex returns n random bytes encoded as hex
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// signWebhookPayload returns the value of the signature header for a body
+func signWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff returns the delay before the next attempt after attempts failures
+func webhookBackoff(attempts int) time.Duration {
+	delay := webhookBaseBackoff
+	for i := 1; i < attempts && delay < webhookMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > webhookMaxBackoff {
+		delay = webhookMaxBackoff
+	}
+	return delay
+}
+
+// webhookDispatcher delivers outbox rows to subscriber URLs
+// Several instances can run against the same database: rows are claimed
+// with FOR UPDATE SKIP LOCKED and hidden for a lease while being delivered
+type webhookDispatcher struct {
+	db      database.Querier
+	client  *http.Client
+	metrics *appMetrics
+	status  *workerStatus // Reported by the readiness probe
+}
+
+func newWebhookDispatcher(db database.Querier, metrics *appMetrics) *webhookDispatcher {
+	return &webhookDispatcher{
+		db:      db,
+		client:  &http.Client{Timeout: webhookRequestTimeout},
+		metrics: metrics,
+		status:  newWorkerStatus(webhookDispatcherStale),
+	}
+}
+
+// Run polls the outbox until ctx is cancelled
+func (d *webhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(webhookDispatchInterval)
+	defer ticker.Stop()
+
+	d.status.start()
+	defer d.status.stop()
+
+	for {
+		// Drain due deliveries, a full batch means there may be more
+		for {
+			claimed, err := d.dispatchBatch(ctx)
+			if err != nil {
+				log.Printf("Webhook dispatch failed: %v", err)
+			}
+			d.status.record(err)
+			if err != nil || claimed < webhookBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatchBatch claims due deliveries and sends them concurrently
+func (d *webhookDispatcher) dispatchBatch(ctx context.Context) (int, error) {
+	deliveries, err := d.db.ClaimOutboxBatch(ctx, database.ClaimOutboxBatchParams{
+		LeaseSeconds: webhookLeaseSeconds,
+		BatchSize:    webhookBatchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func(delivery database.ClaimOutboxBatchRow) {
+			defer wg.Done()
+			d.handleDelivery(ctx, delivery)
+		}(delivery)
+	}
+	wg.Wait()
+
+	return len(deliveries), nil
+}
+
+// handleDelivery sends one delivery and records the outcome
+func (d *webhookDispatcher) handleDelivery(ctx context.Context, delivery database.ClaimOutboxBatchRow) {
+	sendErr := d.send(ctx, delivery)
+	if sendErr == nil {
+		// The subscriber has the event, record it even if shutdown started meanwhile
+		if err := d.db.MarkOutboxDelivered(context.WithoutCancel(ctx), delivery.ID); err != nil {
+			log.Printf("Failed to mark webhook delivery %d as delivered: %v", delivery.ID, err)
+		}
+		d.metrics.webhookDeliveries.WithLabelValues("delivered").Inc()
+		return
+	}
+
+	// Shutdown interrupted the attempt, it doesn't count against the subscriber.
+	// The delivery is claimed again once its lease expires
+	if ctx.Err() != nil {
+		log.Printf("Webhook delivery %d interrupted: %v", delivery.ID, sendErr)
+		return
+	}
+
+	// Retry with exponential backoff, give up after webhookMaxAttempts
+	attempts := int(delivery.Attempts) + 1
+	status := database.OutboxStatusPENDING
+	result := "retry"
+	if attempts >= webhookMaxAttempts {
+		status = database.OutboxStatusDEAD
+		result = "dead"
+	}
+	if err := d.db.MarkOutboxFailed(ctx, database.MarkOutboxFailedParams{
+		ID:            delivery.ID,
+		Status:        status,
+		NextAttemptAt: time.Now().Add(webhookBackoff(attempts)),
+		LastError:     sql.NullString{String: sendErr.Error(), Valid: true},
+	}); err != nil {
+		log.Printf("Failed to record webhook delivery %d failure: %v", delivery.ID, err)
+	}
+	d.metrics.webhookDeliveries.WithLabelValues(result).Inc()
+}
+
+// send posts the signed event to the subscriber, any non-2xx status is a failure
+func (d *webhookDispatcher) send(ctx context.Context, delivery database.ClaimOutboxBatchRow) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "avito-backend-webhooks")
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-ID", delivery.EventID)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(webhookSignatureHeader, signWebhookPayload(delivery.Secret, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
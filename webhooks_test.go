@@ -0,0 +1,237 @@
+package main
+
+import (
+	"GODanilich/avito_backend/internal/database"
+	"GODanilich/avito_backend/internal/storage/memory"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testWebhookSecret = "s3cret"
+
+// webhookReceiver is a subscriber endpoint that answers with status and records requests
+type webhookReceiver struct {
+	status atomic.Int32
+
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rcv *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rcv.mu.Lock()
+	rcv.requests = append(rcv.requests, r)
+	rcv.bodies = append(rcv.bodies, body)
+	rcv.mu.Unlock()
+	w.WriteHeader(int(rcv.status.Load()))
+}
+
+// newWebhookTest returns a dispatcher with one pr.created delivery to a receiver
+func newWebhookTest(t *testing.T) (*webhookDispatcher, *memory.Store, *webhookReceiver) {
+	t.Helper()
+	receiver := &webhookReceiver{}
+	receiver.status.Store(http.StatusNoContent)
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+
+	store := memory.New()
+	ctx := context.Background()
+	if _, err := store.CreateWebhookSubscription(ctx, database.CreateWebhookSubscriptionParams{
+		Url:    server.URL + "/hook",
+		Secret: testWebhookSecret,
+	}); err != nil {
+		t.Fatalf("create subscription: %v", err)
+	}
+	if _, err := store.EnqueueOutboxEvent(ctx, database.EnqueueOutboxEventParams{
+		EventID:   "evt-1",
+		EventType: "pr.created",
+		Payload:   []byte(`{"pull_request_id":"pr-1"}`),
+	}); err != nil {
+		t.Fatalf("enqueue event: %v", err)
+	}
+	return newWebhookDispatcher(store, newAppMetrics()), store, receiver
+}
+
+// outboxDelivery returns the only delivery of the store
+func outboxDelivery(t *testing.T, store *memory.Store) database.Outbox {
+	t.Helper()
+	deliveries, err := store.ListOutboxDeliveries(context.Background(), database.ListOutboxDeliveriesParams{PageLimit: 10})
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("deliveries = %v, %v, want one", deliveries, err)
+	}
+	return deliveries[0]
+}
+
+// expectBackoff checks the delivery is due about webhookBackoff(attempts) from now
+func expectBackoff(t *testing.T, delivery database.Outbox, attempts int) {
+	t.Helper()
+	delay := time.Until(delivery.NextAttemptAt)
+	if want := webhookBackoff(attempts); delay > want || delay < want-5*time.Second {
+		t.Errorf("attempt %d: next attempt in %v, want %v", attempts, delay, want)
+	}
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	d, store, receiver := newWebhookTest(t)
+
+	claimed, err := d.dispatchBatch(context.Background())
+	if err != nil || claimed != 1 {
+		t.Fatalf("dispatchBatch = %d, %v, want 1 delivery", claimed, err)
+	}
+	if delivery := outboxDelivery(t, store); delivery.Status != database.OutboxStatusDELIVERED || delivery.Attempts != 1 {
+		t.Errorf("delivery = %+v, want DELIVERED after 1 attempt", delivery)
+	}
+
+	if len(receiver.requests) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(receiver.requests))
+	}
+	req, body := receiver.requests[0], receiver.bodies[0]
+	if string(body) != `{"pull_request_id":"pr-1"}` {
+		t.Errorf("body = %s", body)
+	}
+	mac := hmac.New(sha256.New, []byte(testWebhookSecret))
+	mac.Write(body)
+	if got, want := req.Header.Get("X-Signature-256"), "sha256="+hex.EncodeToString(mac.Sum(nil)); !hmac.Equal([]byte(got), []byte(want)) {
+		t.Errorf("X-Signature-256 = %q, want %q", got, want)
+	}
+	for header, want := range map[string]string{
+		"Content-Type":       "application/json",
+		"X-Webhook-Event":    "pr.created",
+		"X-Webhook-ID":       "evt-1",
+		"X-Webhook-Delivery": "1",
+	} {
+		if got := req.Header.Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	// Delivered events aren't sent again
+	if claimed, _ := d.dispatchBatch(context.Background()); claimed != 0 {
+		t.Errorf("claimed %d deliveries after success, want 0", claimed)
+	}
+}
+
+func TestWebhookRetriesUntilDead(t *testing.T) {
+	d, store, receiver := newWebhookTest(t)
+	receiver.status.Store(http.StatusInternalServerError)
+	ctx := context.Background()
+
+	if claimed, err := d.dispatchBatch(ctx); err != nil || claimed != 1 {
+		t.Fatalf("dispatchBatch = %d, %v, want 1 delivery", claimed, err)
+	}
+	delivery := outboxDelivery(t, store)
+	if delivery.Status != database.OutboxStatusPENDING || delivery.Attempts != 1 || !strings.Contains(delivery.LastError.String, "500") {
+		t.Fatalf("delivery = %+v, want PENDING after 1 attempt with the status as error", delivery)
+	}
+	expectBackoff(t, delivery, 1)
+
+	// Not due until the backoff has passed
+	if claimed, _ := d.dispatchBatch(ctx); claimed != 0 {
+		t.Errorf("claimed %d deliveries during backoff, want 0", claimed)
+	}
+
+	sub, err := store.GetWebhookSubscription(ctx, delivery.SubscriptionID)
+	if err != nil {
+		t.Fatalf("get subscription: %v", err)
+	}
+
+	// Retry as the dispatcher would once each backoff has passed, the delay doubles every time
+	for attempts := 2; attempts <= webhookMaxAttempts; attempts++ {
+		d.handleDelivery(ctx, database.ClaimOutboxBatchRow{
+			ID:        delivery.ID,
+			EventID:   delivery.EventID,
+			EventType: delivery.EventType,
+			Payload:   delivery.Payload,
+			Attempts:  delivery.Attempts,
+			Url:       sub.Url,
+			Secret:    testWebhookSecret,
+		})
+		delivery = outboxDelivery(t, store)
+		if int(delivery.Attempts) != attempts {
+			t.Fatalf("attempts = %d, want %d", delivery.Attempts, attempts)
+		}
+		if attempts < webhookMaxAttempts {
+			if delivery.Status != database.OutboxStatusPENDING {
+				t.Fatalf("attempt %d: status = %s, want PENDING", attempts, delivery.Status)
+			}
+			expectBackoff(t, delivery, attempts)
+		}
+	}
+	if delivery.Status != database.OutboxStatusDEAD {
+		t.Errorf("status after %d attempts = %s, want DEAD", webhookMaxAttempts, delivery.Status)
+	}
+	if len(receiver.requests) != webhookMaxAttempts {
+		t.Errorf("receiver got %d requests, want %d", len(receiver.requests), webhookMaxAttempts)
+	}
+}
+
+// Events queued before a subscription was paused wait until it is resumed
+func TestWebhookPausedSubscription(t *testing.T) {
+	d, store, receiver := newWebhookTest(t)
+	ctx := context.Background()
+
+	delivery := outboxDelivery(t, store)
+	sub, err := store.GetWebhookSubscription(ctx, delivery.SubscriptionID)
+	if err != nil {
+		t.Fatalf("get subscription: %v", err)
+	}
+	setActive := func(active bool) {
+		t.Helper()
+		if _, err := store.UpdateWebhookSubscription(ctx, database.UpdateWebhookSubscriptionParams{
+			ID: sub.ID, Url: sub.Url, EventTypes: sub.EventTypes, IsActive: active,
+		}); err != nil {
+			t.Fatalf("update subscription: %v", err)
+		}
+	}
+
+	setActive(false)
+	if claimed, err := d.dispatchBatch(ctx); err != nil || claimed != 0 {
+		t.Fatalf("dispatchBatch = %d, %v, want nothing claimed while paused", claimed, err)
+	}
+	if delivery := outboxDelivery(t, store); delivery.Status != database.OutboxStatusPENDING || delivery.Attempts != 0 {
+		t.Errorf("delivery = %+v, want PENDING without attempts", delivery)
+	}
+	if len(receiver.requests) != 0 {
+		t.Errorf("receiver got %d requests while paused, want 0", len(receiver.requests))
+	}
+
+	setActive(true)
+	if claimed, err := d.dispatchBatch(ctx); err != nil || claimed != 1 {
+		t.Fatalf("dispatchBatch = %d, %v, want 1 delivery after resuming", claimed, err)
+	}
+	if delivery := outboxDelivery(t, store); delivery.Status != database.OutboxStatusDELIVERED {
+		t.Errorf("status = %s, want DELIVERED", delivery.Status)
+	}
+}
+
+// An attempt cut short by shutdown isn't counted as a failure
+func TestWebhookDeliveryInterruptedByShutdown(t *testing.T) {
+	d, store, receiver := newWebhookTest(t)
+	rows, err := store.ClaimOutboxBatch(context.Background(), database.ClaimOutboxBatchParams{LeaseSeconds: webhookLeaseSeconds, BatchSize: 1})
+	if err != nil || len(rows) != 1 {
+		t.Fatalf("claim = %v, %v", rows, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d.handleDelivery(ctx, rows[0])
+
+	delivery := outboxDelivery(t, store)
+	if delivery.Status != database.OutboxStatusPENDING || delivery.Attempts != 0 || delivery.LastError.Valid {
+		t.Errorf("delivery = %+v, want PENDING without attempts", delivery)
+	}
+	if len(receiver.requests) != 0 {
+		t.Errorf("receiver got %d requests, want 0", len(receiver.requests))
+	}
+}